@@ -0,0 +1,122 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AccessGrantReasonActive means the rule of the grant is enforced ahead of the policy of the peering.
+	AccessGrantReasonActive = "Active"
+
+	// AccessGrantReasonExpired means the TTL of the grant has elapsed, and its rule has been removed.
+	AccessGrantReasonExpired = "Expired"
+
+	// AccessGrantReasonNoPeering means the peering the grant refers to is not established, or has no
+	// PeeringConnectivity the rule can be injected into.
+	AccessGrantReasonNoPeering = "NoPeering"
+)
+
+// AccessGrantSpec defines the desired state of AccessGrant.
+type AccessGrantSpec struct {
+	// ClusterID is the ID of the remote cluster of the peering the access is granted on.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	ClusterID string `json:"clusterID"`
+
+	// Rule is the rule injected into the effective policy of the peering, ahead of its own rules.
+	// It cannot be changed: a different access requires a new grant.
+	// +required
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="rule is immutable"
+	Rule Rule `json:"rule"`
+
+	// TTL is how long the access is granted for, starting from the creation of the grant.
+	// It must be positive and at most a week, and cannot be changed to extend the access.
+	// +required
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s') && duration(self) <= duration('168h')",message="ttl must be positive and at most 168h"
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="ttl is immutable"
+	TTL metav1.Duration `json:"ttl"`
+
+	// Reason records why the access is granted, e.g. the reference of an incident.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Reason string `json:"reason"`
+}
+
+// AccessGrantStatus defines the observed state of AccessGrant.
+type AccessGrantStatus struct {
+	// Conditions represent the current state of the AccessGrant resource.
+	//
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// ObservedGeneration is the last observed generation of the AccessGrant resource.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ExpiresAt is the time the access expires, after which the rule is removed.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// Namespace is the tenant namespace of the peering the access is granted on.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterID`
+// +kubebuilder:printcolumn:name="Expires",type=date,JSONPath=`.status.expiresAt`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.spec.reason`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// AccessGrant is the Schema for the accessgrants API.
+// It grants a short-lived access on a peering without editing its PeeringConnectivity resources:
+// its rule is evaluated ahead of the chain of the tenant namespace of the peering until its TTL
+// elapses, after which it is removed automatically. The grant is kept once expired, for auditing.
+// The grant cannot bypass the restrictions of the NamespacePeeringPolicy resources.
+type AccessGrant struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Metadata is standard Kubernetes object metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines the peering, the rule and the duration of the grant.
+	// +required
+	Spec AccessGrantSpec `json:"spec"`
+
+	// Status reports whether the grant is active and when it expires.
+	// +optional
+	Status AccessGrantStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AccessGrantList contains a list of AccessGrant resources.
+type AccessGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AccessGrant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AccessGrant{}, &AccessGrantList{})
+}
@@ -0,0 +1,130 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NamespacePolicyType is a direction of the cross-cluster traffic of the pods of a namespace
+// restricted by a NamespacePeeringPolicy.
+//
+// +kubebuilder:validation:Enum=Ingress;Egress
+type NamespacePolicyType string
+
+const (
+	// NamespacePolicyTypeIngress restricts the traffic received by the pods of the namespace through the peering.
+	NamespacePolicyTypeIngress NamespacePolicyType = "Ingress"
+
+	// NamespacePolicyTypeEgress restricts the traffic sent by the pods of the namespace through the peering.
+	NamespacePolicyTypeEgress NamespacePolicyType = "Egress"
+)
+
+const (
+	// NamespacePolicyReasonEnforced means the policy is enforced on at least one peering.
+	NamespacePolicyReasonEnforced = "Enforced"
+
+	// NamespacePolicyReasonNoPeering means no established peering with a PeeringConnectivity matches the policy.
+	NamespacePolicyReasonNoPeering = "NoPeering"
+
+	// NamespacePolicyReasonNoRestriction means the policy does not restrict any direction.
+	NamespacePolicyReasonNoRestriction = "NoRestriction"
+)
+
+// NamespacePeeringPolicySpec defines the desired state of NamespacePeeringPolicy.
+// It lists the parties the pods of the namespace are allowed to exchange cross-cluster traffic with.
+// The policy can only restrict the traffic allowed by the PeeringConnectivity resources of the tenant
+// namespace of the peering, never widen it.
+type NamespacePeeringPolicySpec struct {
+	// ClusterIDs restricts the policy to the peerings with the given clusters.
+	// If omitted, the policy applies to all the peerings.
+	// +optional
+	// +listType=set
+	ClusterIDs []string `json:"clusterIDs,omitempty"`
+
+	// Ingress lists the parties allowed to send traffic to the pods of the namespace through the peering.
+	// +optional
+	Ingress []Party `json:"ingress,omitempty"`
+
+	// Egress lists the parties the pods of the namespace are allowed to send traffic to through the peering.
+	// +optional
+	Egress []Party `json:"egress,omitempty"`
+
+	// PolicyTypes lists the directions restricted by the policy. A restricted direction without
+	// parties blocks all the cross-cluster traffic of the pods of the namespace in that direction.
+	// If omitted, the directions with at least one party are restricted.
+	// +optional
+	// +listType=set
+	PolicyTypes []NamespacePolicyType `json:"policyTypes,omitempty"`
+}
+
+// NamespacePeeringPolicyStatus defines the observed state of NamespacePeeringPolicy.
+type NamespacePeeringPolicyStatus struct {
+	// Conditions represent the current state of the NamespacePeeringPolicy resource.
+	//
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// ObservedGeneration is the last observed generation of the NamespacePeeringPolicy resource.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Peerings lists the IDs of the remote clusters of the peerings the policy is enforced on.
+	// +optional
+	// +listType=set
+	Peerings []string `json:"peerings,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Types",type=string,JSONPath=`.spec.policyTypes`
+// +kubebuilder:printcolumn:name="Peerings",type=string,JSONPath=`.status.peerings`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// NamespacePeeringPolicy is the Schema for the namespacepeeringpolicies API.
+// It allows the owners of an application namespace to further restrict the cross-cluster traffic
+// of their pods, without write access to the tenant namespaces of the peerings. The policy is
+// enforced by the gateway in a dedicated chain evaluated after the one of the peering, and by the
+// NetworkPolicy generated in the namespace, if any.
+type NamespacePeeringPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Metadata is standard Kubernetes object metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines the peerings the policy applies to and the traffic it allows.
+	// +required
+	Spec NamespacePeeringPolicySpec `json:"spec"`
+
+	// Status reports the peerings the policy is enforced on.
+	// +optional
+	Status NamespacePeeringPolicyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// NamespacePeeringPolicyList contains a list of NamespacePeeringPolicy resources.
+type NamespacePeeringPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NamespacePeeringPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NamespacePeeringPolicy{}, &NamespacePeeringPolicyList{})
+}
@@ -22,7 +22,7 @@ import (
 // It categorizes different types of pods and network entities to enable fine-grained
 // network connectivity policy management across cluster boundaries.
 //
-// +kubebuilder:validation:Enum=local-cluster;remote-cluster;leaf;offloaded;slice-local;slice-remote;internet;nameserver
+// +kubebuilder:validation:Enum=local-cluster;remote-cluster;leaf;offloaded;slice-local;slice-remote;internet;nameserver;any-dns;local-services;remote-services;local-nodes
 type ResourceGroup string
 
 const (
@@ -54,24 +54,84 @@ const (
 	// This group is used to match traffic destined to public IP addresses.
 	ResourceGroupInternet ResourceGroup = "internet"
 
-	// Nameserver represents ANY NAMESERVER since it matches against port 53, which is used for DNS queries.
+	// Nameserver represents the cluster DNS service, i.e., its addresses and endpoints on the DNS ports.
 	ResourceGroupNameserver ResourceGroup = "nameserver"
+
+	// AnyDNS represents ANY NAMESERVER since it matches against port 53, which is used for DNS queries.
+	ResourceGroupAnyDNS ResourceGroup = "any-dns"
+
+	// ResourceGroupLocalServices represents the virtual IPs of the Services of the local cluster,
+	// i.e., its service CIDR.
+	ResourceGroupLocalServices ResourceGroup = "local-services"
+
+	// ResourceGroupRemoteServices represents the Services reflected on the provider cluster
+	// by the consumer cluster, i.e., their virtual IPs and their endpoints.
+	// For provider only!
+	ResourceGroupRemoteServices ResourceGroup = "remote-services"
+
+	// ResourceGroupLocalNodes represents the addresses of the nodes of the local cluster,
+	// including the host-network pods. Virtual nodes are excluded.
+	ResourceGroupLocalNodes ResourceGroup = "local-nodes"
 )
 
 // Action defines the action to take when a firewall rule matches network traffic.
 //
-// +kubebuilder:validation:Enum=allow
+// +kubebuilder:validation:Enum=allow;deny
 type Action string
 
 const (
 	// ActionAllow permits the matched network traffic to pass through.
 	ActionAllow Action = "allow"
+	// ActionDeny drops the matched network traffic.
+	ActionDeny Action = "deny"
 )
 
+// Direction defines which connections between the source and the destination of a rule it matches.
+// The direction is relative to the source of the rule: its egress are the connections it initiates
+// towards the destination, its ingress the ones the destination initiates towards it.
+//
+// +kubebuilder:validation:Enum=Egress;Ingress;Bidirectional
+type Direction string
+
+const (
+	// DirectionEgress matches the connections initiated by the source towards the destination.
+	DirectionEgress Direction = "Egress"
+
+	// DirectionIngress matches the connections initiated by the destination towards the source.
+	DirectionIngress Direction = "Ingress"
+
+	// DirectionBidirectional matches the connections initiated by either party towards the other.
+	DirectionBidirectional Direction = "Bidirectional"
+)
+
+// ServiceReference identifies a Kubernetes Service and, optionally, a subset of its ports.
+type ServiceReference struct {
+	// Namespace is the namespace of the Service.
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the Service.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Ports restricts the matched traffic to the ports of the Service with the given names.
+	// If omitted, the traffic on any port is matched.
+	// +optional
+	// +listType=set
+	Ports []string `json:"ports,omitempty"`
+}
+
+// FQDN is a fully qualified domain name, optionally starting with a wildcard label (e.g., *.example.com).
+//
+// +kubebuilder:validation:MinLength=1
+// +kubebuilder:validation:MaxLength=253
+// +kubebuilder:validation:Pattern=`^(\*\.)?([a-zA-Z0-9]([-a-zA-Z0-9]{0,61}[a-zA-Z0-9])?\.)*[a-zA-Z0-9]([-a-zA-Z0-9]{0,61}[a-zA-Z0-9])?\.?$`
+type FQDN string
+
 // Party defines a participant in a network connectivity rule.
 // A party can represent either the source or destination of network traffic.
 //
-// +kubebuilder:validation:XValidation:rule="(has(self.group) ? 1 : 0) + (has(self.__namespace__) ? 1 : 0) == 1",message="exactly one of group or namespace must be set"
+// +kubebuilder:validation:XValidation:rule="(has(self.group) ? 1 : 0) + (has(self.__namespace__) ? 1 : 0) + (has(self.service) ? 1 : 0) + (has(self.fqdn) ? 1 : 0) == 1",message="exactly one of group, namespace, service or fqdn must be set"
 type Party struct {
 	// Group defines the resource group of this party.
 	// It identifies which set of pods or resources this party represents.
@@ -79,6 +139,40 @@ type Party struct {
 
 	// Namespace specifies the Kubernetes namespace associated with this party.
 	Namespace *string `json:"namespace,omitempty"`
+
+	// Service specifies the Kubernetes Service associated with this party.
+	// It matches both the virtual IPs of the Service and its backing endpoints.
+	Service *ServiceReference `json:"service,omitempty"`
+
+	// FQDN specifies the domain name associated with this party.
+	// It matches the addresses the name resolves to, which are refreshed according to the TTL of the records.
+	// A wildcard (e.g., *.example.com) matches the addresses of the wildcard record of the zone.
+	FQDN *FQDN `json:"fqdn,omitempty"`
+}
+
+// ScheduleWindow is a recurring time window.
+type ScheduleWindow struct {
+	// Start is a cron expression with five fields (minute, hour, day of month, month and day of week)
+	// defining when the window opens, e.g. "0 22 * * mon-fri". The @yearly, @monthly, @weekly, @daily
+	// and @hourly shorthands are supported as well.
+	// +kubebuilder:validation:MinLength=1
+	Start string `json:"start"`
+
+	// Duration is how long the window stays open after each start, e.g. "2h30m".
+	Duration metav1.Duration `json:"duration"`
+}
+
+// RuleSchedule restricts a rule to recurring time windows.
+type RuleSchedule struct {
+	// Windows are the time windows in which the rule is active.
+	// The rule is active while any of them is open.
+	// +kubebuilder:validation:MinItems=1
+	Windows []ScheduleWindow `json:"windows"`
+
+	// TimeZone is the IANA name of the time zone the windows are expressed in (e.g., Europe/Rome).
+	// +optional
+	// +kubebuilder:default=UTC
+	TimeZone string `json:"timeZone,omitempty"`
 }
 
 // Rule defines a network connectivity rule for peering scenarios.
@@ -95,6 +189,105 @@ type Rule struct {
 	// Destination defines the destination party for the traffic.
 	// If omitted, the rule applies to traffic to any destination.
 	Destination *Party `json:"destination,omitempty"`
+
+	// Direction defines which connections between the source and the destination the rule matches,
+	// relative to the source. The return traffic of the matched connections is accepted according to
+	// the conntrack settings of the gateway.
+	// +optional
+	// +kubebuilder:default=Egress
+	Direction Direction `json:"direction,omitempty"`
+
+	// Schedule restricts the rule to recurring time windows, outside of which it is not enforced.
+	// If omitted, the rule is always active.
+	// +optional
+	Schedule *RuleSchedule `json:"schedule,omitempty"`
+}
+
+// InterfaceName is the name of a network interface of the gateway.
+//
+// +kubebuilder:validation:MinLength=1
+// +kubebuilder:validation:MaxLength=15
+// +kubebuilder:validation:Pattern=`^[^/:\s]+$`
+type InterfaceName string
+
+// ConntrackMode defines whether the gateway tracks the connections of the peering.
+//
+// +kubebuilder:validation:Enum=Stateful;Stateless
+type ConntrackMode string
+
+const (
+	// ConntrackModeStateful accepts the traffic of the established connections, so that allowing
+	// a direction implicitly allows its return traffic.
+	ConntrackModeStateful ConntrackMode = "Stateful"
+
+	// ConntrackModeStateless filters every packet with the connectivity rules, so that each
+	// direction of the traffic must be allowed explicitly.
+	ConntrackModeStateless ConntrackMode = "Stateless"
+)
+
+// ExistingConnectionsPolicy defines how the deny rules apply to the connections established
+// before they were added.
+//
+// +kubebuilder:validation:Enum=Keep;Reevaluate
+type ExistingConnectionsPolicy string
+
+const (
+	// ExistingConnectionsKeep accepts the traffic of the established connections before evaluating
+	// any rule, so that they survive the addition of a deny rule.
+	ExistingConnectionsKeep ExistingConnectionsPolicy = "Keep"
+
+	// ExistingConnectionsReevaluate applies the deny rules to the traffic of the established
+	// connections too, so that the ones matching a newly added deny rule are cut.
+	ExistingConnectionsReevaluate ExistingConnectionsPolicy = "Reevaluate"
+)
+
+// ConntrackSettings overrides the operator defaults for the connection tracking of the gateway.
+// Omitted fields fall back to the operator configuration.
+type ConntrackSettings struct {
+	// Mode defines whether the traffic of the established connections is accepted without
+	// evaluating the connectivity rules.
+	// +optional
+	Mode ConntrackMode `json:"mode,omitempty"`
+
+	// Related defines whether the traffic related to an established connection (e.g., ICMP errors)
+	// is accepted as well. Ignored in Stateless mode.
+	// +optional
+	Related *bool `json:"related,omitempty"`
+
+	// DropInvalid defines whether the traffic not belonging to any valid connection is dropped
+	// before evaluating the connectivity rules.
+	// +optional
+	DropInvalid *bool `json:"dropInvalid,omitempty"`
+
+	// ExistingConnections defines whether the deny rules apply to the traffic of the established
+	// connections. Ignored in Stateless mode, where they always do.
+	// +optional
+	ExistingConnections ExistingConnectionsPolicy `json:"existingConnections,omitempty"`
+}
+
+// GatewaySettings overrides the operator defaults for the preamble rules of the gateway
+// firewall configuration, which are evaluated before the connectivity rules.
+// Omitted fields fall back to the operator configuration.
+type GatewaySettings struct {
+	// BypassNonTunnelTraffic defines whether the traffic not received from the tunnel interface
+	// bypasses the connectivity rules.
+	// +optional
+	BypassNonTunnelTraffic *bool `json:"bypassNonTunnelTraffic,omitempty"`
+
+	// BypassUplinkTraffic defines whether the traffic leaving through the uplink interfaces
+	// bypasses the connectivity rules.
+	// +optional
+	BypassUplinkTraffic *bool `json:"bypassUplinkTraffic,omitempty"`
+
+	// UplinkInterfaces are the names of the interfaces connecting the gateway to the local cluster.
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:MaxItems=16
+	UplinkInterfaces []InterfaceName `json:"uplinkInterfaces,omitempty"`
+
+	// Conntrack defines how the gateway handles the state of the connections of the peering.
+	// +optional
+	Conntrack *ConntrackSettings `json:"conntrack,omitempty"`
 }
 
 // PeeringConnectivitySpec defines the desired state of PeeringConnectivity.
@@ -105,6 +298,88 @@ type PeeringConnectivitySpec struct {
 	// Rules are evaluated in order, and the first matching rule determines
 	// whether traffic is allowed or denied.
 	Rules []Rule `json:"rules,omitempty"`
+
+	// Gateway overrides the operator defaults for the gateway firewall configuration.
+	// +optional
+	Gateway *GatewaySettings `json:"gateway,omitempty"`
+
+	// Priority orders the PeeringConnectivity resources of the same tenant namespace, which are merged
+	// into a single chain: the rules of the ones with a higher priority are evaluated first, and each
+	// gateway setting is taken from the one with the highest priority setting it.
+	// Resources with the same priority are ordered by name.
+	// +optional
+	// +kubebuilder:default=0
+	Priority int32 `json:"priority,omitempty"`
+}
+
+// ChainPlacement reports where the rules of a PeeringConnectivity landed in the effective chain
+// of its tenant namespace.
+type ChainPlacement struct {
+	// Position is the index of the PeeringConnectivity among the ones merged into the chain.
+	Position int32 `json:"position"`
+
+	// FirstRule is the index in the chain of the first rule of the PeeringConnectivity.
+	FirstRule int32 `json:"firstRule"`
+
+	// Rules is the number of rules of the PeeringConnectivity.
+	Rules int32 `json:"rules"`
+
+	// ChainLength is the total number of rules in the chain.
+	ChainLength int32 `json:"chainLength"`
+
+	// Chain lists the PeeringConnectivity resources merged into the chain, in order.
+	// +optional
+	Chain []string `json:"chain,omitempty"`
+
+	// Conflicts lists the gateway settings overridden by a PeeringConnectivity with a higher priority.
+	// +optional
+	Conflicts []string `json:"conflicts,omitempty"`
+
+	// Grants lists the active AccessGrant resources whose rules are evaluated ahead of the chain,
+	// which are counted in the rule indexes and the length of the chain.
+	// +optional
+	Grants []string `json:"grants,omitempty"`
+}
+
+// RuleScheduleStatus reports whether a scheduled rule is active.
+type RuleScheduleStatus struct {
+	// Rule is the index of the rule in the spec.
+	Rule int32 `json:"rule"`
+
+	// Active is whether the rule is currently enforced.
+	Active bool `json:"active"`
+
+	// NextTransition is the next time a window of the rule opens or closes, at which it is evaluated again.
+	// +optional
+	NextTransition *metav1.Time `json:"nextTransition,omitempty"`
+
+	// Message reports why the schedule cannot be evaluated, in which case the rule is not enforced.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// RuleCounterStatus reports the traffic matched by a rule at the gateway.
+type RuleCounterStatus struct {
+	// Rule is the index of the rule in the spec.
+	Rule int32 `json:"rule"`
+
+	// Packets is the number of packets matched by the rule since it was last applied.
+	Packets int64 `json:"packets"`
+
+	// Bytes is the number of bytes matched by the rule since it was last applied.
+	Bytes int64 `json:"bytes"`
+}
+
+// CounterStatus reports the traffic matched by the rules of the PeeringConnectivity at the gateway.
+type CounterStatus struct {
+	// LastUpdate is the time the counters were read.
+	LastUpdate metav1.Time `json:"lastUpdate"`
+
+	// Rules reports the counters of the rules currently enforced at the gateway.
+	// +optional
+	// +listType=map
+	// +listMapKey=rule
+	Rules []RuleCounterStatus `json:"rules,omitempty"`
 }
 
 // PeeringConnectivityStatus defines the observed state of PeeringConnectivity.
@@ -123,10 +398,58 @@ type PeeringConnectivityStatus struct {
 	// ObservedGeneration is the last observed generation of the PeeringConnectivity resource.
 	// It is used to track whether the status reflects the latest spec changes.
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Placement reports where the rules of the PeeringConnectivity landed in the effective chain
+	// of its tenant namespace.
+	// +optional
+	Placement *ChainPlacement `json:"placement,omitempty"`
+
+	// Schedules reports whether each of the scheduled rules of the PeeringConnectivity is active.
+	// +optional
+	// +listType=map
+	// +listMapKey=rule
+	Schedules []RuleScheduleStatus `json:"schedules,omitempty"`
+
+	// Counters reports the traffic matched by each rule at the gateway, if the counters are read.
+	// +optional
+	Counters *CounterStatus `json:"counters,omitempty"`
+
+	// CurrentRevision is the number of the revision of the spec currently applied.
+	// +optional
+	CurrentRevision int64 `json:"currentRevision,omitempty"`
+
+	// Revisions lists the recorded revisions of the spec, from the oldest to the current one.
+	// The spec can be rolled back to any of them with the connectivity.liqo.io/rollback-to annotation.
+	// +optional
+	// +listType=map
+	// +listMapKey=revision
+	Revisions []RevisionStatus `json:"revisions,omitempty"`
+}
+
+// RevisionStatus describes a revision of the spec of a PeeringConnectivity, recorded as a ControllerRevision
+// when it was applied.
+type RevisionStatus struct {
+	// Revision is the number of the revision, increasing each time a different spec is applied.
+	Revision int64 `json:"revision"`
+
+	// Name is the name of the ControllerRevision storing the spec.
+	Name string `json:"name"`
+
+	// FirewallHash is the hash of the spec of the gateway FirewallConfiguration rendered when the
+	// revision was last applied.
+	// +optional
+	FirewallHash string `json:"firewallHash,omitempty"`
+
+	// AppliedAt is when the revision was last applied.
+	AppliedAt metav1.Time `json:"appliedAt"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Priority",type=integer,JSONPath=`.spec.priority`
+// +kubebuilder:printcolumn:name="Position",type=integer,JSONPath=`.status.placement.position`
+// +kubebuilder:printcolumn:name="Revision",type=integer,JSONPath=`.status.currentRevision`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // PeeringConnectivity is the Schema for the peeringconnectivities API.
 // It represents a connectivity policy configuration for controlling network connectivity
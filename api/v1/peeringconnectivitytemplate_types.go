@@ -0,0 +1,168 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PeeringRole is the role of the remote cluster in a Liqo peering, as reported by its ForeignCluster.
+//
+// +kubebuilder:validation:Enum=consumer;provider
+type PeeringRole string
+
+const (
+	// PeeringRoleConsumer matches the peerings in which the remote cluster consumes the resources of the local one.
+	PeeringRoleConsumer PeeringRole = "consumer"
+
+	// PeeringRoleProvider matches the peerings in which the remote cluster provides resources to the local one.
+	PeeringRoleProvider PeeringRole = "provider"
+)
+
+const (
+	// TemplateReasonApplied means the template contributes to the PeeringConnectivity of the peering.
+	TemplateReasonApplied = "Applied"
+
+	// TemplateReasonConflict means the template contributes to the PeeringConnectivity of the peering,
+	// but some of its gateway settings are overridden by a template with a higher priority.
+	TemplateReasonConflict = "Conflict"
+
+	// TemplateReasonUserManaged means the tenant namespace of the peering contains PeeringConnectivity
+	// resources not managed by the templates, which are left untouched.
+	TemplateReasonUserManaged = "UserManaged"
+
+	// TemplateReasonNotEstablished means the networking of the peering has not been established yet.
+	TemplateReasonNotEstablished = "NotEstablished"
+
+	// TemplateReasonPending means the PeeringConnectivity of the peering has not been updated yet.
+	TemplateReasonPending = "Pending"
+
+	// TemplateReasonInvalidSelector means the cluster selector of the template cannot be parsed.
+	TemplateReasonInvalidSelector = "InvalidSelector"
+)
+
+// PeeringConnectivityTemplateSpec defines the desired state of PeeringConnectivityTemplate.
+type PeeringConnectivityTemplateSpec struct {
+	// ClusterSelector selects the peerings the template applies to by the labels of their ForeignCluster.
+	// An empty selector matches all the peerings.
+	// +required
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector"`
+
+	// Roles restricts the template to the peerings in which the remote cluster has one of the given roles.
+	// If omitted, the template applies regardless of the role.
+	// +optional
+	// +listType=set
+	Roles []PeeringRole `json:"roles,omitempty"`
+
+	// Priority orders the templates matching the same peering. Their rules are merged by decreasing
+	// priority, hence the rules of the templates with a higher priority are evaluated first, and each
+	// gateway setting is taken from the template with the highest priority setting it.
+	// Templates with the same priority are ordered by name.
+	// +optional
+	// +kubebuilder:default=0
+	Priority int32 `json:"priority,omitempty"`
+
+	// Template is the spec merged into the PeeringConnectivity of the matching peerings.
+	// Its priority is ignored, as the templates are ordered by their own priority.
+	// +required
+	Template PeeringConnectivitySpec `json:"template"`
+}
+
+// TemplatePeeringStatus reports how a template applies to one of the peerings it matches.
+type TemplatePeeringStatus struct {
+	// ClusterID is the ID of the remote cluster of the peering.
+	ClusterID string `json:"clusterID"`
+
+	// Namespace is the tenant namespace of the peering.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// PeeringConnectivity is the name of the PeeringConnectivity the template is merged into.
+	// +optional
+	PeeringConnectivity string `json:"peeringConnectivity,omitempty"`
+
+	// Applied is whether the template contributes to the PeeringConnectivity of the peering.
+	Applied bool `json:"applied"`
+
+	// Reason is a CamelCase reason for the state of the template in the peering.
+	Reason string `json:"reason"`
+
+	// Message is a human readable description of the state of the template in the peering.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PeeringConnectivityTemplateStatus defines the observed state of PeeringConnectivityTemplate.
+type PeeringConnectivityTemplateStatus struct {
+	// Conditions represent the current state of the PeeringConnectivityTemplate resource.
+	//
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// ObservedGeneration is the last observed generation of the PeeringConnectivityTemplate resource.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// MatchedPeerings is the number of peerings matched by the template.
+	MatchedPeerings int32 `json:"matchedPeerings,omitempty"`
+
+	// Peerings reports how the template applies to each of the peerings it matches.
+	// +optional
+	// +listType=map
+	// +listMapKey=clusterID
+	Peerings []TemplatePeeringStatus `json:"peerings,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Priority",type=integer,JSONPath=`.spec.priority`
+// +kubebuilder:printcolumn:name="Matched",type=integer,JSONPath=`.status.matchedPeerings`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PeeringConnectivityTemplate is the Schema for the peeringconnectivitytemplates API.
+// It defines the connectivity policy shared by the peerings whose ForeignCluster matches its
+// selector, e.g. the peerings of the same trust tier. The matching templates are merged into
+// the PeeringConnectivity created by the operator in the tenant namespace of each peering.
+type PeeringConnectivityTemplate struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Metadata is standard Kubernetes object metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines the peerings the template applies to and the policy it defines.
+	// +required
+	Spec PeeringConnectivityTemplateSpec `json:"spec"`
+
+	// Status reports the peerings the template applies to.
+	// +optional
+	Status PeeringConnectivityTemplateStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PeeringConnectivityTemplateList contains a list of PeeringConnectivityTemplate resources.
+type PeeringConnectivityTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PeeringConnectivityTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PeeringConnectivityTemplate{}, &PeeringConnectivityTemplateList{})
+}
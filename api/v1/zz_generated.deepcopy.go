@@ -23,6 +23,344 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessGrant) DeepCopyInto(out *AccessGrant) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessGrant.
+func (in *AccessGrant) DeepCopy() *AccessGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AccessGrant) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessGrantList) DeepCopyInto(out *AccessGrantList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AccessGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessGrantList.
+func (in *AccessGrantList) DeepCopy() *AccessGrantList {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessGrantList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AccessGrantList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessGrantSpec) DeepCopyInto(out *AccessGrantSpec) {
+	*out = *in
+	in.Rule.DeepCopyInto(&out.Rule)
+	out.TTL = in.TTL
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessGrantSpec.
+func (in *AccessGrantSpec) DeepCopy() *AccessGrantSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessGrantSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessGrantStatus) DeepCopyInto(out *AccessGrantStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessGrantStatus.
+func (in *AccessGrantStatus) DeepCopy() *AccessGrantStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessGrantStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChainPlacement) DeepCopyInto(out *ChainPlacement) {
+	*out = *in
+	if in.Chain != nil {
+		in, out := &in.Chain, &out.Chain
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conflicts != nil {
+		in, out := &in.Conflicts, &out.Conflicts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChainPlacement.
+func (in *ChainPlacement) DeepCopy() *ChainPlacement {
+	if in == nil {
+		return nil
+	}
+	out := new(ChainPlacement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConntrackSettings) DeepCopyInto(out *ConntrackSettings) {
+	*out = *in
+	if in.Related != nil {
+		in, out := &in.Related, &out.Related
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DropInvalid != nil {
+		in, out := &in.DropInvalid, &out.DropInvalid
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConntrackSettings.
+func (in *ConntrackSettings) DeepCopy() *ConntrackSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(ConntrackSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CounterStatus) DeepCopyInto(out *CounterStatus) {
+	*out = *in
+	in.LastUpdate.DeepCopyInto(&out.LastUpdate)
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]RuleCounterStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CounterStatus.
+func (in *CounterStatus) DeepCopy() *CounterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CounterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewaySettings) DeepCopyInto(out *GatewaySettings) {
+	*out = *in
+	if in.BypassNonTunnelTraffic != nil {
+		in, out := &in.BypassNonTunnelTraffic, &out.BypassNonTunnelTraffic
+		*out = new(bool)
+		**out = **in
+	}
+	if in.BypassUplinkTraffic != nil {
+		in, out := &in.BypassUplinkTraffic, &out.BypassUplinkTraffic
+		*out = new(bool)
+		**out = **in
+	}
+	if in.UplinkInterfaces != nil {
+		in, out := &in.UplinkInterfaces, &out.UplinkInterfaces
+		*out = make([]InterfaceName, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conntrack != nil {
+		in, out := &in.Conntrack, &out.Conntrack
+		*out = new(ConntrackSettings)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewaySettings.
+func (in *GatewaySettings) DeepCopy() *GatewaySettings {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewaySettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacePeeringPolicy) DeepCopyInto(out *NamespacePeeringPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacePeeringPolicy.
+func (in *NamespacePeeringPolicy) DeepCopy() *NamespacePeeringPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacePeeringPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NamespacePeeringPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacePeeringPolicyList) DeepCopyInto(out *NamespacePeeringPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NamespacePeeringPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacePeeringPolicyList.
+func (in *NamespacePeeringPolicyList) DeepCopy() *NamespacePeeringPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacePeeringPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NamespacePeeringPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacePeeringPolicySpec) DeepCopyInto(out *NamespacePeeringPolicySpec) {
+	*out = *in
+	if in.ClusterIDs != nil {
+		in, out := &in.ClusterIDs, &out.ClusterIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Ingress != nil {
+		in, out := &in.Ingress, &out.Ingress
+		*out = make([]Party, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Egress != nil {
+		in, out := &in.Egress, &out.Egress
+		*out = make([]Party, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PolicyTypes != nil {
+		in, out := &in.PolicyTypes, &out.PolicyTypes
+		*out = make([]NamespacePolicyType, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacePeeringPolicySpec.
+func (in *NamespacePeeringPolicySpec) DeepCopy() *NamespacePeeringPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacePeeringPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacePeeringPolicyStatus) DeepCopyInto(out *NamespacePeeringPolicyStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Peerings != nil {
+		in, out := &in.Peerings, &out.Peerings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacePeeringPolicyStatus.
+func (in *NamespacePeeringPolicyStatus) DeepCopy() *NamespacePeeringPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacePeeringPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Party) DeepCopyInto(out *Party) {
 	*out = *in
@@ -36,6 +374,16 @@ func (in *Party) DeepCopyInto(out *Party) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(ServiceReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FQDN != nil {
+		in, out := &in.FQDN, &out.FQDN
+		*out = new(FQDN)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Party.
@@ -117,6 +465,11 @@ func (in *PeeringConnectivitySpec) DeepCopyInto(out *PeeringConnectivitySpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Gateway != nil {
+		in, out := &in.Gateway, &out.Gateway
+		*out = new(GatewaySettings)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringConnectivitySpec.
@@ -139,6 +492,30 @@ func (in *PeeringConnectivityStatus) DeepCopyInto(out *PeeringConnectivityStatus
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(ChainPlacement)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Schedules != nil {
+		in, out := &in.Schedules, &out.Schedules
+		*out = make([]RuleScheduleStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Counters != nil {
+		in, out := &in.Counters, &out.Counters
+		*out = new(CounterStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Revisions != nil {
+		in, out := &in.Revisions, &out.Revisions
+		*out = make([]RevisionStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringConnectivityStatus.
@@ -151,6 +528,130 @@ func (in *PeeringConnectivityStatus) DeepCopy() *PeeringConnectivityStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringConnectivityTemplate) DeepCopyInto(out *PeeringConnectivityTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringConnectivityTemplate.
+func (in *PeeringConnectivityTemplate) DeepCopy() *PeeringConnectivityTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringConnectivityTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PeeringConnectivityTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringConnectivityTemplateList) DeepCopyInto(out *PeeringConnectivityTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PeeringConnectivityTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringConnectivityTemplateList.
+func (in *PeeringConnectivityTemplateList) DeepCopy() *PeeringConnectivityTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringConnectivityTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PeeringConnectivityTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringConnectivityTemplateSpec) DeepCopyInto(out *PeeringConnectivityTemplateSpec) {
+	*out = *in
+	in.ClusterSelector.DeepCopyInto(&out.ClusterSelector)
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]PeeringRole, len(*in))
+		copy(*out, *in)
+	}
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringConnectivityTemplateSpec.
+func (in *PeeringConnectivityTemplateSpec) DeepCopy() *PeeringConnectivityTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringConnectivityTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringConnectivityTemplateStatus) DeepCopyInto(out *PeeringConnectivityTemplateStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Peerings != nil {
+		in, out := &in.Peerings, &out.Peerings
+		*out = make([]TemplatePeeringStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringConnectivityTemplateStatus.
+func (in *PeeringConnectivityTemplateStatus) DeepCopy() *PeeringConnectivityTemplateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringConnectivityTemplateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RevisionStatus) DeepCopyInto(out *RevisionStatus) {
+	*out = *in
+	in.AppliedAt.DeepCopyInto(&out.AppliedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RevisionStatus.
+func (in *RevisionStatus) DeepCopy() *RevisionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RevisionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Rule) DeepCopyInto(out *Rule) {
 	*out = *in
@@ -164,6 +665,11 @@ func (in *Rule) DeepCopyInto(out *Rule) {
 		*out = new(Party)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(RuleSchedule)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Rule.
@@ -175,3 +681,108 @@ func (in *Rule) DeepCopy() *Rule {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuleCounterStatus) DeepCopyInto(out *RuleCounterStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleCounterStatus.
+func (in *RuleCounterStatus) DeepCopy() *RuleCounterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RuleCounterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuleSchedule) DeepCopyInto(out *RuleSchedule) {
+	*out = *in
+	if in.Windows != nil {
+		in, out := &in.Windows, &out.Windows
+		*out = make([]ScheduleWindow, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleSchedule.
+func (in *RuleSchedule) DeepCopy() *RuleSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(RuleSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuleScheduleStatus) DeepCopyInto(out *RuleScheduleStatus) {
+	*out = *in
+	if in.NextTransition != nil {
+		in, out := &in.NextTransition, &out.NextTransition
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleScheduleStatus.
+func (in *RuleScheduleStatus) DeepCopy() *RuleScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RuleScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduleWindow) DeepCopyInto(out *ScheduleWindow) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleWindow.
+func (in *ScheduleWindow) DeepCopy() *ScheduleWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceReference) DeepCopyInto(out *ServiceReference) {
+	*out = *in
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceReference.
+func (in *ServiceReference) DeepCopy() *ServiceReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplatePeeringStatus) DeepCopyInto(out *TemplatePeeringStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplatePeeringStatus.
+func (in *TemplatePeeringStatus) DeepCopy() *TemplatePeeringStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplatePeeringStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -18,10 +18,15 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
 	"os"
 
+	// Embed the time zone database, as the base image may not provide it,
+	// to evaluate the schedules of the rules in any time zone.
+	_ "time/tzdata"
+
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -36,6 +41,7 @@ import (
 
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -84,6 +90,8 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	engineOpts := options.NewDefaultOptions()
+	engineOpts.BindFlags(flag.CommandLine)
 	opts := zap.Options{
 		Development: true,
 	}
@@ -93,6 +101,12 @@ func main() {
 	// Set up the logger.
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	// Validate the connectivity engine options.
+	if err := engineOpts.Validate(); err != nil {
+		setupLog.Error(err, "invalid options")
+		os.Exit(1)
+	}
+
 	// Disable HTTP/2 by default to mitigate HTTP/2 vulnerabilities.
 	// If the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
@@ -191,12 +205,48 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Register the identity indexes shared by the controllers to map the peerings to their tenant namespaces
+	// and virtual nodes, before any of them is set up.
+	if err := utils.SetupIdentityIndexes(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		setupLog.Error(err, "unable to set up the identity indexes")
+		os.Exit(1)
+	}
+
 	// Create and register the PeeringConnectivity controller.
-	peeringConnectivityReconciler := controller.NewPeeringConnectivityReconciler(mgr)
+	peeringConnectivityReconciler := controller.NewPeeringConnectivityReconciler(mgr, engineOpts)
 	if err := (peeringConnectivityReconciler).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PeeringConnectivity")
 		os.Exit(1)
 	}
+
+	// Create and register the ForeignCluster controller, which manages the PeeringConnectivity lifecycle.
+	foreignClusterReconciler, err := controller.NewForeignClusterReconciler(mgr, engineOpts)
+	if err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ForeignCluster")
+		os.Exit(1)
+	}
+	if err := foreignClusterReconciler.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ForeignCluster")
+		os.Exit(1)
+	}
+
+	// Create and register the PeeringConnectivityTemplate controller, which reports where the templates apply.
+	if err := controller.NewPeeringConnectivityTemplateReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "PeeringConnectivityTemplate")
+		os.Exit(1)
+	}
+
+	// Create and register the NamespacePeeringPolicy controller, which reports where the policies are enforced.
+	if err := controller.NewNamespacePeeringPolicyReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "NamespacePeeringPolicy")
+		os.Exit(1)
+	}
+
+	// Create and register the AccessGrant controller, which reports whether the grants are active and expires them.
+	if err := controller.NewAccessGrantReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AccessGrant")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
 	// Add health check endpoints.
@@ -23,15 +23,21 @@ import (
 	"fmt"
 	"os"
 
+	// Embed the time zone database to evaluate the schedules of the rules in any time zone.
+	_ "time/tzdata"
+
 	corev1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlcache "sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -42,9 +48,16 @@ func main() {
 	var clusterID string
 	var clusterIds []string
 
+	engineOpts := options.NewDefaultOptions()
+	engineOpts.BindFlags(flag.CommandLine)
 	flag.StringVar(&clusterID, "cluster-id", "", "The ID of the cluster to test the controller with.")
 	flag.Parse()
 
+	if err := engineOpts.Validate(); err != nil {
+		fmt.Printf("Invalid options: %v\n", err)
+		os.Exit(1)
+	}
+
 	opts := zap.Options{Development: true}
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
@@ -54,14 +67,41 @@ func main() {
 	utils.RegisterScheme(scheme)
 	utilruntime.Must(corev1beta1.AddToScheme(scheme))
 
-	cl, _ := client.New(cfg, client.Options{Scheme: scheme})
+	ctx := context.Background()
+
+	// The peering identity is resolved through field indexes, which only the cache supports.
+	cache, err := ctrlcache.New(cfg, ctrlcache.Options{Scheme: scheme})
+	if err != nil {
+		fmt.Printf("Error creating the cache: %v\n", err)
+		os.Exit(1)
+	}
+	if err := utils.SetupIdentityIndexes(ctx, cache); err != nil {
+		fmt.Printf("Error setting up the indexes: %v\n", err)
+		os.Exit(1)
+	}
+	go func() {
+		if err := cache.Start(ctx); err != nil {
+			fmt.Printf("Error starting the cache: %v\n", err)
+			os.Exit(1)
+		}
+	}()
+	if !cache.WaitForCacheSync(ctx) {
+		fmt.Println("Error waiting for the cache to sync")
+		os.Exit(1)
+	}
+
+	cl, err := client.New(cfg, client.Options{Scheme: scheme, Cache: &client.CacheOptions{Reader: cache}})
+	if err != nil {
+		fmt.Printf("Error creating the client: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Get the list of clusters to be parsed
 	if clusterID != "" {
 		clusterIds = append(clusterIds, clusterID)
 	} else {
 		clustersList := &corev1beta1.ForeignClusterList{}
-		if err := cl.List(context.Background(), clustersList); err != nil {
+		if err := cl.List(ctx, clustersList); err != nil {
 			fmt.Printf("Error listing ForeignClusters: %v\n", err)
 			os.Exit(1)
 		}
@@ -80,24 +120,37 @@ func main() {
 		Client:   cl,
 		Scheme:   scheme,
 		Recorder: recorder,
+		Options:  engineOpts,
 	}
 
 	for _, clusterID := range clusterIds {
-		fmt.Printf("Running reconciler for cluster ID: %s\n", clusterID)
+		namespace, err := utils.GetTenantNamespace(ctx, cl, clusterID)
+		if err != nil {
+			fmt.Printf("Error resolving the tenant namespace of cluster %s: %v\n", clusterID, err)
+			os.Exit(1)
+		}
 
-		req := reconcile.Request{
-			NamespacedName: types.NamespacedName{
-				Name:      clusterID,
-				Namespace: fmt.Sprintf("liqo-tenant-%s", clusterID),
-			},
+		peeringConnectivityList := &connectivityv1.PeeringConnectivityList{}
+		if err := cl.List(ctx, peeringConnectivityList, client.InNamespace(namespace)); err != nil {
+			fmt.Printf("Error listing PeeringConnectivities in %s: %v\n", namespace, err)
+			os.Exit(1)
 		}
 
-		res, err := reconciler.Reconcile(context.Background(), req)
-		fmt.Printf("Result: %+v, Error: %v\n", res, err)
+		for i := range peeringConnectivityList.Items {
+			pc := &peeringConnectivityList.Items[i]
+			fmt.Printf("Running reconciler for cluster ID: %s (%s/%s)\n", clusterID, pc.Namespace, pc.Name)
 
-		// Exit with an error code if reconciliation failed.
-		if err != nil {
-			os.Exit(1)
+			req := reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: pc.Name, Namespace: pc.Namespace},
+			}
+
+			res, err := reconciler.Reconcile(ctx, req)
+			fmt.Printf("Result: %+v, Error: %v\n", res, err)
+
+			// Exit with an error code if reconciliation failed.
+			if err != nil {
+				os.Exit(1)
+			}
 		}
 	}
 }
@@ -0,0 +1,179 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+)
+
+const (
+	// EventReasonAccessGranted is emitted when the rule of an AccessGrant starts being enforced.
+	EventReasonAccessGranted = "AccessGranted"
+	// EventReasonAccessExpired is emitted when an AccessGrant expires and its rule is removed.
+	EventReasonAccessExpired = "AccessExpired"
+)
+
+// AccessGrantReconciler reports in the status of the AccessGrant resources whether they are active
+// and when they expire, emitting an event when they are granted and when they expire. The rules of
+// the grants are injected into the FirewallConfiguration and the NetworkPolicies of the peering by the
+// PeeringConnectivityReconciler, which also removes them on expiry.
+type AccessGrantReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=connectivity.liqo.io,resources=accessgrants,verbs=get;list;watch
+// +kubebuilder:rbac:groups=connectivity.liqo.io,resources=accessgrants/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// NewAccessGrantReconciler creates a new AccessGrantReconciler.
+func NewAccessGrantReconciler(mgr ctrl.Manager) *AccessGrantReconciler {
+	return &AccessGrantReconciler{
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorderFor("accessgrant-controller"),
+	}
+}
+
+// Reconcile updates the status of the AccessGrant, and requeues it when it expires.
+func (r *AccessGrantReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	grant := &connectivityv1.AccessGrant{}
+	if err := r.Client.Get(ctx, req.NamespacedName, grant); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !grant.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	original := grant.Status.DeepCopy()
+
+	expiration := utils.AccessGrantExpiration(grant)
+	grant.Status.ExpiresAt = &metav1.Time{Time: expiration}
+
+	if !utils.IsAccessGrantActive(grant, time.Now()) {
+		if r.setReason(grant, metav1.ConditionFalse, connectivityv1.AccessGrantReasonExpired,
+			fmt.Sprintf("The access expired at %s", expiration.UTC().Format(time.RFC3339))) {
+			r.Recorder.Eventf(grant, corev1.EventTypeNormal, EventReasonAccessExpired,
+				"Access on peering %s expired, its rule has been removed", grant.Spec.ClusterID)
+		}
+		return ctrl.Result{}, r.updateStatus(ctx, grant, original)
+	}
+
+	namespace, err := utils.GetTenantNamespace(ctx, r.Client, grant.Spec.ClusterID)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("unable to resolve the tenant namespace of cluster %q: %w", grant.Spec.ClusterID, err)
+	}
+	grant.Status.Namespace = namespace
+
+	// The rule is enforced only along with the PeeringConnectivity resources of the peering.
+	chain, err := utils.GetPeeringConnectivityChain(ctx, r.Client, namespace, nil)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if chain == nil {
+		r.setReason(grant, metav1.ConditionFalse, connectivityv1.AccessGrantReasonNoPeering,
+			fmt.Sprintf("No PeeringConnectivity found in the tenant namespace %q of the peering", namespace))
+	} else if r.setReason(grant, metav1.ConditionTrue, connectivityv1.AccessGrantReasonActive,
+		fmt.Sprintf("The rule is enforced ahead of the policy of the peering until %s", expiration.UTC().Format(time.RFC3339))) {
+		r.Recorder.Eventf(grant, corev1.EventTypeNormal, EventReasonAccessGranted,
+			"Access granted on peering %s until %s: %s", grant.Spec.ClusterID, expiration.UTC().Format(time.RFC3339), grant.Spec.Reason)
+	}
+
+	if err := r.updateStatus(ctx, grant, original); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: max(time.Until(expiration), minAccessGrantRequeueDelay)}, nil
+}
+
+// setReason sets the Ready condition of the grant, returning whether its reason changed.
+func (r *AccessGrantReconciler) setReason(
+	grant *connectivityv1.AccessGrant, status metav1.ConditionStatus, reason, message string,
+) bool {
+	previous := meta.FindStatusCondition(grant.Status.Conditions, utils.ConditionTypeReady)
+	meta.SetStatusCondition(&grant.Status.Conditions, metav1.Condition{
+		Type:    utils.ConditionTypeReady,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+	return previous == nil || previous.Reason != reason
+}
+
+// updateStatus updates the status of the grant, if changed.
+func (r *AccessGrantReconciler) updateStatus(
+	ctx context.Context, grant *connectivityv1.AccessGrant, original *connectivityv1.AccessGrantStatus,
+) error {
+	grant.Status.ObservedGeneration = grant.Generation
+	if equality.Semantic.DeepEqual(&grant.Status, original) {
+		return nil
+	}
+
+	if err := r.Status().Update(ctx, grant); err != nil {
+		log.FromContext(ctx).Error(err, "failed to update status")
+		return err
+	}
+	return nil
+}
+
+// allGrantEnqueuer enqueues all the AccessGrant resources, as any of them may refer to the peering
+// the changed object refers to.
+func (r *AccessGrantReconciler) allGrantEnqueuer(ctx context.Context, _ client.Object) []ctrl.Request {
+	var grants connectivityv1.AccessGrantList
+	if err := r.Client.List(ctx, &grants); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list the AccessGrant resources")
+		return nil
+	}
+
+	requests := make([]ctrl.Request, len(grants.Items))
+	for i := range grants.Items {
+		requests[i] = ctrl.Request{NamespacedName: types.NamespacedName{Name: grants.Items[i].Name}}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+// It watches the ForeignCluster resources and the creation and deletion of the PeeringConnectivity
+// resources, since they determine whether the rules of the grants are enforced.
+func (r *AccessGrantReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&connectivityv1.AccessGrant{}).
+		Watches(&liqov1beta1.ForeignCluster{}, handler.EnqueueRequestsFromMapFunc(r.allGrantEnqueuer)).
+		Watches(&connectivityv1.PeeringConnectivity{}, handler.EnqueueRequestsFromMapFunc(r.allGrantEnqueuer),
+			builder.WithPredicates(predicate.Funcs{
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					return e.ObjectOld.GetDeletionTimestamp().IsZero() != e.ObjectNew.GetDeletionTimestamp().IsZero()
+				},
+			})).
+		Named("accessgrant").
+		Complete(r)
+}
@@ -0,0 +1,161 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+)
+
+var _ = Describe("AccessGrant Controller", func() {
+	Context("When reporting whether a grant is active", func() {
+		const clusterID = "grant-cluster"
+
+		var (
+			ctx        context.Context
+			namespace  string
+			recorder   *record.FakeRecorder
+			reconciler *AccessGrantReconciler
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			namespace = "liqo-tenant-" + clusterID
+			recorder = record.NewFakeRecorder(10)
+			reconciler = &AccessGrantReconciler{Client: indexedK8sClient, Recorder: recorder}
+
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+			if err := k8sClient.Create(ctx, ns); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			foreignCluster := &liqov1beta1.ForeignCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: clusterID},
+				Spec:       liqov1beta1.ForeignClusterSpec{ClusterID: clusterID},
+			}
+			Expect(k8sClient.Create(ctx, foreignCluster)).To(Succeed())
+			foreignCluster.Status.Modules.Networking.Enabled = true
+			foreignCluster.Status.TenantNamespace.Local = namespace
+			Expect(k8sClient.Status().Update(ctx, foreignCluster)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, &liqov1beta1.ForeignCluster{ObjectMeta: metav1.ObjectMeta{Name: clusterID}})).To(Succeed())
+			Expect(k8sClient.DeleteAllOf(ctx, &connectivityv1.PeeringConnectivity{}, client.InNamespace(namespace))).To(Succeed())
+			Expect(k8sClient.DeleteAllOf(ctx, &connectivityv1.AccessGrant{})).To(Succeed())
+		})
+
+		newGrant := func(ttl time.Duration) *connectivityv1.AccessGrant {
+			return &connectivityv1.AccessGrant{
+				ObjectMeta: metav1.ObjectMeta{Name: "incident"},
+				Spec: connectivityv1.AccessGrantSpec{
+					ClusterID: clusterID,
+					Rule: connectivityv1.Rule{
+						Action: connectivityv1.ActionAllow,
+						Source: &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupRemoteCluster)},
+					},
+					TTL:    metav1.Duration{Duration: ttl},
+					Reason: "INC-1234",
+				},
+			}
+		}
+
+		reconcileGrant := func(ttl time.Duration) (*connectivityv1.AccessGrant, reconcile.Result) {
+			grant := newGrant(ttl)
+			Expect(k8sClient.Create(ctx, grant)).To(Succeed())
+
+			result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(grant)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(grant), grant)).To(Succeed())
+			return grant, result
+		}
+
+		It("should report no peering while the tenant namespace has no PeeringConnectivity", func() {
+			grant, _ := reconcileGrant(time.Hour)
+
+			Expect(grant.Status.Namespace).To(Equal(namespace))
+			condition := meta.FindStatusCondition(grant.Status.Conditions, utils.ConditionTypeReady)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(connectivityv1.AccessGrantReasonNoPeering))
+			Expect(recorder.Events).To(BeEmpty())
+		})
+
+		It("should report the active grants and requeue them when they expire", func() {
+			Expect(k8sClient.Create(ctx, &connectivityv1.PeeringConnectivity{
+				ObjectMeta: metav1.ObjectMeta{Name: clusterID, Namespace: namespace},
+			})).To(Succeed())
+
+			grant, result := reconcileGrant(time.Hour)
+
+			Expect(grant.Status.ExpiresAt.Time).To(BeTemporally("==", grant.CreationTimestamp.Add(time.Hour)))
+			Expect(grant.Status.ObservedGeneration).To(Equal(grant.Generation))
+			Expect(meta.FindStatusCondition(grant.Status.Conditions, utils.ConditionTypeReady).Reason).
+				To(Equal(connectivityv1.AccessGrantReasonActive))
+			Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+			Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonAccessGranted)))
+		})
+
+		It("should report the expired grants", func() {
+			// The creation timestamp is truncated to the second, hence the grant is already expired.
+			grant, result := reconcileGrant(time.Nanosecond)
+
+			condition := meta.FindStatusCondition(grant.Status.Conditions, utils.ConditionTypeReady)
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(connectivityv1.AccessGrantReasonExpired))
+			Expect(result.RequeueAfter).To(BeZero())
+			Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonAccessExpired)))
+
+			By("not emitting the expiry event again")
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(grant)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.Events).To(BeEmpty())
+		})
+
+		It("should reject the grants without a bounded positive TTL", func() {
+			Expect(errors.IsInvalid(k8sClient.Create(ctx, newGrant(0)))).To(BeTrue())
+			Expect(errors.IsInvalid(k8sClient.Create(ctx, newGrant(-time.Hour)))).To(BeTrue())
+			Expect(errors.IsInvalid(k8sClient.Create(ctx, newGrant(8*24*time.Hour)))).To(BeTrue())
+		})
+
+		It("should reject the changes of the TTL and the rule of a grant", func() {
+			grant := newGrant(time.Hour)
+			Expect(k8sClient.Create(ctx, grant)).To(Succeed())
+
+			extended := grant.DeepCopy()
+			extended.Spec.TTL.Duration = 2 * time.Hour
+			Expect(errors.IsInvalid(k8sClient.Update(ctx, extended))).To(BeTrue())
+
+			widened := grant.DeepCopy()
+			widened.Spec.Rule.Source = nil
+			Expect(errors.IsInvalid(k8sClient.Update(ctx, widened))).To(BeTrue())
+		})
+	})
+})
@@ -20,11 +20,15 @@ import (
 
 	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
 	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
+	"github.com/liqotech/liqo/pkg/consts"
 	"github.com/liqotech/liqo/pkg/fabric"
 	"github.com/liqotech/liqo/pkg/firewall"
 	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/fqdn"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/resourcegroups"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -33,15 +37,15 @@ const (
 	// fabricResourceNameSuffix is the suffix appended to the cluster ID to form the fabric FirewallConfiguration name.
 	fabricResourceNameSuffix = "connectivity-fabric"
 
-	// fabricTableName is the name of the nftables table used by the fabric FirewallConfiguration.
-	fabricTableName = "cluster-connectivity"
+	// fabricTablePrefix is the prefix of the name of the nftables table used by the fabric FirewallConfiguration.
+	fabricTablePrefix = "connectivity-fabric"
 
-	// fabricChainName is the name of the nftables chain used by the fabric FirewallConfiguration.
-	fabricChainName = "cluster-connectivity-filter"
+	// fabricChainPrefix is the prefix of the name of the nftables chain used by the fabric FirewallConfiguration.
+	fabricChainPrefix = "connectivity-fabric-filter"
 
-	// fabricChainPriority is the priority of the fabric firewall chain.
-	// Lower values have higher priority.
-	fabricChainPriority = 200
+	// LegacyFabricTableName is the name of the nftables table shared by all the fabric FirewallConfigurations
+	// created by previous versions of the connectivity engine.
+	LegacyFabricTableName = "cluster-connectivity"
 )
 
 // ForgeFabricResourceName generates the name of the Fabric FirewallConfiguration resource
@@ -50,14 +54,29 @@ func ForgeFabricResourceName(clusterID string) string {
 	return fmt.Sprintf("%s-%s", clusterID, fabricResourceNameSuffix)
 }
 
+// ForgeFabricTableName generates the name of the nftables table of the Fabric FirewallConfiguration
+// for the given cluster ID, so that the tables of different peerings never collide.
+func ForgeFabricTableName(clusterID string) string {
+	return utils.ForgeClusterScopedName(fabricTablePrefix, clusterID)
+}
+
+// ForgeFabricChainName generates the name of the nftables chain of the Fabric FirewallConfiguration
+// for the given cluster ID.
+func ForgeFabricChainName(clusterID string) string {
+	return utils.ForgeClusterScopedName(fabricChainPrefix, clusterID)
+}
+
 // ForgeFabricLabels creates the labels for a Fabric FirewallConfiguration resource.
 // These labels identify the configuration as a fabric-level connectivity configuration
-// that targets all nodes in the cluster.
+// that targets all nodes in the cluster, and the peering it belongs to.
 func ForgeFabricLabels(clusterID string) map[string]string {
 	// Labels identify this as a fabric-level firewall configuration targeting all nodes.
+	// The unique target label is reserved to the node name by Liqo, hence the peering is
+	// identified by the remote cluster ID label.
 	return map[string]string{
 		firewall.FirewallCategoryTargetKey:    fabric.FirewallCategoryTargetValue,
 		firewall.FirewallSubCategoryTargetKey: fabric.FirewallSubCategoryTargetAllNodesValue,
+		consts.RemoteClusterID:                clusterID,
 	}
 }
 
@@ -68,38 +87,40 @@ func ForgeFabricLabels(clusterID string) map[string]string {
 // - Creating match rules for source and destination filtering
 // - Setting up allow/deny actions based on the rule specifications
 // - Adding a default rule to allow established/related connections
-func ForgeFabricSpec(ctx context.Context, cl client.Client, cfg *connectivityv1.PeeringConnectivity, clusterID string) (*networkingv1beta1.FirewallConfigurationSpec, error) {
+//
+// The chain is attached to the postrouting hook of the nodes, at the default chain priority.
+// It also returns the policy construct represented by each of the created sets.
+func ForgeFabricSpec(
+	ctx context.Context,
+	cl client.Client,
+	opts *options.Options,
+	resolver *fqdn.Cache,
+	cfg *connectivityv1.PeeringConnectivity,
+	clusterID string,
+) (*networkingv1beta1.FirewallConfigurationSpec, utils.SetOrigins, error) {
 	// Initialize the FirewallConfiguration with basic structure.
 	spec := networkingv1beta1.FirewallConfigurationSpec{
 		Table: networkingv1beta1firewall.Table{
-			Name:   ptr.To(fabricTableName),
+			Name:   ptr.To(ForgeFabricTableName(clusterID)),
 			Family: ptr.To(networkingv1beta1firewall.TableFamilyIPv4),
 			Sets:   make([]networkingv1beta1firewall.Set, 0),
-			Chains: []networkingv1beta1firewall.Chain{{
-				Name:     ptr.To(fabricChainName),
-				Hook:     ptr.To(networkingv1beta1firewall.ChainHookPostrouting),
-				Policy:   ptr.To(networkingv1beta1firewall.ChainPolicyAccept),
-				Priority: ptr.To[networkingv1beta1firewall.ChainPriority](fabricChainPriority),
-				Type:     networkingv1beta1firewall.ChainTypeFilter,
-				Rules: networkingv1beta1firewall.RulesSet{
-					FilterRules: []networkingv1beta1firewall.FilterRule{
-						{
-							// First rule: Always allow established and related connections.
-							// This is essential to allow responses to outgoing connections.
-							Name:   ptr.To("allow-established-related"),
-							Action: networkingv1beta1firewall.ActionAccept,
-							Match: []networkingv1beta1firewall.Match{{
-								CtState: &networkingv1beta1firewall.MatchCtState{
-									Value: []networkingv1beta1firewall.CtStateValue{
-										networkingv1beta1firewall.CtStateEstablished,
-										networkingv1beta1firewall.CtStateRelated,
-									},
-								},
-								Op: networkingv1beta1firewall.MatchOperationEq,
-							}},
-						},
+		},
+	}
+
+	rules := []networkingv1beta1firewall.FilterRule{
+		{
+			// First rule: Always allow established and related connections.
+			// This is essential to allow responses to outgoing connections.
+			Name:   ptr.To("allow-established-related"),
+			Action: networkingv1beta1firewall.ActionAccept,
+			Match: []networkingv1beta1firewall.Match{{
+				CtState: &networkingv1beta1firewall.MatchCtState{
+					Value: []networkingv1beta1firewall.CtStateValue{
+						networkingv1beta1firewall.CtStateEstablished,
+						networkingv1beta1firewall.CtStateRelated,
 					},
 				},
+				Op: networkingv1beta1firewall.MatchOperationEq,
 			}},
 		},
 	}
@@ -107,46 +128,68 @@ func ForgeFabricSpec(ctx context.Context, cl client.Client, cfg *connectivityv1.
 	// Add the allowed traffic rules
 	usedResourceGroups := make(map[connectivityv1.ResourceGroup]struct{})
 	usedNamespaces := make(map[string]struct{})
+	usedServices := make(map[types.NamespacedName]struct{})
+	usedFQDNs := make(map[string]struct{})
 
 	for i, rule := range cfg.Spec.Rules {
-		ruleName := fmt.Sprintf("allowed-traffic-%d", i)
-
-		filterRule := networkingv1beta1firewall.FilterRule{
-			Name:   ptr.To(ruleName),
-			Action: networkingv1beta1firewall.ActionAccept,
-			Match:  []networkingv1beta1firewall.Match{},
-		}
-
 		// Set the action based on the rule specification.
+		action := networkingv1beta1firewall.ActionAccept
 		if rule.Action != connectivityv1.ActionAllow {
-			filterRule.Action = networkingv1beta1firewall.ActionDrop
+			action = networkingv1beta1firewall.ActionDrop
 		}
 
-		// Add match rules for the source (if specified).
-		sourceRules, err := ForgeMatchRule(ctx, cl, rule.Source, clusterID, networkingv1beta1firewall.MatchPositionSrc, usedResourceGroups, usedNamespaces)
-		if err != nil {
-			return nil, err
-		}
-		filterRule.Match = append(filterRule.Match, sourceRules...)
+		// Add a filter rule to the chain for each combination of the source and destination alternatives
+		// of each flow of the rule, matching the initiator of the connections as the source of the packets.
+		var alternatives [][]networkingv1beta1firewall.Match
+		for _, flow := range utils.ForgeRuleFlows(&cfg.Spec.Rules[i]) {
+			sourceRules, err := ForgeMatchRule(ctx, cl, opts, flow.Initiator, clusterID, networkingv1beta1firewall.MatchPositionSrc, usedResourceGroups, usedNamespaces, usedServices, usedFQDNs)
+			if err != nil {
+				return nil, nil, err
+			}
 
-		// Add match rules for the destination (if specified).
-		destRules, err := ForgeMatchRule(ctx, cl, rule.Destination, clusterID, networkingv1beta1firewall.MatchPositionDst, usedResourceGroups, usedNamespaces)
-		if err != nil {
-			return nil, err
+			destRules, err := ForgeMatchRule(ctx, cl, opts, flow.Responder, clusterID, networkingv1beta1firewall.MatchPositionDst, usedResourceGroups, usedNamespaces, usedServices, usedFQDNs)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			alternatives = append(alternatives, utils.CombineMatchAlternatives(sourceRules, destRules)...)
 		}
-		filterRule.Match = append(filterRule.Match, destRules...)
+		for j, matches := range alternatives {
+			ruleName := fmt.Sprintf("allowed-traffic-%d", i)
+			if len(alternatives) > 1 {
+				ruleName = fmt.Sprintf("allowed-traffic-%d-%d", i, j)
+			}
 
-		// Add the filter rule to the chain.
-		spec.Table.Chains[0].Rules.FilterRules = append(spec.Table.Chains[0].Rules.FilterRules, filterRule)
+			rules = append(rules, networkingv1beta1firewall.FilterRule{
+				Name:   ptr.To(ruleName),
+				Action: action,
+				Match:  matches,
+			})
+		}
 	}
 
+	spec.Table.Chains = []networkingv1beta1firewall.Chain{{
+		Name:     ptr.To(ForgeFabricChainName(clusterID)),
+		Hook:     ptr.To(networkingv1beta1firewall.ChainHook(options.DefaultChainHook)),
+		Policy:   ptr.To(networkingv1beta1firewall.ChainPolicyAccept),
+		Priority: ptr.To(networkingv1beta1firewall.ChainPriority(options.DefaultChainPriority)),
+		Type:     networkingv1beta1firewall.ChainTypeFilter,
+		Rules:    networkingv1beta1firewall.RulesSet{FilterRules: rules},
+	}}
+
+	// Set names are hashed, hence the construct each set represents is tracked separately.
+	setOrigins := make(utils.SetOrigins)
+
 	// Create firewall sets for all resource groups that require them.
 	// Sets contain collections of IP addresses (e.g., pod IPs) that can be referenced in rules.
 	for rg := range usedResourceGroups {
 		if resourcegroups.ResourceGroupFuncts[rg].MakeFirewallConfigurationSets != nil {
-			sets, err := resourcegroups.ResourceGroupFuncts[rg].MakeFirewallConfigurationSets(ctx, cl, clusterID)
+			sets, err := resourcegroups.ResourceGroupFuncts[rg].MakeFirewallConfigurationSets(ctx, cl, opts, clusterID)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
+			}
+			for i := range sets {
+				setOrigins[sets[i].Name] = utils.ForgeGroupSetOrigin(rg)
 			}
 			spec.Table.Sets = append(spec.Table.Sets, sets...)
 		}
@@ -157,29 +200,63 @@ func ForgeFabricSpec(ctx context.Context, cl client.Client, cfg *connectivityv1.
 		// Create a set for each namespace
 		pods, err := utils.GetPodsInNamespace(ctx, cl, ns)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+
+		set := utils.ForgePodIpsSet(utils.ForgeNamespaceSetName(ns), pods)
+		setOrigins[set.Name] = utils.ForgeNamespaceSetOrigin(ns)
+		spec.Table.Sets = append(spec.Table.Sets, set)
+	}
+
+	// Create Service sets if needed
+	for svc := range usedServices {
+		// Create a set for each Service, containing its virtual IPs and its endpoints
+		endpoints, err := utils.GetServiceEndpoints(ctx, cl, svc.Namespace, svc.Name)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		set := utils.ForgePodIpsSet(fmt.Sprintf("ns-%s", ns), pods)
+		set := utils.ForgeIPsSet(utils.ForgeServiceSetName(svc.Namespace, svc.Name), endpoints.Addresses)
+		setOrigins[set.Name] = utils.ForgeServiceSetOrigin(svc.Namespace, svc.Name)
 		spec.Table.Sets = append(spec.Table.Sets, set)
 	}
 
+	// Create FQDN sets if needed
+	for name := range usedFQDNs {
+		// Create a set for each domain name, containing the addresses it currently resolves to
+		addresses, err := resolver.Lookup(ctx, name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		set := utils.ForgeIPsSet(utils.ForgeFQDNSetName(name), addresses)
+		setOrigins[set.Name] = utils.ForgeFQDNSetOrigin(name)
+		spec.Table.Sets = append(spec.Table.Sets, set)
+	}
+
+	// The used constructs are tracked in maps, hence the sets are sorted to render the same spec
+	// as long as the sets and their elements do not change.
+	utils.SortSets(spec.Table.Sets)
+
 	// Return the complete FirewallConfiguration spec.
-	return &spec, nil
+	return &spec, setOrigins, nil
 }
 
 // ForgeMatchRule creates firewall match rules for a party (source or destination).
-// It translates a high-level Party specification into low-level nftables match rules
-// and tracks which resource groups are used so their sets can be created.
+// It translates a high-level Party specification into low-level nftables match rules,
+// as a list of alternatives, and tracks which resource groups are used so their sets can be created.
 func ForgeMatchRule(
 	ctx context.Context,
 	cl client.Client,
+	opts *options.Options,
 	party *connectivityv1.Party,
 	clusterID string,
 	position networkingv1beta1firewall.MatchPosition,
 	usedResourceGroups map[connectivityv1.ResourceGroup]struct{},
 	usedNamespaces map[string]struct{},
-) (matchRules []networkingv1beta1firewall.Match, err error) {
+	usedServices map[types.NamespacedName]struct{},
+	usedFQDNs map[string]struct{},
+) (matchRules [][]networkingv1beta1firewall.Match, err error) {
 	if party == nil {
 		// No party specified, so no match rules needed (matches all).
 		return nil, nil
@@ -187,7 +264,7 @@ func ForgeMatchRule(
 
 	if party.Group != nil {
 		// Generate match rules for the specified resource group.
-		matchRules, err = resourcegroups.ResourceGroupFuncts[*party.Group].MakeFirewallConfigurationRule(ctx, cl, clusterID, position)
+		matchRules, err = resourcegroups.ResourceGroupFuncts[*party.Group].MakeFirewallConfigurationRule(ctx, cl, opts, clusterID, position)
 		if err != nil {
 			return nil, err
 		}
@@ -198,15 +275,51 @@ func ForgeMatchRule(
 		usedNamespaces[*party.Namespace] = struct{}{}
 
 		// Generate match rules for the specified namespace.
-		matchRules = []networkingv1beta1firewall.Match{{
+		matchRules = [][]networkingv1beta1firewall.Match{{{
 			IP: &networkingv1beta1firewall.MatchIP{
-				Value:    fmt.Sprintf("@ns-%s", *party.Namespace),
+				Value:    utils.ForgeSetReference(utils.ForgeNamespaceSetName(*party.Namespace)),
+				Position: position,
+			},
+			Op: networkingv1beta1firewall.MatchOperationEq,
+		}}}
+	} else if party.Service != nil {
+		// Mark this Service as used so its set can be created.
+		usedServices[types.NamespacedName{Namespace: party.Service.Namespace, Name: party.Service.Name}] = struct{}{}
+
+		// Generate match rules for the addresses of the specified Service.
+		ipMatch := []networkingv1beta1firewall.Match{{
+			IP: &networkingv1beta1firewall.MatchIP{
+				Value:    utils.ForgeSetReference(utils.ForgeServiceSetName(party.Service.Namespace, party.Service.Name)),
 				Position: position,
 			},
 			Op: networkingv1beta1firewall.MatchOperationEq,
 		}}
+
+		if len(party.Service.Ports) == 0 {
+			matchRules = [][]networkingv1beta1firewall.Match{ipMatch}
+		} else {
+			// Restrict the match to the selected ports, both before and after the service translation.
+			endpoints, err := utils.GetServiceEndpoints(ctx, cl, party.Service.Namespace, party.Service.Name)
+			if err != nil {
+				return nil, err
+			}
+			matchRules = utils.ForgePortMatchAlternatives(ipMatch, endpoints.FilterPorts(party.Service.Ports).AllPorts(), position)
+		}
+	} else if party.FQDN != nil {
+		// Mark this domain name as used so its set can be created.
+		name := fqdn.Canonicalize(string(*party.FQDN))
+		usedFQDNs[name] = struct{}{}
+
+		// Generate match rules for the addresses the specified domain name resolves to.
+		matchRules = [][]networkingv1beta1firewall.Match{{{
+			IP: &networkingv1beta1firewall.MatchIP{
+				Value:    utils.ForgeSetReference(utils.ForgeFQDNSetName(name)),
+				Position: position,
+			},
+			Op: networkingv1beta1firewall.MatchOperationEq,
+		}}}
 	} else {
-		return nil, fmt.Errorf("party must specify either a resource group or a namespace")
+		return nil, fmt.Errorf("party must specify either a resource group, a namespace, a service or an FQDN")
 	}
 
 	return matchRules, nil
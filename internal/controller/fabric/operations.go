@@ -18,10 +18,12 @@ import (
 	"context"
 
 	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
-	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/fqdn"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
@@ -29,18 +31,21 @@ import (
 // ReconcileFabricFirewallConfiguration ensures that the FirewallConfiguration
 // resource for the fabric connectivity rules exists and is up to date.
 // It creates or updates the resource as needed based on the provided
-// PeeringConnectivity configuration.
+// chain of PeeringConnectivity resources of the tenant namespace and operator options.
 func ReconcileFabricFirewallConfiguration(
 	ctx context.Context,
 	c client.Client,
 	scheme *runtime.Scheme,
-	cfg *connectivityv1.PeeringConnectivity,
+	opts *options.Options,
+	resolver *fqdn.Cache,
+	chain *utils.PeeringConnectivityChain,
 	clusterID string,
 ) (controllerutil.OperationResult, error) {
+	cfg := chain.Effective
 	fabricFwcfg := networkingv1beta1.FirewallConfiguration{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      ForgeFabricResourceName(clusterID),
-			Namespace: utils.GetClusterNamespace(clusterID),
+			Namespace: cfg.Namespace,
 		},
 	}
 
@@ -50,29 +55,42 @@ func ReconcileFabricFirewallConfiguration(
 		fabricFwcfg.SetLabels(ForgeFabricLabels(clusterID))
 
 		// Generate the FirewallConfiguration spec based on the PeeringConnectivity rules.
-		spec, err := ForgeFabricSpec(ctx, c, cfg, clusterID)
+		spec, setOrigins, err := ForgeFabricSpec(ctx, c, opts, resolver, cfg, clusterID)
 		if err != nil {
 			return err
 		}
-		fabricFwcfg.Spec = *spec
+		if _, err := utils.SetFirewallConfigurationSpec(&fabricFwcfg, spec); err != nil {
+			return err
+		}
 
-		// Set owner reference so the FirewallConfiguration is deleted when the
-		// PeeringConnectivity is deleted.
-		return controllerutil.SetOwnerReference(cfg, &fabricFwcfg, scheme)
+		// Record the policy construct each set represents, as set names are not human readable.
+		if err := setOrigins.Annotate(&fabricFwcfg); err != nil {
+			return err
+		}
+
+		// Set the owner references so the FirewallConfiguration is deleted when all
+		// the PeeringConnectivity resources of the chain are deleted.
+		fabricFwcfg.OwnerReferences = nil
+		for _, member := range chain.Members {
+			if err := controllerutil.SetOwnerReference(member, &fabricFwcfg, scheme); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 }
 
 // EnsureFabricFirewallConfigurationDeleted deletes the fabric-level FirewallConfiguration
-// resource associated with the given cluster ID, if it exists.
+// resource associated with the given cluster ID from the given tenant namespace, if it exists.
 func EnsureFabricFirewallConfigurationDeleted(
 	ctx context.Context,
 	c client.Client,
-	clusterID string,
+	namespace, clusterID string,
 ) error {
 	fabricFwcfg := networkingv1beta1.FirewallConfiguration{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      ForgeFabricResourceName(clusterID),
-			Namespace: utils.GetClusterNamespace(clusterID),
+			Namespace: namespace,
 		},
 	}
 
@@ -82,3 +100,21 @@ func EnsureFabricFirewallConfigurationDeleted(
 	}
 	return nil
 }
+
+// EnsureLegacyFabricFirewallConfigurationDeleted deletes the fabric-level FirewallConfiguration
+// associated with the given cluster ID in the given tenant namespace if it still uses the legacy
+// shared table name, so that the shared table is removed from the nodes before the per-peering
+// one is created.
+// It returns true while the deletion is still in progress.
+func EnsureLegacyFabricFirewallConfigurationDeleted(
+	ctx context.Context,
+	c client.Client,
+	namespace, clusterID string,
+) (bool, error) {
+	key := types.NamespacedName{
+		Name:      ForgeFabricResourceName(clusterID),
+		Namespace: namespace,
+	}
+
+	return utils.EnsureLegacyFirewallConfigurationDeleted(ctx, c, key, LegacyFabricTableName)
+}
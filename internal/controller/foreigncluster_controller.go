@@ -0,0 +1,472 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/fabric"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/gateway"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/networkpolicy"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/template"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/yaml"
+)
+
+// ForeignClusterReconciler drives the lifecycle of the PeeringConnectivity resources from the
+// Liqo ForeignCluster resources: it creates a PeeringConnectivity when a peering is established,
+// keeps it aligned with the PeeringConnectivityTemplate resources matching the peering, and
+// deletes the resources of the peering when it is torn down.
+type ForeignClusterReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+	Options  *options.Options
+	// DefaultSpec is the spec of the automatically created PeeringConnectivity resources.
+	DefaultSpec *connectivityv1.PeeringConnectivitySpec
+}
+
+const (
+	// AutoCreatedLabelKey marks the PeeringConnectivity resources created when the peering was established.
+	AutoCreatedLabelKey = "connectivity.liqo.io/auto-created"
+
+	// PeeringCleanupFinalizer is added to the ForeignCluster resources with PeeringConnectivity resources in their
+	// tenant namespace while the cleanup is enabled, so that the resources of a torn down peering are deleted from
+	// the cluster ID and the tenant namespace they record.
+	PeeringCleanupFinalizer = "connectivity.liqo.io/peering-cleanup"
+
+	// EventReasonPeeringConnectivityCreated is emitted when a PeeringConnectivity is created for an established peering.
+	EventReasonPeeringConnectivityCreated = "PeeringConnectivityCreated"
+	// EventReasonPeeringConnectivityUpdated is emitted when a PeeringConnectivity is updated from the templates.
+	EventReasonPeeringConnectivityUpdated = "PeeringConnectivityUpdated"
+	// EventReasonPeeringConnectivityDeleted is emitted when a PeeringConnectivity is deleted as no template matches.
+	EventReasonPeeringConnectivityDeleted = "PeeringConnectivityDeleted"
+	// EventReasonPeeringTornDown is emitted when a PeeringConnectivity is deleted because the peering was torn down.
+	EventReasonPeeringTornDown = "PeeringTornDown"
+	// EventReasonPeeringCleanupError is emitted when the resources of a torn down peering cannot be deleted.
+	EventReasonPeeringCleanupError = "PeeringCleanupError"
+
+	// peeringCleanupRequeueDelay is the delay before checking again whether the PeeringConnectivity
+	// resources of a torn down peering have been finalized.
+	peeringCleanupRequeueDelay = 5 * time.Second
+)
+
+// +kubebuilder:rbac:groups=core.liqo.io,resources=foreignclusters,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=core.liqo.io,resources=foreignclusters/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=connectivity.liqo.io,resources=peeringconnectivitytemplates,verbs=get;list;watch
+
+// NewForeignClusterReconciler creates a new ForeignClusterReconciler, loading the spec of the
+// automatically created PeeringConnectivity resources from the file set in the options, if any.
+func NewForeignClusterReconciler(mgr ctrl.Manager, opts *options.Options) (*ForeignClusterReconciler, error) {
+	spec, err := loadDefaultPeeringConnectivitySpec(opts.Peering.DefaultSpecFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ForeignClusterReconciler{
+		Client:      mgr.GetClient(),
+		Recorder:    mgr.GetEventRecorderFor("foreigncluster-controller"),
+		Options:     opts,
+		DefaultSpec: spec,
+	}, nil
+}
+
+// loadDefaultPeeringConnectivitySpec reads the PeeringConnectivity spec from the given YAML file.
+// An empty path results in a spec without rules.
+func loadDefaultPeeringConnectivitySpec(path string) (*connectivityv1.PeeringConnectivitySpec, error) {
+	spec := &connectivityv1.PeeringConnectivitySpec{}
+	if path == "" {
+		return spec, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the default PeeringConnectivity spec: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(data, spec); err != nil {
+		return nil, fmt.Errorf("invalid default PeeringConnectivity spec %s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// Reconcile creates the PeeringConnectivity of the peering described by the ForeignCluster once the
+// networking is established, and cleans up the resources of the peering when the ForeignCluster is deleted.
+func (r *ForeignClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	foreignCluster := &liqov1beta1.ForeignCluster{}
+	if err := r.Client.Get(ctx, req.NamespacedName, foreignCluster); err != nil {
+		if !errors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("unable to get the ForeignCluster %q: %w", req.Name, err)
+		}
+
+		// The peering has been torn down, and its resources cleaned up before the finalizer was removed.
+		return ctrl.Result{}, nil
+	}
+
+	clusterID := string(foreignCluster.Spec.ClusterID)
+	if !foreignCluster.DeletionTimestamp.IsZero() {
+		pending, err := r.cleanupPeering(ctx, foreignCluster, clusterID)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if pending {
+			// The finalizers of the PeeringConnectivity resources resolve the cluster ID of their
+			// namespace from the ForeignCluster, hence it is kept until they are finalized.
+			log.FromContext(ctx).Info("waiting for the PeeringConnectivity resources of the torn down peering to be finalized")
+			return ctrl.Result{RequeueAfter: peeringCleanupRequeueDelay}, nil
+		}
+		return ctrl.Result{}, r.removeCleanupFinalizer(ctx, foreignCluster)
+	}
+
+	// The PeeringConnectivity is not created until the networking of the peering is established.
+	if utils.IsPeeringEstablished(foreignCluster) {
+		if err := r.ensurePeeringConnectivity(ctx, foreignCluster, clusterID); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, r.ensureCleanupFinalizer(ctx, foreignCluster, clusterID)
+}
+
+// ensurePeeringConnectivity ensures the PeeringConnectivity managed by the operator in the tenant
+// namespace of the peering reflects the templates matching it, or the default spec if none match and
+// the automatic creation is enabled. The PeeringConnectivity resources created by the users take
+// precedence: if any exists in the tenant namespace, no PeeringConnectivity is created.
+func (r *ForeignClusterReconciler) ensurePeeringConnectivity(
+	ctx context.Context, foreignCluster *liqov1beta1.ForeignCluster, clusterID string,
+) error {
+	var templates connectivityv1.PeeringConnectivityTemplateList
+	if err := r.Client.List(ctx, &templates); err != nil {
+		return fmt.Errorf("unable to list the PeeringConnectivityTemplate resources: %w", err)
+	}
+	merged := template.Merge(template.Select(templates.Items, foreignCluster))
+
+	namespace := foreignCluster.Status.TenantNamespace.Local
+	peeringConnectivityList := &connectivityv1.PeeringConnectivityList{}
+	if err := r.Client.List(ctx, peeringConnectivityList, client.InNamespace(namespace)); err != nil {
+		return fmt.Errorf("unable to list the PeeringConnectivity resources in %q: %w", namespace, err)
+	}
+
+	for i := range peeringConnectivityList.Items {
+		if pc := &peeringConnectivityList.Items[i]; IsManagedPeeringConnectivity(pc, clusterID) {
+			return r.updatePeeringConnectivity(ctx, foreignCluster, pc, merged)
+		}
+	}
+
+	if len(peeringConnectivityList.Items) > 0 || (len(merged.Templates) == 0 && !r.Options.Peering.AutoCreate) {
+		return nil
+	}
+	return r.createPeeringConnectivity(ctx, foreignCluster, namespace, clusterID, merged)
+}
+
+// IsManagedPeeringConnectivity returns whether the PeeringConnectivity is the one created by the
+// operator for the peering with the given cluster.
+func IsManagedPeeringConnectivity(pc *connectivityv1.PeeringConnectivity, clusterID string) bool {
+	return pc.Name == clusterID && pc.Labels[AutoCreatedLabelKey] == "true"
+}
+
+// createPeeringConnectivity creates the PeeringConnectivity of the peering from the merged templates,
+// or from the default spec if no template matches.
+func (r *ForeignClusterReconciler) createPeeringConnectivity(
+	ctx context.Context, foreignCluster *liqov1beta1.ForeignCluster, namespace, clusterID string, merged *template.Result,
+) error {
+	pc := &connectivityv1.PeeringConnectivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterID,
+			Namespace: namespace,
+			Labels:    map[string]string{AutoCreatedLabelKey: "true"},
+		},
+		Spec: *r.DefaultSpec.DeepCopy(),
+	}
+	if len(merged.Templates) > 0 {
+		pc.Annotations = map[string]string{template.AppliedTemplatesAnnotationKey: template.FormatAppliedTemplates(merged.Templates)}
+		pc.Spec = merged.Spec
+	}
+
+	if err := r.Client.Create(ctx, pc); err != nil {
+		if errors.IsAlreadyExists(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to create the PeeringConnectivity %s/%s: %w", namespace, clusterID, err)
+	}
+
+	log.FromContext(ctx).Info("created the PeeringConnectivity for the established peering",
+		"clusterID", clusterID, "namespace", namespace, "templates", merged.Templates)
+	r.Recorder.Eventf(foreignCluster, corev1.EventTypeNormal, EventReasonPeeringConnectivityCreated,
+		"PeeringConnectivity %s/%s created for the established peering%s", namespace, pc.Name, describeTemplates(merged.Templates))
+	return nil
+}
+
+// updatePeeringConnectivity aligns the managed PeeringConnectivity with the merged templates. If the
+// templates no longer match, it falls back to the default spec, or it is deleted if the automatic
+// creation is disabled. A PeeringConnectivity created from the default spec is left untouched until
+// a template matches the peering.
+func (r *ForeignClusterReconciler) updatePeeringConnectivity(
+	ctx context.Context, foreignCluster *liqov1beta1.ForeignCluster, pc *connectivityv1.PeeringConnectivity, merged *template.Result,
+) error {
+	logger := log.FromContext(ctx)
+	spec := merged.Spec
+
+	if len(merged.Templates) == 0 {
+		if len(template.AppliedTemplates(pc.Annotations)) == 0 {
+			return nil
+		}
+
+		if !r.Options.Peering.AutoCreate {
+			if err := r.Client.Delete(ctx, pc); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("unable to delete the PeeringConnectivity %s/%s: %w", pc.Namespace, pc.Name, err)
+			}
+			logger.Info("deleted the PeeringConnectivity no longer matched by any template", "peeringConnectivity", pc.Name)
+			r.Recorder.Eventf(foreignCluster, corev1.EventTypeNormal, EventReasonPeeringConnectivityDeleted,
+				"PeeringConnectivity %s/%s deleted as no template matches the peering", pc.Namespace, pc.Name)
+			return nil
+		}
+		spec = *r.DefaultSpec.DeepCopy()
+	}
+
+	annotation := template.FormatAppliedTemplates(merged.Templates)
+	if equality.Semantic.DeepEqual(pc.Spec, spec) && pc.Annotations[template.AppliedTemplatesAnnotationKey] == annotation {
+		return nil
+	}
+
+	original := pc.DeepCopy()
+	pc.Spec = spec
+	if annotation == "" {
+		delete(pc.Annotations, template.AppliedTemplatesAnnotationKey)
+	} else {
+		if pc.Annotations == nil {
+			pc.Annotations = map[string]string{}
+		}
+		pc.Annotations[template.AppliedTemplatesAnnotationKey] = annotation
+	}
+	if err := r.Client.Patch(ctx, pc, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("unable to update the PeeringConnectivity %s/%s: %w", pc.Namespace, pc.Name, err)
+	}
+
+	logger.Info("updated the PeeringConnectivity from the templates", "peeringConnectivity", pc.Name, "templates", merged.Templates)
+	r.Recorder.Eventf(foreignCluster, corev1.EventTypeNormal, EventReasonPeeringConnectivityUpdated,
+		"PeeringConnectivity %s/%s updated%s", pc.Namespace, pc.Name, describeTemplates(merged.Templates))
+	return nil
+}
+
+// describeTemplates returns the suffix of the event messages listing the applied templates.
+func describeTemplates(templates []string) string {
+	if len(templates) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" from templates %s", strings.Join(templates, ", "))
+}
+
+// peeringConnectivityEnqueuer enqueues the ForeignCluster resources using the tenant namespace of the
+// PeeringConnectivity, whose cleanup finalizer depends on the PeeringConnectivity resources of the namespace.
+func (r *ForeignClusterReconciler) peeringConnectivityEnqueuer(ctx context.Context, obj client.Object) []ctrl.Request {
+	var foreignClusters liqov1beta1.ForeignClusterList
+	if err := r.Client.List(ctx, &foreignClusters,
+		client.MatchingFields{utils.ForeignClusterTenantNamespaceIndex: obj.GetNamespace()}); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list the ForeignCluster resources", "namespace", obj.GetNamespace())
+		return nil
+	}
+
+	requests := make([]ctrl.Request, len(foreignClusters.Items))
+	for i := range foreignClusters.Items {
+		requests[i] = ctrl.Request{NamespacedName: types.NamespacedName{Name: foreignClusters.Items[i].Name}}
+	}
+	return requests
+}
+
+// allForeignClusterEnqueuer enqueues all the ForeignCluster resources, e.g. when a template changes.
+func (r *ForeignClusterReconciler) allForeignClusterEnqueuer(ctx context.Context, _ client.Object) []ctrl.Request {
+	var foreignClusters liqov1beta1.ForeignClusterList
+	if err := r.Client.List(ctx, &foreignClusters); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list the ForeignCluster resources")
+		return nil
+	}
+
+	requests := make([]ctrl.Request, len(foreignClusters.Items))
+	for i := range foreignClusters.Items {
+		requests[i] = ctrl.Request{NamespacedName: types.NamespacedName{Name: foreignClusters.Items[i].Name}}
+	}
+	return requests
+}
+
+// ensureCleanupFinalizer adds the PeeringCleanupFinalizer to the ForeignCluster if the cleanup is enabled and
+// its tenant namespace contains PeeringConnectivity resources, and removes it otherwise, so that the peerings
+// not managed by the operator, as well as disabling the cleanup, never block the deletion of the ForeignClusters.
+func (r *ForeignClusterReconciler) ensureCleanupFinalizer(
+	ctx context.Context, foreignCluster *liqov1beta1.ForeignCluster, clusterID string,
+) error {
+	if !r.Options.Peering.Cleanup {
+		return r.removeCleanupFinalizer(ctx, foreignCluster)
+	}
+
+	namespace, err := r.tenantNamespace(ctx, foreignCluster, clusterID)
+	if err != nil {
+		return err
+	}
+	peeringConnectivityList := &connectivityv1.PeeringConnectivityList{}
+	if err := r.Client.List(ctx, peeringConnectivityList, client.InNamespace(namespace)); err != nil {
+		return fmt.Errorf("unable to list the PeeringConnectivity resources in %q: %w", namespace, err)
+	}
+	if len(peeringConnectivityList.Items) == 0 {
+		return r.removeCleanupFinalizer(ctx, foreignCluster)
+	}
+
+	original := foreignCluster.DeepCopy()
+	if !controllerutil.AddFinalizer(foreignCluster, PeeringCleanupFinalizer) {
+		return nil
+	}
+	// The finalizers are owned by Liqo as well, hence the patch fails if they changed in the meantime.
+	if err := r.Client.Patch(ctx, foreignCluster, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+		return fmt.Errorf("unable to add the finalizer to the ForeignCluster %q: %w", foreignCluster.Name, err)
+	}
+	return nil
+}
+
+// removeCleanupFinalizer removes the PeeringCleanupFinalizer from the ForeignCluster, if present.
+func (r *ForeignClusterReconciler) removeCleanupFinalizer(ctx context.Context, foreignCluster *liqov1beta1.ForeignCluster) error {
+	original := foreignCluster.DeepCopy()
+	if !controllerutil.RemoveFinalizer(foreignCluster, PeeringCleanupFinalizer) {
+		return nil
+	}
+	if err := r.Client.Patch(ctx, foreignCluster, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+		return fmt.Errorf("unable to remove the finalizer from the ForeignCluster %q: %w", foreignCluster.Name, err)
+	}
+	return nil
+}
+
+// cleanupPeering deletes the PeeringConnectivity resources of the torn down peering with the given
+// cluster, as well as the FirewallConfigurations and the NetworkPolicies generated for it, which may
+// be left behind if the tenant namespace is already gone. It returns whether some PeeringConnectivity
+// resources are still being finalized.
+func (r *ForeignClusterReconciler) cleanupPeering(
+	ctx context.Context, foreignCluster *liqov1beta1.ForeignCluster, clusterID string,
+) (bool, error) {
+	if !r.Options.Peering.Cleanup {
+		return false, nil
+	}
+
+	pending, err := r.deletePeeringResources(ctx, foreignCluster, clusterID)
+	if err != nil {
+		r.Recorder.Eventf(foreignCluster, corev1.EventTypeWarning, EventReasonPeeringCleanupError,
+			"Failed to clean up the peering resources: %v", err)
+		return false, err
+	}
+	return pending, nil
+}
+
+// tenantNamespace returns the tenant namespace of the peering, as recorded by the ForeignCluster,
+// or resolved from the cluster ID if the ForeignCluster does not record it.
+func (r *ForeignClusterReconciler) tenantNamespace(
+	ctx context.Context, foreignCluster *liqov1beta1.ForeignCluster, clusterID string,
+) (string, error) {
+	if namespace := foreignCluster.Status.TenantNamespace.Local; namespace != "" {
+		return namespace, nil
+	}
+	return utils.GetTenantNamespace(ctx, r.Client, clusterID)
+}
+
+// deletePeeringResources deletes the resources of the peering with the given cluster, and returns
+// whether some of its PeeringConnectivity resources are still being finalized.
+func (r *ForeignClusterReconciler) deletePeeringResources(
+	ctx context.Context, foreignCluster *liqov1beta1.ForeignCluster, clusterID string,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	namespace, err := r.tenantNamespace(ctx, foreignCluster, clusterID)
+	if err != nil {
+		return false, err
+	}
+
+	// Deleting the PeeringConnectivity resources lets their finalizer remove the generated resources.
+	peeringConnectivityList := &connectivityv1.PeeringConnectivityList{}
+	if err := r.Client.List(ctx, peeringConnectivityList, client.InNamespace(namespace)); err != nil {
+		return false, fmt.Errorf("unable to list the PeeringConnectivity resources in %q: %w", namespace, err)
+	}
+	for i := range peeringConnectivityList.Items {
+		pc := &peeringConnectivityList.Items[i]
+		if !pc.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		r.Recorder.Eventf(pc, corev1.EventTypeNormal, EventReasonPeeringTornDown,
+			"Deleting the PeeringConnectivity as the peering with cluster %s was torn down", clusterID)
+		if err := r.Client.Delete(ctx, pc); client.IgnoreNotFound(err) != nil {
+			return false, fmt.Errorf("unable to delete the PeeringConnectivity %s/%s: %w", pc.Namespace, pc.Name, err)
+		}
+		logger.Info("deleted the PeeringConnectivity of the torn down peering", "clusterID", clusterID, "peeringConnectivity", pc.Name)
+	}
+
+	// The PeeringConnectivity resources with a finalizer are still present once deleted.
+	remaining := &connectivityv1.PeeringConnectivityList{}
+	if err := r.Client.List(ctx, remaining, client.InNamespace(namespace)); err != nil {
+		return false, fmt.Errorf("unable to list the PeeringConnectivity resources in %q: %w", namespace, err)
+	}
+	if len(remaining.Items) > 0 {
+		return true, nil
+	}
+
+	// The generated resources are also deleted explicitly, in case the PeeringConnectivity resources
+	// are already gone and could not remove them.
+	if err := gateway.EnsureGatewayFirewallConfigurationDeleted(ctx, r.Client, namespace, clusterID); client.IgnoreNotFound(err) != nil {
+		return false, fmt.Errorf("unable to delete the gateway FirewallConfiguration: %w", err)
+	}
+	if err := fabric.EnsureFabricFirewallConfigurationDeleted(ctx, r.Client, namespace, clusterID); client.IgnoreNotFound(err) != nil {
+		return false, fmt.Errorf("unable to delete the fabric FirewallConfiguration: %w", err)
+	}
+	if err := networkpolicy.EnsureStrayNetworkPoliciesDeleted(ctx, r.Client, clusterID); err != nil {
+		return false, fmt.Errorf("unable to delete the NetworkPolicies: %w", err)
+	}
+
+	return false, nil
+}
+
+// SetupWithManager sets up the controller with the Manager, reconciling the ForeignCluster resources.
+// It watches the PeeringConnectivityTemplate resources, and the creation and deletion of the
+// PeeringConnectivity resources, which determine whether the cleanup finalizer is needed.
+// It relies on the identity indexes registered by utils.SetupIdentityIndexes.
+func (r *ForeignClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&liqov1beta1.ForeignCluster{}).
+		Watches(&connectivityv1.PeeringConnectivityTemplate{}, handler.EnqueueRequestsFromMapFunc(r.allForeignClusterEnqueuer),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&connectivityv1.PeeringConnectivity{}, handler.EnqueueRequestsFromMapFunc(r.peeringConnectivityEnqueuer),
+			builder.WithPredicates(predicate.Funcs{
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					return e.ObjectOld.GetDeletionTimestamp().IsZero() != e.ObjectNew.GetDeletionTimestamp().IsZero()
+				},
+			})).
+		Named("foreigncluster").
+		Complete(r)
+}
@@ -0,0 +1,335 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/template"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
+)
+
+var _ = Describe("ForeignCluster Controller", func() {
+	Context("When the peering lifecycle changes", func() {
+		const clusterID = "lifecycle-cluster"
+
+		var (
+			ctx        context.Context
+			namespace  string
+			recorder   *record.FakeRecorder
+			reconciler *ForeignClusterReconciler
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			namespace = "liqo-tenant-" + clusterID
+			recorder = record.NewFakeRecorder(10)
+
+			opts := options.NewDefaultOptions()
+			opts.Peering.AutoCreate = true
+			reconciler = &ForeignClusterReconciler{
+				Client:   indexedK8sClient,
+				Recorder: recorder,
+				Options:  opts,
+				DefaultSpec: &connectivityv1.PeeringConnectivitySpec{
+					Rules: []connectivityv1.Rule{{
+						Action:      connectivityv1.ActionAllow,
+						Source:      &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupRemoteCluster)},
+						Destination: &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupLocalCluster)},
+					}},
+				},
+			}
+
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+			if err := k8sClient.Create(ctx, ns); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		AfterEach(func() {
+			foreignCluster := &liqov1beta1.ForeignCluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: clusterID}, foreignCluster); err == nil {
+				foreignCluster.Finalizers = nil
+				Expect(k8sClient.Update(ctx, foreignCluster)).To(Succeed())
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, foreignCluster))).To(Succeed())
+			}
+			Expect(k8sClient.DeleteAllOf(ctx, &connectivityv1.PeeringConnectivity{}, client.InNamespace(namespace))).To(Succeed())
+			Expect(k8sClient.DeleteAllOf(ctx, &connectivityv1.PeeringConnectivityTemplate{})).To(Succeed())
+		})
+
+		createTemplate := func(name string, priority int32, group connectivityv1.ResourceGroup) {
+			Expect(k8sClient.Create(ctx, &connectivityv1.PeeringConnectivityTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec: connectivityv1.PeeringConnectivityTemplateSpec{
+					ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "trusted"}},
+					Priority:        priority,
+					Template: connectivityv1.PeeringConnectivitySpec{
+						Rules: []connectivityv1.Rule{{
+							Action:      connectivityv1.ActionAllow,
+							Destination: &connectivityv1.Party{Group: ptr.To(group)},
+						}},
+					},
+				},
+			})).To(Succeed())
+		}
+
+		createForeignCluster := func(networkingEnabled bool, labels map[string]string) *liqov1beta1.ForeignCluster {
+			foreignCluster := &liqov1beta1.ForeignCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: clusterID, Labels: labels},
+				Spec:       liqov1beta1.ForeignClusterSpec{ClusterID: clusterID},
+			}
+			Expect(k8sClient.Create(ctx, foreignCluster)).To(Succeed())
+
+			foreignCluster.Status.Modules.Networking.Enabled = networkingEnabled
+			foreignCluster.Status.TenantNamespace.Local = namespace
+			Expect(k8sClient.Status().Update(ctx, foreignCluster)).To(Succeed())
+			return foreignCluster
+		}
+
+		reconcileForeignCluster := func() {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: clusterID}})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		It("should create the default PeeringConnectivity once the peering is established", func() {
+			createForeignCluster(true, nil)
+			reconcileForeignCluster()
+
+			pc := &connectivityv1.PeeringConnectivity{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: clusterID, Namespace: namespace}, pc)).To(Succeed())
+			Expect(pc.Labels).To(HaveKeyWithValue(AutoCreatedLabelKey, "true"))
+			Expect(pc.Spec).To(Equal(*reconciler.DefaultSpec))
+			Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonPeeringConnectivityCreated)))
+
+			By("not creating it again on subsequent reconciliations")
+			reconcileForeignCluster()
+			Expect(recorder.Events).NotTo(Receive())
+		})
+
+		It("should not create the PeeringConnectivity before the networking is established", func() {
+			createForeignCluster(false, nil)
+			reconcileForeignCluster()
+
+			pcList := &connectivityv1.PeeringConnectivityList{}
+			Expect(k8sClient.List(ctx, pcList, client.InNamespace(namespace))).To(Succeed())
+			Expect(pcList.Items).To(BeEmpty())
+		})
+
+		It("should not create the PeeringConnectivity when the automatic creation is disabled", func() {
+			reconciler.Options.Peering.AutoCreate = false
+			createForeignCluster(true, nil)
+			reconcileForeignCluster()
+
+			pcList := &connectivityv1.PeeringConnectivityList{}
+			Expect(k8sClient.List(ctx, pcList, client.InNamespace(namespace))).To(Succeed())
+			Expect(pcList.Items).To(BeEmpty())
+		})
+
+		It("should merge the matching templates into the PeeringConnectivity", func() {
+			reconciler.Options.Peering.AutoCreate = false
+			createTemplate("low", 0, connectivityv1.ResourceGroupInternet)
+			createTemplate("high", 10, connectivityv1.ResourceGroupLocalCluster)
+			createForeignCluster(true, map[string]string{"tier": "trusted"})
+			reconcileForeignCluster()
+
+			pc := &connectivityv1.PeeringConnectivity{}
+			key := types.NamespacedName{Name: clusterID, Namespace: namespace}
+			Expect(k8sClient.Get(ctx, key, pc)).To(Succeed())
+			Expect(pc.Annotations).To(HaveKeyWithValue(template.AppliedTemplatesAnnotationKey, "high,low"))
+			Expect(pc.Spec.Rules).To(HaveLen(2))
+			Expect(*pc.Spec.Rules[0].Destination.Group).To(Equal(connectivityv1.ResourceGroupLocalCluster))
+			Expect(*pc.Spec.Rules[1].Destination.Group).To(Equal(connectivityv1.ResourceGroupInternet))
+
+			By("deleting the PeeringConnectivity once no template matches")
+			Expect(k8sClient.DeleteAllOf(ctx, &connectivityv1.PeeringConnectivityTemplate{})).To(Succeed())
+			reconcileForeignCluster()
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, key, pc))
+			}).Should(BeTrue())
+		})
+
+		It("should restore the default spec once no template matches", func() {
+			createTemplate("high", 10, connectivityv1.ResourceGroupLocalCluster)
+			createForeignCluster(true, map[string]string{"tier": "trusted"})
+			reconcileForeignCluster()
+
+			Expect(k8sClient.DeleteAllOf(ctx, &connectivityv1.PeeringConnectivityTemplate{})).To(Succeed())
+			reconcileForeignCluster()
+
+			pc := &connectivityv1.PeeringConnectivity{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: clusterID, Namespace: namespace}, pc)).To(Succeed())
+			Expect(pc.Annotations).NotTo(HaveKey(template.AppliedTemplatesAnnotationKey))
+			Expect(pc.Spec).To(Equal(*reconciler.DefaultSpec))
+		})
+
+		It("should not apply the templates to the namespaces with user-managed PeeringConnectivity resources", func() {
+			createTemplate("high", 10, connectivityv1.ResourceGroupLocalCluster)
+			custom := &connectivityv1.PeeringConnectivity{ObjectMeta: metav1.ObjectMeta{Name: "custom", Namespace: namespace}}
+			Expect(k8sClient.Create(ctx, custom)).To(Succeed())
+			createForeignCluster(true, map[string]string{"tier": "trusted"})
+			reconcileForeignCluster()
+
+			pcList := &connectivityv1.PeeringConnectivityList{}
+			Expect(k8sClient.List(ctx, pcList, client.InNamespace(namespace))).To(Succeed())
+			Expect(pcList.Items).To(HaveLen(1))
+			Expect(pcList.Items[0].Name).To(Equal("custom"))
+		})
+
+		It("should delete the PeeringConnectivity resources once the ForeignCluster is deleted", func() {
+			foreignCluster := createForeignCluster(false, nil)
+			pc := &connectivityv1.PeeringConnectivity{ObjectMeta: metav1.ObjectMeta{Name: "custom", Namespace: namespace}}
+			Expect(k8sClient.Create(ctx, pc)).To(Succeed())
+
+			reconcileForeignCluster()
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(foreignCluster), foreignCluster)).To(Succeed())
+			Expect(foreignCluster.Finalizers).To(ContainElement(PeeringCleanupFinalizer))
+
+			By("cleaning up the peering before removing the finalizer")
+			Expect(k8sClient.Delete(ctx, foreignCluster)).To(Succeed())
+			reconcileForeignCluster()
+
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(pc), pc))
+			}).Should(BeTrue())
+			Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonPeeringTornDown)))
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(foreignCluster), foreignCluster))
+			}).Should(BeTrue())
+		})
+
+		It("should keep the finalizer until the PeeringConnectivity resources are finalized", func() {
+			foreignCluster := createForeignCluster(false, nil)
+			pc := &connectivityv1.PeeringConnectivity{ObjectMeta: metav1.ObjectMeta{
+				Name: "custom", Namespace: namespace, Finalizers: []string{FinalizerName},
+			}}
+			Expect(k8sClient.Create(ctx, pc)).To(Succeed())
+			reconcileForeignCluster()
+
+			Expect(k8sClient.Delete(ctx, foreignCluster)).To(Succeed())
+			res, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: clusterID}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.RequeueAfter).To(Equal(peeringCleanupRequeueDelay))
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(pc), pc)).To(Succeed())
+			Expect(pc.DeletionTimestamp.IsZero()).To(BeFalse())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(foreignCluster), foreignCluster)).To(Succeed())
+			Expect(foreignCluster.Finalizers).To(ContainElement(PeeringCleanupFinalizer))
+
+			By("removing the finalizer once the PeeringConnectivity resources are finalized")
+			pc.Finalizers = nil
+			Expect(k8sClient.Update(ctx, pc)).To(Succeed())
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(pc), pc))
+			}).Should(BeTrue())
+			reconcileForeignCluster()
+
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(foreignCluster), foreignCluster))
+			}).Should(BeTrue())
+		})
+
+		It("should add the finalizer only to the ForeignClusters with PeeringConnectivity resources", func() {
+			reconciler.Options.Peering.AutoCreate = false
+			foreignCluster := createForeignCluster(true, nil)
+			reconcileForeignCluster()
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(foreignCluster), foreignCluster)).To(Succeed())
+			Expect(foreignCluster.Finalizers).NotTo(ContainElement(PeeringCleanupFinalizer))
+
+			By("adding it once a PeeringConnectivity is created")
+			pc := &connectivityv1.PeeringConnectivity{ObjectMeta: metav1.ObjectMeta{Name: "custom", Namespace: namespace}}
+			Expect(k8sClient.Create(ctx, pc)).To(Succeed())
+			reconcileForeignCluster()
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(foreignCluster), foreignCluster)).To(Succeed())
+			Expect(foreignCluster.Finalizers).To(ContainElement(PeeringCleanupFinalizer))
+
+			By("removing it once the last PeeringConnectivity is deleted")
+			Expect(k8sClient.Delete(ctx, pc)).To(Succeed())
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(pc), pc))
+			}).Should(BeTrue())
+			reconcileForeignCluster()
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(foreignCluster), foreignCluster)).To(Succeed())
+			Expect(foreignCluster.Finalizers).NotTo(ContainElement(PeeringCleanupFinalizer))
+		})
+
+		It("should keep the PeeringConnectivity resources when the cleanup is disabled", func() {
+			reconciler.Options.Peering.Cleanup = false
+			foreignCluster := createForeignCluster(false, nil)
+			pc := &connectivityv1.PeeringConnectivity{ObjectMeta: metav1.ObjectMeta{Name: "custom", Namespace: namespace}}
+			Expect(k8sClient.Create(ctx, pc)).To(Succeed())
+
+			reconcileForeignCluster()
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(foreignCluster), foreignCluster)).To(Succeed())
+			Expect(foreignCluster.Finalizers).NotTo(ContainElement(PeeringCleanupFinalizer))
+
+			Expect(k8sClient.Delete(ctx, foreignCluster)).To(Succeed())
+			reconcileForeignCluster()
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(pc), pc)).To(Succeed())
+		})
+	})
+
+	Context("When loading the default PeeringConnectivity spec", func() {
+		It("should return an empty spec if no file is set", func() {
+			spec, err := loadDefaultPeeringConnectivitySpec("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(spec.Rules).To(BeEmpty())
+		})
+
+		It("should parse the rules from the file", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "spec.yaml")
+			Expect(os.WriteFile(path, []byte(`rules:
+- action: allow
+  source:
+    group: remote-cluster
+  destination:
+    group: local-cluster
+`), 0o600)).To(Succeed())
+
+			spec, err := loadDefaultPeeringConnectivitySpec(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(spec.Rules).To(HaveLen(1))
+			Expect(spec.Rules[0].Action).To(Equal(connectivityv1.ActionAllow))
+		})
+
+		It("should reject unknown fields", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "spec.yaml")
+			Expect(os.WriteFile(path, []byte("rulez: []\n"), 0o600)).To(Succeed())
+
+			_, err := loadDefaultPeeringConnectivitySpec(path)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail if the file does not exist", func() {
+			_, err := loadDefaultPeeringConnectivitySpec(filepath.Join(GinkgoT().TempDir(), "missing.yaml"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,41 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway
+
+import (
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/counters"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
+)
+
+// AggregateRuleCounters sums the counters of the filter rules of the gateway chains of the given cluster
+// by the connectivity rule they are forged from, keyed by the index of the rule in the chain. The chains
+// attached to every hook are summed, hence a packet traversing several of them is counted once per hook.
+func AggregateRuleCounters(snapshot counters.Snapshot, clusterID string) map[int]counters.Counter {
+	chains := make(map[string]struct{}, len(options.SupportedChainHooks))
+	for _, hook := range options.SupportedChainHooks {
+		chains[ForgeGatewayChainName(clusterID, hook)] = struct{}{}
+	}
+
+	aggregated := make(map[int]counters.Counter)
+	for key, counter := range snapshot {
+		if _, found := chains[key.Chain]; !found {
+			continue
+		}
+		if rule, ok := ParseGatewayRuleName(key.Rule); ok {
+			aggregated[rule] = aggregated[rule].Add(counter)
+		}
+	}
+	return aggregated
+}
@@ -17,15 +17,19 @@ package gateway
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 
 	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
 	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
 	"github.com/liqotech/liqo/pkg/firewall"
 	"github.com/liqotech/liqo/pkg/gateway"
-	"github.com/liqotech/liqo/pkg/gateway/tunnel"
 	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/fqdn"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/resourcegroups"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -34,15 +38,26 @@ const (
 	// gatewayResourceNameSuffix is the suffix appended to the cluster ID to form the gateway FirewallConfiguration name.
 	gatewayResourceNameSuffix = "connectivity-gateway"
 
-	// gatewayTableName is the name of the nftables table used by the gateway FirewallConfiguration.
-	gatewayTableName = "cluster-connectivity"
+	// gatewayTablePrefix is the prefix of the name of the nftables table used by the gateway FirewallConfiguration.
+	gatewayTablePrefix = "connectivity-gw"
 
-	// gatewayChainName is the name of the nftables chain used by the gateway FirewallConfiguration.
-	gatewayChainName = "cluster-connectivity-filter"
+	// gatewayChainPrefix is the prefix of the name of the nftables chain used by the gateway FirewallConfiguration.
+	gatewayChainPrefix = "connectivity-gw-filter"
 
-	// gatewayChainPriority is the priority of the gateway firewall chain.
-	// Lower values have higher priority.
-	gatewayChainPriority = 200
+	// gatewayIngressChainPrefix is the prefix of the name of the nftables chains restricting the
+	// traffic received by the pods of a namespace.
+	gatewayIngressChainPrefix = "connectivity-gw-ns-in"
+
+	// gatewayEgressChainPrefix is the prefix of the name of the nftables chains restricting the
+	// traffic sent by the pods of a namespace.
+	gatewayEgressChainPrefix = "connectivity-gw-ns-out"
+
+	// gatewayRulePrefix is the prefix of the name of the filter rules forged from the connectivity rules.
+	gatewayRulePrefix = "allowed-traffic"
+
+	// LegacyGatewayTableName is the name of the nftables table shared by all the gateway FirewallConfigurations
+	// created by previous versions of the connectivity engine.
+	LegacyGatewayTableName = "cluster-connectivity"
 )
 
 // ForgeGatewayResourceName generates the name of the Gateway FirewallConfiguration resource
@@ -51,6 +66,54 @@ func ForgeGatewayResourceName(clusterID string) string {
 	return fmt.Sprintf("%s-%s", clusterID, gatewayResourceNameSuffix)
 }
 
+// ForgeGatewayTableName generates the name of the nftables table of the Gateway FirewallConfiguration
+// for the given cluster ID, so that the tables of different peerings never collide.
+func ForgeGatewayTableName(clusterID string) string {
+	return utils.ForgeClusterScopedName(gatewayTablePrefix, clusterID)
+}
+
+// ForgeGatewayChainName generates the name of the nftables chain of the Gateway FirewallConfiguration
+// for the given cluster ID attached to the given hook.
+func ForgeGatewayChainName(clusterID, hook string) string {
+	return utils.ForgeClusterScopedName(gatewayChainPrefix, utils.ForgeHookScopedValue(clusterID, hook))
+}
+
+// ForgeGatewayRuleName generates the name of the given alternative of the filter rules forged from the
+// connectivity rule with the given index in the chain. The alternative is omitted if it is the only one.
+func ForgeGatewayRuleName(rule, alternative, alternatives int) string {
+	if alternatives > 1 {
+		return fmt.Sprintf("%s-%d-%d", gatewayRulePrefix, rule, alternative)
+	}
+	return fmt.Sprintf("%s-%d", gatewayRulePrefix, rule)
+}
+
+// ParseGatewayRuleName returns the index in the chain of the connectivity rule the filter rule with the
+// given name is forged from, and false if it is not forged from a connectivity rule.
+func ParseGatewayRuleName(name string) (int, bool) {
+	suffix, found := strings.CutPrefix(name, gatewayRulePrefix+"-")
+	if !found {
+		return 0, false
+	}
+
+	index, _, _ := strings.Cut(suffix, "-")
+	rule, err := strconv.Atoi(index)
+	if err != nil || rule < 0 {
+		return 0, false
+	}
+	return rule, true
+}
+
+// ForgeGatewayRestrictionChainName generates the name of the nftables chain of the Gateway FirewallConfiguration
+// for the given cluster ID attached to the given hook, restricting the traffic of the pods of the given namespace
+// in the given direction.
+func ForgeGatewayRestrictionChainName(clusterID, namespace string, policyType connectivityv1.NamespacePolicyType, hook string) string {
+	prefix := gatewayIngressChainPrefix
+	if policyType == connectivityv1.NamespacePolicyTypeEgress {
+		prefix = gatewayEgressChainPrefix
+	}
+	return utils.ForgeClusterScopedName(prefix, utils.ForgeHookScopedValue(clusterID+"/"+namespace, hook))
+}
+
 // ForgeGatewayLabels creates the labels for a Gateway FirewallConfiguration resource.
 // These labels identify the configuration as a gateway-level connectivity configuration
 // that targets all nodes in the cluster.
@@ -68,110 +131,121 @@ func ForgeGatewayLabels(clusterID string) map[string]string {
 // - Creating firewall sets for dynamic pod IP collections
 // - Creating match rules for source and destination filtering
 // - Setting up allow/deny actions based on the rule specifications
-// - Adding a default rule to allow established/related connections
-func ForgeGatewaySpec(ctx context.Context, cl client.Client, cfg *connectivityv1.PeeringConnectivity, clusterID string) (*networkingv1beta1.FirewallConfigurationSpec, error) {
+// - Adding the preamble rules (connection tracking and interface bypasses)
+// - Accepting the established connections after the connectivity rules, if they are re-evaluated
+// - Attaching a chain with the resulting rules to each of the configured hooks
+// - Creating a chain for each direction restricted by the given namespace restrictions
+//
+// It also returns the policy construct represented by each of the created sets.
+// It fails if the gateway settings of the spec are inconsistent with the operator options,
+// e.g. if an uplink interface is the tunnel interface.
+func ForgeGatewaySpec(
+	ctx context.Context,
+	cl client.Client,
+	opts *options.Options,
+	resolver *fqdn.Cache,
+	cfg *connectivityv1.PeeringConnectivity,
+	restrictions []utils.NamespaceRestriction,
+	clusterID string,
+) (*networkingv1beta1.FirewallConfigurationSpec, utils.SetOrigins, error) {
+	gatewayOpts := ResolveGatewayOptions(opts, cfg.Spec.Gateway)
+
+	// The overrides of the spec are checked against the operator options, which the tunnel interface comes from:
+	// an uplink interface matching it would bypass the chain for all the traffic of the tunnel.
+	if err := gatewayOpts.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid gateway settings: %w", err)
+	}
+
 	// Initialize the FirewallConfiguration with basic structure.
 	spec := networkingv1beta1.FirewallConfigurationSpec{
 		Table: networkingv1beta1firewall.Table{
-			Name:   ptr.To(gatewayTableName),
+			Name:   ptr.To(ForgeGatewayTableName(clusterID)),
 			Family: ptr.To(networkingv1beta1firewall.TableFamilyIPv4),
 			Sets:   make([]networkingv1beta1firewall.Set, 0),
-			Chains: []networkingv1beta1firewall.Chain{{
-				Name:     ptr.To(gatewayChainName),
-				Hook:     ptr.To(networkingv1beta1firewall.ChainHookPostrouting),
-				Policy:   ptr.To(networkingv1beta1firewall.ChainPolicyDrop),
-				Priority: ptr.To[networkingv1beta1firewall.ChainPriority](gatewayChainPriority),
-				Type:     networkingv1beta1firewall.ChainTypeFilter,
-				Rules: networkingv1beta1firewall.RulesSet{
-					FilterRules: []networkingv1beta1firewall.FilterRule{
-						{
-							// First rule: Always allow established and related connections.
-							// This is essential to allow responses to outgoing connections.
-							Name:   ptr.To("allow-established-related"),
-							Action: networkingv1beta1firewall.ActionAccept,
-							Match: []networkingv1beta1firewall.Match{{
-								CtState: &networkingv1beta1firewall.MatchCtState{
-									Value: []networkingv1beta1firewall.CtStateValue{
-										networkingv1beta1firewall.CtStateEstablished,
-										networkingv1beta1firewall.CtStateRelated,
-									},
-								},
-								Op: networkingv1beta1firewall.MatchOperationEq,
-							}},
-						},
-						{
-							// Consider only traffic originating from the tunnel interface.
-							Name:   ptr.To("match-tunnel-interface"),
-							Action: networkingv1beta1firewall.ActionAccept,
-							Match: []networkingv1beta1firewall.Match{{
-								Dev: &networkingv1beta1firewall.MatchDev{
-									Position: networkingv1beta1firewall.MatchDevPositionIn,
-									Value:    tunnel.TunnelInterfaceName,
-								},
-								Op: networkingv1beta1firewall.MatchOperationNeq,
-							}},
-						},
-						{
-							// Always allow traffic to eth0
-							Name:   ptr.To("allow-eth0"),
-							Action: networkingv1beta1firewall.ActionAccept,
-							Match: []networkingv1beta1firewall.Match{{
-								Dev: &networkingv1beta1firewall.MatchDev{
-									Position: networkingv1beta1firewall.MatchDevPositionOut,
-									Value:    "eth0",
-								},
-								Op: networkingv1beta1firewall.MatchOperationEq,
-							}},
-						},
-					},
-				},
-			}},
 		},
 	}
+	var rules []networkingv1beta1firewall.FilterRule
 
 	// Add the allowed traffic rules
 	usedResourceGroups := make(map[connectivityv1.ResourceGroup]struct{})
 	usedNamespaces := make(map[string]struct{})
+	usedServices := make(map[types.NamespacedName]struct{})
+	usedFQDNs := make(map[string]struct{})
 
 	for i, rule := range cfg.Spec.Rules {
-		ruleName := fmt.Sprintf("allowed-traffic-%d", i)
-
-		filterRule := networkingv1beta1firewall.FilterRule{
-			Name:   ptr.To(ruleName),
-			Action: networkingv1beta1firewall.ActionAccept,
-			Match:  []networkingv1beta1firewall.Match{},
-		}
-
 		// Set the action based on the rule specification.
+		action := networkingv1beta1firewall.ActionAccept
 		if rule.Action != connectivityv1.ActionAllow {
-			filterRule.Action = networkingv1beta1firewall.ActionDrop
+			action = networkingv1beta1firewall.ActionDrop
 		}
 
-		// Add match rules for the source (if specified).
-		sourceRules, err := ForgeMatchRule(ctx, cl, rule.Source, clusterID, networkingv1beta1firewall.MatchPositionSrc, usedResourceGroups, usedNamespaces)
-		if err != nil {
-			return nil, err
+		// Add a filter rule to the chain for each combination of the source and destination alternatives
+		// of each flow of the rule, matching the initiator of the connections as the source of the packets.
+		var alternatives [][]networkingv1beta1firewall.Match
+		for _, flow := range utils.ForgeRuleFlows(&cfg.Spec.Rules[i]) {
+			sourceRules, err := ForgeMatchRule(ctx, cl, opts, flow.Initiator, clusterID, networkingv1beta1firewall.MatchPositionSrc, usedResourceGroups, usedNamespaces, usedServices, usedFQDNs)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			destRules, err := ForgeMatchRule(ctx, cl, opts, flow.Responder, clusterID, networkingv1beta1firewall.MatchPositionDst, usedResourceGroups, usedNamespaces, usedServices, usedFQDNs)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			alternatives = append(alternatives, utils.CombineMatchAlternatives(sourceRules, destRules)...)
+		}
+		for j, matches := range alternatives {
+			ruleName := ForgeGatewayRuleName(i, j, len(alternatives))
+			rules = append(rules, networkingv1beta1firewall.FilterRule{
+				Name:    ptr.To(ruleName),
+				Action:  action,
+				Counter: true,
+				Match:   matches,
+			})
 		}
-		filterRule.Match = append(filterRule.Match, sourceRules...)
+	}
+
+	// Accept the established connections not denied by the connectivity rules, if they are re-evaluated.
+	if !gatewayOpts.Stateless && gatewayOpts.ReevaluateEstablished {
+		rules = append(rules, ForgeEstablishedRule(gatewayOpts))
+	}
+
+	// Attach a chain with the same connectivity rules to each hook, as each hook sees a different part of the
+	// traffic. The preamble rules depend on the hook, as not all the interfaces of the packets are known at each hook.
+	for _, hook := range gatewayOpts.ChainHooks {
+		spec.Table.Chains = append(spec.Table.Chains, networkingv1beta1firewall.Chain{
+			Name:     ptr.To(ForgeGatewayChainName(clusterID, hook)),
+			Hook:     ptr.To(networkingv1beta1firewall.ChainHook(hook)),
+			Policy:   ptr.To(networkingv1beta1firewall.ChainPolicyDrop),
+			Priority: ptr.To(networkingv1beta1firewall.ChainPriority(gatewayOpts.ChainPriority)),
+			Type:     networkingv1beta1firewall.ChainTypeFilter,
+			Rules:    networkingv1beta1firewall.RulesSet{FilterRules: append(ForgePreambleRules(gatewayOpts, hook), rules...)},
+		})
+	}
 
-		// Add match rules for the destination (if specified).
-		destRules, err := ForgeMatchRule(ctx, cl, rule.Destination, clusterID, networkingv1beta1firewall.MatchPositionDst, usedResourceGroups, usedNamespaces)
+	// Add the chains restricting the traffic of the pods of the application namespaces.
+	for i := range restrictions {
+		chains, err := forgeRestrictionChains(ctx, cl, opts, cfg, &restrictions[i], clusterID, usedResourceGroups, usedNamespaces, usedServices, usedFQDNs)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		filterRule.Match = append(filterRule.Match, destRules...)
-
-		// Add the filter rule to the chain.
-		spec.Table.Chains[0].Rules.FilterRules = append(spec.Table.Chains[0].Rules.FilterRules, filterRule)
+		spec.Table.Chains = append(spec.Table.Chains, chains...)
 	}
 
+	// Set names are hashed, hence the construct each set represents is tracked separately.
+	setOrigins := make(utils.SetOrigins)
+
 	// Create firewall sets for all resource groups that require them.
 	// Sets contain collections of IP addresses (e.g., pod IPs) that can be referenced in rules.
 	for rg := range usedResourceGroups {
 		if resourcegroups.ResourceGroupFuncts[rg].MakeFirewallConfigurationSets != nil {
-			sets, err := resourcegroups.ResourceGroupFuncts[rg].MakeFirewallConfigurationSets(ctx, cl, clusterID)
+			sets, err := resourcegroups.ResourceGroupFuncts[rg].MakeFirewallConfigurationSets(ctx, cl, opts, clusterID)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
+			}
+			for i := range sets {
+				setOrigins[sets[i].Name] = utils.ForgeGroupSetOrigin(rg)
 			}
 			spec.Table.Sets = append(spec.Table.Sets, sets...)
 		}
@@ -182,29 +256,314 @@ func ForgeGatewaySpec(ctx context.Context, cl client.Client, cfg *connectivityv1
 		// Create a set for each namespace
 		pods, err := utils.GetPodsInNamespace(ctx, cl, ns)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+
+		set := utils.ForgePodIpsSet(utils.ForgeNamespaceSetName(ns), pods)
+		setOrigins[set.Name] = utils.ForgeNamespaceSetOrigin(ns)
+		spec.Table.Sets = append(spec.Table.Sets, set)
+	}
+
+	// Create Service sets if needed
+	for svc := range usedServices {
+		// Create a set for each Service, containing its virtual IPs and its endpoints
+		endpoints, err := utils.GetServiceEndpoints(ctx, cl, svc.Namespace, svc.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		set := utils.ForgeIPsSet(utils.ForgeServiceSetName(svc.Namespace, svc.Name), endpoints.Addresses)
+		setOrigins[set.Name] = utils.ForgeServiceSetOrigin(svc.Namespace, svc.Name)
+		spec.Table.Sets = append(spec.Table.Sets, set)
+	}
+
+	// Create FQDN sets if needed
+	for name := range usedFQDNs {
+		// Create a set for each domain name, containing the addresses it currently resolves to
+		addresses, err := resolver.Lookup(ctx, name)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		set := utils.ForgePodIpsSet(fmt.Sprintf("ns-%s", ns), pods)
+		set := utils.ForgeIPsSet(utils.ForgeFQDNSetName(name), addresses)
+		setOrigins[set.Name] = utils.ForgeFQDNSetOrigin(name)
 		spec.Table.Sets = append(spec.Table.Sets, set)
 	}
 
+	// The used constructs are tracked in maps, hence the sets are sorted to render the same spec
+	// as long as the sets and their elements do not change.
+	utils.SortSets(spec.Table.Sets)
+
 	// Return the complete FirewallConfiguration spec.
-	return &spec, nil
+	return &spec, setOrigins, nil
+}
+
+// forgeRestrictionChains creates the chains restricting the traffic of the pods of a namespace, one for each
+// restricted direction and hook. Each chain accepts by default and drops the traffic of the pods not matching any of the
+// allowed parties. The chains are evaluated after the gateway firewall chain, and a packet must be accepted by
+// all the chains of the hook to pass: hence, they can only restrict the traffic allowed by the peering.
+// Each direction has its own chain, as the traffic accepted by a direction must still be checked by the other.
+func forgeRestrictionChains(
+	ctx context.Context,
+	cl client.Client,
+	opts *options.Options,
+	cfg *connectivityv1.PeeringConnectivity,
+	restriction *utils.NamespaceRestriction,
+	clusterID string,
+	usedResourceGroups map[connectivityv1.ResourceGroup]struct{},
+	usedNamespaces map[string]struct{},
+	usedServices map[types.NamespacedName]struct{},
+	usedFQDNs map[string]struct{},
+) ([]networkingv1beta1firewall.Chain, error) {
+	// Mark the namespace as used so the set of its pods can be created.
+	usedNamespaces[restriction.Namespace] = struct{}{}
+
+	directions := []struct {
+		policyType connectivityv1.NamespacePolicyType
+		restricted bool
+		parties    []connectivityv1.Party
+		// position is the position of the pods of the namespace in the matched packets,
+		// while partyPosition is the one of the allowed parties.
+		position, partyPosition networkingv1beta1firewall.MatchPosition
+	}{{
+		policyType: connectivityv1.NamespacePolicyTypeIngress, restricted: restriction.RestrictIngress, parties: restriction.Ingress,
+		position: networkingv1beta1firewall.MatchPositionDst, partyPosition: networkingv1beta1firewall.MatchPositionSrc,
+	}, {
+		policyType: connectivityv1.NamespacePolicyTypeEgress, restricted: restriction.RestrictEgress, parties: restriction.Egress,
+		position: networkingv1beta1firewall.MatchPositionSrc, partyPosition: networkingv1beta1firewall.MatchPositionDst,
+	}}
+
+	var chains []networkingv1beta1firewall.Chain
+	for _, direction := range directions {
+		if !direction.restricted {
+			continue
+		}
+		name := strings.ToLower(string(direction.policyType))
+
+		namespaceMatch := []networkingv1beta1firewall.Match{{
+			IP: &networkingv1beta1firewall.MatchIP{
+				Value:    utils.ForgeSetReference(utils.ForgeNamespaceSetName(restriction.Namespace)),
+				Position: direction.position,
+			},
+			Op: networkingv1beta1firewall.MatchOperationEq,
+		}}
+
+		// The preamble rules are the same of the gateway firewall chain, so that the chain restricts
+		// exactly the traffic filtered by the peering. The established connections are always accepted
+		// first, though, as the final drop rule would otherwise drop the return traffic of the allowed ones.
+		gatewayOpts := ResolveGatewayOptions(opts, cfg.Spec.Gateway)
+		gatewayOpts.ReevaluateEstablished = false
+		var rules []networkingv1beta1firewall.FilterRule
+		for i := range direction.parties {
+			partyRules, err := ForgeMatchRule(ctx, cl, opts, &direction.parties[i], clusterID, direction.partyPosition, usedResourceGroups, usedNamespaces, usedServices, usedFQDNs)
+			if err != nil {
+				return nil, err
+			}
+
+			alternatives := utils.CombineMatchAlternatives(partyRules, [][]networkingv1beta1firewall.Match{namespaceMatch})
+			for j, matches := range alternatives {
+				ruleName := fmt.Sprintf("allowed-%s-%d", name, i)
+				if len(alternatives) > 1 {
+					ruleName = fmt.Sprintf("allowed-%s-%d-%d", name, i, j)
+				}
+
+				rules = append(rules, networkingv1beta1firewall.FilterRule{
+					Name:    ptr.To(ruleName),
+					Action:  networkingv1beta1firewall.ActionAccept,
+					Counter: true,
+					Match:   matches,
+				})
+			}
+		}
+
+		// Drop the remaining traffic of the pods of the namespace.
+		rules = append(rules, networkingv1beta1firewall.FilterRule{
+			Name:    ptr.To(fmt.Sprintf("restricted-%s", name)),
+			Action:  networkingv1beta1firewall.ActionDrop,
+			Counter: true,
+			Match:   namespaceMatch,
+		})
+
+		// The chains follow the gateway chains at each of their hooks.
+		for _, hook := range gatewayOpts.ChainHooks {
+			chains = append(chains, networkingv1beta1firewall.Chain{
+				Name:     ptr.To(ForgeGatewayRestrictionChainName(clusterID, restriction.Namespace, direction.policyType, hook)),
+				Hook:     ptr.To(networkingv1beta1firewall.ChainHook(hook)),
+				Policy:   ptr.To(networkingv1beta1firewall.ChainPolicyAccept),
+				Priority: ptr.To(networkingv1beta1firewall.ChainPriority(gatewayOpts.ChainPriority + 1)),
+				Type:     networkingv1beta1firewall.ChainTypeFilter,
+				Rules:    networkingv1beta1firewall.RulesSet{FilterRules: append(ForgePreambleRules(gatewayOpts, hook), rules...)},
+			})
+		}
+	}
+
+	return chains, nil
+}
+
+// ResolveGatewayOptions merges the operator-wide gateway options with the overrides
+// specified in a PeeringConnectivity resource. Fields omitted in the overrides keep
+// the operator value.
+func ResolveGatewayOptions(opts *options.Options, settings *connectivityv1.GatewaySettings) options.GatewayOptions {
+	resolved := opts.Gateway
+	if settings == nil {
+		return resolved
+	}
+
+	if settings.BypassNonTunnelTraffic != nil {
+		resolved.BypassNonTunnelTraffic = *settings.BypassNonTunnelTraffic
+	}
+	if settings.BypassUplinkTraffic != nil {
+		resolved.BypassUplinkTraffic = *settings.BypassUplinkTraffic
+	}
+	if len(settings.UplinkInterfaces) > 0 {
+		resolved.UplinkInterfaces = make([]string, len(settings.UplinkInterfaces))
+		for i, iface := range settings.UplinkInterfaces {
+			resolved.UplinkInterfaces[i] = string(iface)
+		}
+	}
+
+	if conntrack := settings.Conntrack; conntrack != nil {
+		if conntrack.Mode != "" {
+			resolved.Stateless = conntrack.Mode == connectivityv1.ConntrackModeStateless
+		}
+		if conntrack.Related != nil {
+			resolved.AllowRelated = *conntrack.Related
+		}
+		if conntrack.DropInvalid != nil {
+			resolved.DropInvalid = *conntrack.DropInvalid
+		}
+		if conntrack.ExistingConnections != "" {
+			resolved.ReevaluateEstablished = conntrack.ExistingConnections == connectivityv1.ExistingConnectionsReevaluate
+		}
+	}
+
+	return resolved
+}
+
+// ForgePreambleRules creates the rules evaluated before the connectivity rules of the gateway chain attached
+// to the given hook:
+// - Drop the traffic not belonging to any valid connection, if enabled
+// - Allow established (and related) connections, unless stateless or re-evaluated after the connectivity rules
+// - Accept the traffic not received from the tunnel interface (not sent to it at the output hook), if enabled
+// - Accept the traffic leaving through each uplink interface, if enabled and the hook sees the output interface
+func ForgePreambleRules(opts options.GatewayOptions, hook string) []networkingv1beta1firewall.FilterRule {
+	var rules []networkingv1beta1firewall.FilterRule
+
+	if opts.DropInvalid {
+		// Drop the packets conntrack cannot attribute to any connection (e.g., out-of-window TCP segments).
+		rules = append(rules, networkingv1beta1firewall.FilterRule{
+			Name:    ptr.To("drop-invalid"),
+			Action:  networkingv1beta1firewall.ActionDrop,
+			Counter: true,
+			Match: []networkingv1beta1firewall.Match{{
+				CtState: &networkingv1beta1firewall.MatchCtState{
+					Value: []networkingv1beta1firewall.CtStateValue{networkingv1beta1firewall.CtStateInvalid},
+				},
+				Op: networkingv1beta1firewall.MatchOperationEq,
+			}},
+		})
+	}
+
+	if !opts.Stateless && !opts.ReevaluateEstablished {
+		// Allow the responses to the allowed connections before evaluating any other rule.
+		rules = append(rules, ForgeEstablishedRule(opts))
+	}
+
+	if opts.BypassNonTunnelTraffic {
+		// Consider only traffic originating from the tunnel interface, or, for the traffic generated by
+		// the gateway itself, directed to it.
+		position := networkingv1beta1firewall.MatchDevPositionIn
+		if !hookMatchesInputInterface(hook) {
+			position = networkingv1beta1firewall.MatchDevPositionOut
+		}
+		rules = append(rules, networkingv1beta1firewall.FilterRule{
+			Name:    ptr.To("match-tunnel-interface"),
+			Action:  networkingv1beta1firewall.ActionAccept,
+			Counter: true,
+			Match: []networkingv1beta1firewall.Match{{
+				Dev: &networkingv1beta1firewall.MatchDev{
+					Position: position,
+					Value:    opts.TunnelInterface,
+				},
+				Op: networkingv1beta1firewall.MatchOperationNeq,
+			}},
+		})
+	}
+
+	if opts.BypassUplinkTraffic && hookMatchesOutputInterface(hook) {
+		// Always allow traffic towards the local cluster.
+		for _, iface := range opts.UplinkInterfaces {
+			rules = append(rules, networkingv1beta1firewall.FilterRule{
+				Name:    ptr.To(fmt.Sprintf("allow-%s", iface)),
+				Action:  networkingv1beta1firewall.ActionAccept,
+				Counter: true,
+				Match: []networkingv1beta1firewall.Match{{
+					Dev: &networkingv1beta1firewall.MatchDev{
+						Position: networkingv1beta1firewall.MatchDevPositionOut,
+						Value:    iface,
+					},
+					Op: networkingv1beta1firewall.MatchOperationEq,
+				}},
+			})
+		}
+	}
+
+	return rules
+}
+
+// hookMatchesInputInterface returns whether the packets seen at the given hook have an input interface,
+// i.e. they are not generated by the gateway itself.
+func hookMatchesInputInterface(hook string) bool {
+	return networkingv1beta1firewall.ChainHook(hook) != networkingv1beta1firewall.ChainHookOutput
+}
+
+// hookMatchesOutputInterface returns whether the packets seen at the given hook have an output interface,
+// i.e. they are seen after the routing decision.
+func hookMatchesOutputInterface(hook string) bool {
+	switch networkingv1beta1firewall.ChainHook(hook) {
+	case networkingv1beta1firewall.ChainHookPrerouting, networkingv1beta1firewall.ChainHookInput:
+		return false
+	default:
+		return true
+	}
+}
+
+// ForgeEstablishedRule creates the rule accepting the traffic of the established connections,
+// and of the related ones if enabled.
+func ForgeEstablishedRule(opts options.GatewayOptions) networkingv1beta1firewall.FilterRule {
+	name := "allow-established"
+	states := []networkingv1beta1firewall.CtStateValue{networkingv1beta1firewall.CtStateEstablished}
+	if opts.AllowRelated {
+		name = "allow-established-related"
+		states = append(states, networkingv1beta1firewall.CtStateRelated)
+	}
+
+	return networkingv1beta1firewall.FilterRule{
+		Name:    ptr.To(name),
+		Action:  networkingv1beta1firewall.ActionAccept,
+		Counter: true,
+		Match: []networkingv1beta1firewall.Match{{
+			CtState: &networkingv1beta1firewall.MatchCtState{Value: states},
+			Op:      networkingv1beta1firewall.MatchOperationEq,
+		}},
+	}
 }
 
 // ForgeMatchRule creates firewall match rules for a party (source or destination).
-// It translates a high-level Party specification into low-level nftables match rules
-// and tracks which resource groups are used so their sets can be created.
+// It translates a high-level Party specification into low-level nftables match rules,
+// as a list of alternatives, and tracks which resource groups are used so their sets can be created.
 func ForgeMatchRule(
 	ctx context.Context,
 	cl client.Client,
+	opts *options.Options,
 	party *connectivityv1.Party,
 	clusterID string,
 	position networkingv1beta1firewall.MatchPosition,
 	usedResourceGroups map[connectivityv1.ResourceGroup]struct{},
 	usedNamespaces map[string]struct{},
-) (matchRules []networkingv1beta1firewall.Match, err error) {
+	usedServices map[types.NamespacedName]struct{},
+	usedFQDNs map[string]struct{},
+) (matchRules [][]networkingv1beta1firewall.Match, err error) {
 	if party == nil {
 		// No party specified, so no match rules needed (matches all).
 		return nil, nil
@@ -212,7 +571,7 @@ func ForgeMatchRule(
 
 	if party.Group != nil {
 		// Generate match rules for the specified resource group.
-		matchRules, err = resourcegroups.ResourceGroupFuncts[*party.Group].MakeFirewallConfigurationRule(ctx, cl, clusterID, position)
+		matchRules, err = resourcegroups.ResourceGroupFuncts[*party.Group].MakeFirewallConfigurationRule(ctx, cl, opts, clusterID, position)
 		if err != nil {
 			return nil, err
 		}
@@ -223,15 +582,51 @@ func ForgeMatchRule(
 		usedNamespaces[*party.Namespace] = struct{}{}
 
 		// Generate match rules for the specified namespace.
-		matchRules = []networkingv1beta1firewall.Match{{
+		matchRules = [][]networkingv1beta1firewall.Match{{{
+			IP: &networkingv1beta1firewall.MatchIP{
+				Value:    utils.ForgeSetReference(utils.ForgeNamespaceSetName(*party.Namespace)),
+				Position: position,
+			},
+			Op: networkingv1beta1firewall.MatchOperationEq,
+		}}}
+	} else if party.Service != nil {
+		// Mark this Service as used so its set can be created.
+		usedServices[types.NamespacedName{Namespace: party.Service.Namespace, Name: party.Service.Name}] = struct{}{}
+
+		// Generate match rules for the addresses of the specified Service.
+		ipMatch := []networkingv1beta1firewall.Match{{
 			IP: &networkingv1beta1firewall.MatchIP{
-				Value:    fmt.Sprintf("@ns-%s", *party.Namespace),
+				Value:    utils.ForgeSetReference(utils.ForgeServiceSetName(party.Service.Namespace, party.Service.Name)),
 				Position: position,
 			},
 			Op: networkingv1beta1firewall.MatchOperationEq,
 		}}
+
+		if len(party.Service.Ports) == 0 {
+			matchRules = [][]networkingv1beta1firewall.Match{ipMatch}
+		} else {
+			// Restrict the match to the selected ports, both before and after the service translation.
+			endpoints, err := utils.GetServiceEndpoints(ctx, cl, party.Service.Namespace, party.Service.Name)
+			if err != nil {
+				return nil, err
+			}
+			matchRules = utils.ForgePortMatchAlternatives(ipMatch, endpoints.FilterPorts(party.Service.Ports).AllPorts(), position)
+		}
+	} else if party.FQDN != nil {
+		// Mark this domain name as used so its set can be created.
+		name := fqdn.Canonicalize(string(*party.FQDN))
+		usedFQDNs[name] = struct{}{}
+
+		// Generate match rules for the addresses the specified domain name resolves to.
+		matchRules = [][]networkingv1beta1firewall.Match{{{
+			IP: &networkingv1beta1firewall.MatchIP{
+				Value:    utils.ForgeSetReference(utils.ForgeFQDNSetName(name)),
+				Position: position,
+			},
+			Op: networkingv1beta1firewall.MatchOperationEq,
+		}}}
 	} else {
-		return nil, fmt.Errorf("party must specify either a resource group or a namespace")
+		return nil, fmt.Errorf("party must specify either a resource group, a namespace, a service or an FQDN")
 	}
 
 	return matchRules, nil
@@ -18,10 +18,12 @@ import (
 	"context"
 
 	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
-	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/fqdn"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
@@ -29,50 +31,72 @@ import (
 // ReconcileGatewayFirewallConfiguration ensures that the FirewallConfiguration
 // resource for the gateway connectivity rules exists and is up to date.
 // It creates or updates the resource as needed based on the provided
-// PeeringConnectivity configuration.
+// chain of PeeringConnectivity resources of the tenant namespace and operator options.
+// Along with the result of the operation, it returns the hash of the rendered spec.
 func ReconcileGatewayFirewallConfiguration(
 	ctx context.Context,
 	c client.Client,
 	scheme *runtime.Scheme,
-	cfg *connectivityv1.PeeringConnectivity,
+	opts *options.Options,
+	resolver *fqdn.Cache,
+	chain *utils.PeeringConnectivityChain,
 	clusterID string,
-) (controllerutil.OperationResult, error) {
+) (controllerutil.OperationResult, string, error) {
+	cfg := chain.Effective
 	gatewayFwcfg := networkingv1beta1.FirewallConfiguration{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      ForgeGatewayResourceName(clusterID),
-			Namespace: utils.GetClusterNamespace(clusterID),
+			Namespace: cfg.Namespace,
 		},
 	}
 
-	return controllerutil.CreateOrUpdate(ctx, c, &gatewayFwcfg, func() error {
+	var hash string
+	op, err := controllerutil.CreateOrUpdate(ctx, c, &gatewayFwcfg, func() error {
 		// Set labels that identify this FirewallConfiguration as a gateway-level
 		// connectivity configuration targeting all nodes.
 		gatewayFwcfg.SetLabels(ForgeGatewayLabels(clusterID))
 
 		// Generate the FirewallConfiguration spec based on the PeeringConnectivity rules.
-		spec, err := ForgeGatewaySpec(ctx, c, cfg, clusterID)
+		spec, setOrigins, err := ForgeGatewaySpec(ctx, c, opts, resolver, cfg, chain.Restrictions, clusterID)
 		if err != nil {
 			return err
 		}
-		gatewayFwcfg.Spec = *spec
+		if hash, err = utils.SetFirewallConfigurationSpec(&gatewayFwcfg, spec); err != nil {
+			return err
+		}
 
-		// Set owner reference so the FirewallConfiguration is deleted when the
-		// PeeringConnectivity is deleted.
-		return controllerutil.SetOwnerReference(cfg, &gatewayFwcfg, scheme)
+		// Record the policy construct each set represents, as set names are not human readable.
+		if err := setOrigins.Annotate(&gatewayFwcfg); err != nil {
+			return err
+		}
+
+		// Set the owner references so the FirewallConfiguration is deleted when all
+		// the PeeringConnectivity resources of the chain are deleted.
+		gatewayFwcfg.OwnerReferences = nil
+		for _, member := range chain.Members {
+			if err := controllerutil.SetOwnerReference(member, &gatewayFwcfg, scheme); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
+	if err != nil {
+		return op, "", err
+	}
+	return op, hash, nil
 }
 
 // EnsureGatewayFirewallConfigurationDeleted deletes the gateway-level FirewallConfiguration
-// resource associated with the given cluster ID, if it exists.
+// resource associated with the given cluster ID from the given tenant namespace, if it exists.
 func EnsureGatewayFirewallConfigurationDeleted(
 	ctx context.Context,
 	c client.Client,
-	clusterID string,
+	namespace, clusterID string,
 ) error {
 	gatewayFwcfg := networkingv1beta1.FirewallConfiguration{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      ForgeGatewayResourceName(clusterID),
-			Namespace: utils.GetClusterNamespace(clusterID),
+			Namespace: namespace,
 		},
 	}
 
@@ -82,3 +106,21 @@ func EnsureGatewayFirewallConfigurationDeleted(
 	}
 	return nil
 }
+
+// EnsureLegacyGatewayFirewallConfigurationDeleted deletes the gateway-level FirewallConfiguration
+// associated with the given cluster ID in the given tenant namespace if it still uses the legacy
+// shared table name, so that the shared table is removed from the nodes before the per-peering
+// one is created.
+// It returns true while the deletion is still in progress.
+func EnsureLegacyGatewayFirewallConfigurationDeleted(
+	ctx context.Context,
+	c client.Client,
+	namespace, clusterID string,
+) (bool, error) {
+	key := types.NamespacedName{
+		Name:      ForgeGatewayResourceName(clusterID),
+		Namespace: namespace,
+	}
+
+	return utils.EnsureLegacyFirewallConfigurationDeleted(ctx, c, key, LegacyGatewayTableName)
+}
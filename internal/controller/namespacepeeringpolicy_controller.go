@@ -0,0 +1,167 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+)
+
+// NamespacePeeringPolicyReconciler reports in the status of the NamespacePeeringPolicy resources the
+// peerings they are enforced on. The policies are rendered into the FirewallConfiguration and the
+// NetworkPolicies of each peering by the PeeringConnectivityReconciler.
+type NamespacePeeringPolicyReconciler struct {
+	client.Client
+}
+
+// +kubebuilder:rbac:groups=connectivity.liqo.io,resources=namespacepeeringpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=connectivity.liqo.io,resources=namespacepeeringpolicies/status,verbs=get;update;patch
+
+// NewNamespacePeeringPolicyReconciler creates a new NamespacePeeringPolicyReconciler.
+func NewNamespacePeeringPolicyReconciler(mgr ctrl.Manager) *NamespacePeeringPolicyReconciler {
+	return &NamespacePeeringPolicyReconciler{Client: mgr.GetClient()}
+}
+
+// Reconcile updates the status of the NamespacePeeringPolicy with the peerings it is enforced on.
+func (r *NamespacePeeringPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	policy := &connectivityv1.NamespacePeeringPolicy{}
+	if err := r.Client.Get(ctx, req.NamespacedName, policy); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	original := policy.Status.DeepCopy()
+
+	if !utils.NamespacePolicyRestricts(&policy.Spec, connectivityv1.NamespacePolicyTypeIngress) &&
+		!utils.NamespacePolicyRestricts(&policy.Spec, connectivityv1.NamespacePolicyTypeEgress) {
+		policy.Status.Peerings = nil
+		meta.SetStatusCondition(&policy.Status.Conditions, metav1.Condition{
+			Type:    utils.ConditionTypeReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  connectivityv1.NamespacePolicyReasonNoRestriction,
+			Message: "The policy neither lists any party nor any policy type, hence it does not restrict any traffic",
+		})
+		return ctrl.Result{}, r.updateStatus(ctx, policy, original)
+	}
+
+	var foreignClusters liqov1beta1.ForeignClusterList
+	if err := r.Client.List(ctx, &foreignClusters); err != nil {
+		return ctrl.Result{}, fmt.Errorf("unable to list the ForeignCluster resources: %w", err)
+	}
+
+	var peerings []string
+	for i := range foreignClusters.Items {
+		foreignCluster := &foreignClusters.Items[i]
+		clusterID := string(foreignCluster.Spec.ClusterID)
+		if !utils.IsPeeringEstablished(foreignCluster) || !utils.NamespacePolicyAppliesTo(policy, clusterID) {
+			continue
+		}
+
+		// The policy is enforced only along with the PeeringConnectivity resources of the peering.
+		chain, err := utils.GetPeeringConnectivityChain(ctx, r.Client, foreignCluster.Status.TenantNamespace.Local, nil)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if chain != nil {
+			peerings = append(peerings, clusterID)
+		}
+	}
+	slices.SortFunc(peerings, strings.Compare)
+
+	policy.Status.Peerings = peerings
+	if len(peerings) == 0 {
+		meta.SetStatusCondition(&policy.Status.Conditions, metav1.Condition{
+			Type:    utils.ConditionTypeReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  connectivityv1.NamespacePolicyReasonNoPeering,
+			Message: "No established peering with a PeeringConnectivity matches the policy",
+		})
+	} else {
+		meta.SetStatusCondition(&policy.Status.Conditions, metav1.Condition{
+			Type:    utils.ConditionTypeReady,
+			Status:  metav1.ConditionTrue,
+			Reason:  connectivityv1.NamespacePolicyReasonEnforced,
+			Message: fmt.Sprintf("The policy is enforced on %d peerings", len(peerings)),
+		})
+	}
+	return ctrl.Result{}, r.updateStatus(ctx, policy, original)
+}
+
+// updateStatus updates the status of the policy, if changed.
+func (r *NamespacePeeringPolicyReconciler) updateStatus(
+	ctx context.Context, policy *connectivityv1.NamespacePeeringPolicy, original *connectivityv1.NamespacePeeringPolicyStatus,
+) error {
+	policy.Status.ObservedGeneration = policy.Generation
+	if equality.Semantic.DeepEqual(&policy.Status, original) {
+		return nil
+	}
+
+	if err := r.Status().Update(ctx, policy); err != nil {
+		log.FromContext(ctx).Error(err, "failed to update status")
+		return err
+	}
+	return nil
+}
+
+// allPolicyEnqueuer enqueues all the NamespacePeeringPolicy resources, as any of them may apply
+// to the peering the changed object refers to.
+func (r *NamespacePeeringPolicyReconciler) allPolicyEnqueuer(ctx context.Context, _ client.Object) []ctrl.Request {
+	var policies connectivityv1.NamespacePeeringPolicyList
+	if err := r.Client.List(ctx, &policies); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list the NamespacePeeringPolicy resources")
+		return nil
+	}
+
+	requests := make([]ctrl.Request, len(policies.Items))
+	for i := range policies.Items {
+		requests[i] = ctrl.Request{NamespacedName: types.NamespacedName{
+			Name: policies.Items[i].Name, Namespace: policies.Items[i].Namespace,
+		}}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+// It watches the ForeignCluster resources and the creation and deletion of the PeeringConnectivity
+// resources, since they determine the peerings the policies are enforced on.
+func (r *NamespacePeeringPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&connectivityv1.NamespacePeeringPolicy{}).
+		Watches(&liqov1beta1.ForeignCluster{}, handler.EnqueueRequestsFromMapFunc(r.allPolicyEnqueuer)).
+		Watches(&connectivityv1.PeeringConnectivity{}, handler.EnqueueRequestsFromMapFunc(r.allPolicyEnqueuer),
+			builder.WithPredicates(predicate.Funcs{
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					return e.ObjectOld.GetDeletionTimestamp().IsZero() != e.ObjectNew.GetDeletionTimestamp().IsZero()
+				},
+			})).
+		Named("namespacepeeringpolicy").
+		Complete(r)
+}
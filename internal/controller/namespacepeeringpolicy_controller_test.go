@@ -0,0 +1,123 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+)
+
+var _ = Describe("NamespacePeeringPolicy Controller", func() {
+	Context("When reporting the peerings a policy is enforced on", func() {
+		const (
+			clusterID    = "policy-cluster"
+			appNamespace = "policy-app"
+		)
+
+		var (
+			ctx        context.Context
+			namespace  string
+			reconciler *NamespacePeeringPolicyReconciler
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			namespace = "liqo-tenant-" + clusterID
+			reconciler = &NamespacePeeringPolicyReconciler{Client: k8sClient}
+
+			for _, name := range []string{namespace, appNamespace} {
+				ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+				if err := k8sClient.Create(ctx, ns); err != nil && !errors.IsAlreadyExists(err) {
+					Expect(err).NotTo(HaveOccurred())
+				}
+			}
+
+			foreignCluster := &liqov1beta1.ForeignCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: clusterID},
+				Spec:       liqov1beta1.ForeignClusterSpec{ClusterID: clusterID},
+			}
+			Expect(k8sClient.Create(ctx, foreignCluster)).To(Succeed())
+			foreignCluster.Status.Modules.Networking.Enabled = true
+			foreignCluster.Status.TenantNamespace.Local = namespace
+			Expect(k8sClient.Status().Update(ctx, foreignCluster)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, &liqov1beta1.ForeignCluster{ObjectMeta: metav1.ObjectMeta{Name: clusterID}})).To(Succeed())
+			Expect(k8sClient.DeleteAllOf(ctx, &connectivityv1.PeeringConnectivity{}, client.InNamespace(namespace))).To(Succeed())
+			Expect(k8sClient.DeleteAllOf(ctx, &connectivityv1.NamespacePeeringPolicy{}, client.InNamespace(appNamespace))).To(Succeed())
+		})
+
+		reconcilePolicy := func(spec connectivityv1.NamespacePeeringPolicySpec) *connectivityv1.NamespacePeeringPolicy {
+			policy := &connectivityv1.NamespacePeeringPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "restrict", Namespace: appNamespace},
+				Spec:       spec,
+			}
+			Expect(k8sClient.Create(ctx, policy)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(policy)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(policy), policy)).To(Succeed())
+			return policy
+		}
+
+		It("should report the policies not restricting any direction", func() {
+			policy := reconcilePolicy(connectivityv1.NamespacePeeringPolicySpec{})
+
+			condition := meta.FindStatusCondition(policy.Status.Conditions, utils.ConditionTypeReady)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(connectivityv1.NamespacePolicyReasonNoRestriction))
+		})
+
+		It("should report no peering while the tenant namespace has no PeeringConnectivity", func() {
+			policy := reconcilePolicy(connectivityv1.NamespacePeeringPolicySpec{
+				Ingress: []connectivityv1.Party{{Group: ptr.To(connectivityv1.ResourceGroupRemoteCluster)}},
+			})
+
+			Expect(policy.Status.Peerings).To(BeEmpty())
+			Expect(meta.FindStatusCondition(policy.Status.Conditions, utils.ConditionTypeReady).Reason).
+				To(Equal(connectivityv1.NamespacePolicyReasonNoPeering))
+		})
+
+		It("should report the peerings with a PeeringConnectivity the policy applies to", func() {
+			Expect(k8sClient.Create(ctx, &connectivityv1.PeeringConnectivity{
+				ObjectMeta: metav1.ObjectMeta{Name: clusterID, Namespace: namespace},
+			})).To(Succeed())
+
+			policy := reconcilePolicy(connectivityv1.NamespacePeeringPolicySpec{
+				ClusterIDs:  []string{clusterID},
+				PolicyTypes: []connectivityv1.NamespacePolicyType{connectivityv1.NamespacePolicyTypeEgress},
+			})
+
+			Expect(policy.Status.Peerings).To(Equal([]string{clusterID}))
+			Expect(policy.Status.ObservedGeneration).To(Equal(policy.Generation))
+			Expect(meta.IsStatusConditionTrue(policy.Status.Conditions, utils.ConditionTypeReady)).To(BeTrue())
+		})
+	})
+})
@@ -16,20 +16,38 @@ package networkpolicy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/fqdn"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/resourcegroups"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// errNoMatchingPeers is returned when a party does not match any traffic, since an empty
+// list of NetworkPolicy peers or ports would match all the traffic instead.
+var errNoMatchingPeers = errors.New("the party does not match any peer")
+
+// ForgeProviderNetworkPolicySpec creates the spec of the NetworkPolicy selecting the pods offloaded by the given
+// cluster in the given namespace. Each flow of a rule whose initiator includes the offloaded pods becomes an
+// egress rule towards its responder, and each flow whose responder includes them an ingress rule from its
+// initiator, so that the NetworkPolicy allows the same connections of the offloaded pods as the gateway firewall.
+// NetworkPolicies can only allow traffic, hence the traffic of the deny rules is excluded from the rules following
+// them instead. It returns utils.ErrNotExpressible if the excluded traffic cannot be expressed by a NetworkPolicy.
 func ForgeProviderNetworkPolicySpec(
 	ctx context.Context,
 	cl client.Client,
+	opts *options.Options,
+	resolver *fqdn.Cache,
 	cfg *connectivityv1.PeeringConnectivity,
 	clusterID string,
+	namespace string,
 ) (*networkingv1.NetworkPolicySpec, error) {
 	spec := networkingv1.NetworkPolicySpec{
 		Ingress:     []networkingv1.NetworkPolicyIngressRule{},
@@ -37,31 +55,201 @@ func ForgeProviderNetworkPolicySpec(
 		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
 	}
 
+	// The traffic of the deny rules preceding each rule, which the gateway firewall drops.
+	var deniedEgress, deniedIngress []policyPeers
+
 	// Add rules based on the PeeringConnectivity configuration.
-	for _, rule := range cfg.Spec.Rules {
-		if rule.Source != nil && rule.Source.Group != nil && *rule.Source.Group == connectivityv1.ResourceGroupOffloaded {
-			to, toPorts, err := ForgeNetworkPolicyPeer(ctx, cl, clusterID, rule.Destination)
-			if err != nil {
-				return nil, fmt.Errorf("failed to forge network policy peer for rule destination: %w", err)
+	for i := range cfg.Spec.Rules {
+		allow := cfg.Spec.Rules[i].Action == connectivityv1.ActionAllow
+
+		for _, flow := range utils.ForgeRuleFlows(&cfg.Spec.Rules[i]) {
+			if includesOffloadedPods(flow.Initiator) {
+				to, toPorts, err := ForgeNetworkPolicyPeer(ctx, cl, opts, resolver, clusterID, flow.Responder)
+				switch {
+				case errors.Is(err, errNoMatchingPeers):
+					// The rule cannot match any traffic, hence it is skipped.
+				case err != nil:
+					return nil, fmt.Errorf("failed to forge network policy peer for rule responder: %w", err)
+				case !allow:
+					deniedEgress = append(deniedEgress, policyPeers{Peers: to, Ports: toPorts})
+				default:
+					allowed, err := subtractDeniedPeers(ctx, cl, policyPeers{Peers: to, Ports: toPorts}, deniedEgress, namespace)
+					if err != nil {
+						return nil, fmt.Errorf("failed to exclude the denied traffic from the egress of rule %d: %w", i, err)
+					}
+					for _, peer := range allowed {
+						spec.Egress = append(spec.Egress, networkingv1.NetworkPolicyEgressRule{To: peer.Peers, Ports: peer.Ports})
+					}
+				}
 			}
-			spec.Egress = append(spec.Egress, networkingv1.NetworkPolicyEgressRule{To: to, Ports: toPorts})
-		}
 
-		if rule.Destination != nil && rule.Destination.Group != nil && *rule.Destination.Group == connectivityv1.ResourceGroupOffloaded {
-			from, fromPorts, err := ForgeNetworkPolicyPeer(ctx, cl, clusterID, rule.Source)
-			if err != nil {
-				return nil, fmt.Errorf("failed to forge network policy peer for rule source: %w", err)
+			if includesOffloadedPods(flow.Responder) {
+				from, fromPorts, err := ForgeNetworkPolicyPeer(ctx, cl, opts, resolver, clusterID, flow.Initiator)
+				switch {
+				case errors.Is(err, errNoMatchingPeers):
+					// The rule cannot match any traffic, hence it is skipped.
+				case err != nil:
+					return nil, fmt.Errorf("failed to forge network policy peer for rule initiator: %w", err)
+				case !allow:
+					deniedIngress = append(deniedIngress, policyPeers{Peers: from, Ports: fromPorts})
+				default:
+					allowed, err := subtractDeniedPeers(ctx, cl, policyPeers{Peers: from, Ports: fromPorts}, deniedIngress, namespace)
+					if err != nil {
+						return nil, fmt.Errorf("failed to exclude the denied traffic from the ingress of rule %d: %w", i, err)
+					}
+					for _, peer := range allowed {
+						spec.Ingress = append(spec.Ingress, networkingv1.NetworkPolicyIngressRule{From: peer.Peers, Ports: peer.Ports})
+					}
+				}
 			}
-			spec.Ingress = append(spec.Ingress, networkingv1.NetworkPolicyIngressRule{From: from, Ports: fromPorts})
 		}
 	}
 
 	return &spec, nil
 }
 
-func ForgeNetworkPolicyPeer(ctx context.Context, cl client.Client, clusterID string, peer *connectivityv1.Party) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
+// subtractDeniedPeers returns the NetworkPolicy peers and ports matching the traffic matched by the allowed ones,
+// but not by any of the denied ones, of a NetworkPolicy in the given namespace. The peers are narrowed for the
+// denied ports, while the other ports keep them unchanged. It returns utils.ErrNotExpressible if only some of
+// the allowed ports are denied to the same peers, e.g. any port but a denied one.
+func subtractDeniedPeers(
+	ctx context.Context,
+	cl client.Client,
+	allowed policyPeers,
+	denied []policyPeers,
+	namespace string,
+) ([]policyPeers, error) {
+	remaining := []policyPeers{allowed}
+	for i := range denied {
+		var narrowed []policyPeers
+		for _, rule := range remaining {
+			covered, uncovered, ok := utils.SplitNetworkPolicyPorts(rule.Ports, denied[i].Ports)
+			if ok && len(rule.Ports) > 0 && len(covered) == 0 {
+				// The denied ports are disjoint from the allowed ones.
+				narrowed = append(narrowed, rule)
+				continue
+			}
+
+			peers, matching, err := utils.SubtractNetworkPolicyPeers(ctx, cl, rule.Peers, denied[i].Peers, namespace)
+			switch {
+			case err != nil:
+				return nil, err
+			case matching && equality.Semantic.DeepEqual(peers, rule.Peers):
+				// The denied peers are disjoint from the allowed ones.
+				narrowed = append(narrowed, rule)
+				continue
+			case !ok:
+				return nil, fmt.Errorf("%w: only some of the allowed ports are denied", utils.ErrNotExpressible)
+			}
+
+			if len(uncovered) > 0 {
+				narrowed = append(narrowed, policyPeers{Peers: rule.Peers, Ports: uncovered})
+			}
+			if matching {
+				narrowed = append(narrowed, policyPeers{Peers: peers, Ports: covered})
+			}
+		}
+		remaining = narrowed
+	}
+	return remaining, nil
+}
+
+// includesOffloadedPods returns whether the given party includes the pods offloaded by the peered cluster,
+// i.e. it is the offloaded resource group or it is omitted, matching any endpoint.
+func includesOffloadedPods(party *connectivityv1.Party) bool {
+	return party == nil || (party.Group != nil && *party.Group == connectivityv1.ResourceGroupOffloaded)
+}
+
+// RestrictNetworkPolicySpec restricts the rules of the NetworkPolicy generated in the given namespace to the
+// traffic allowed by the restriction of the namespace. Since NetworkPolicies are additive, the restriction
+// cannot be enforced by a separate NetworkPolicy: each rule is replaced by its intersection with each of
+// the allowed parties, so that the traffic not allowed by the PeeringConnectivity is never allowed.
+func RestrictNetworkPolicySpec(
+	ctx context.Context,
+	cl client.Client,
+	opts *options.Options,
+	resolver *fqdn.Cache,
+	spec *networkingv1.NetworkPolicySpec,
+	restriction *utils.NamespaceRestriction,
+	clusterID string,
+) error {
+	if restriction.RestrictIngress {
+		allowed, err := forgeAllowedPeers(ctx, cl, opts, resolver, clusterID, restriction.Ingress)
+		if err != nil {
+			return fmt.Errorf("failed to forge network policy peers for the ingress restriction: %w", err)
+		}
+
+		var ingress []networkingv1.NetworkPolicyIngressRule
+		for _, rule := range spec.Ingress {
+			for _, peer := range allowed {
+				from, okPeers := utils.IntersectNetworkPolicyPeers(rule.From, peer.Peers, restriction.Namespace)
+				ports, okPorts := utils.IntersectNetworkPolicyPorts(rule.Ports, peer.Ports)
+				if okPeers && okPorts {
+					ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{From: from, Ports: ports})
+				}
+			}
+		}
+		spec.Ingress = append([]networkingv1.NetworkPolicyIngressRule{}, ingress...)
+	}
+
+	if restriction.RestrictEgress {
+		allowed, err := forgeAllowedPeers(ctx, cl, opts, resolver, clusterID, restriction.Egress)
+		if err != nil {
+			return fmt.Errorf("failed to forge network policy peers for the egress restriction: %w", err)
+		}
+
+		var egress []networkingv1.NetworkPolicyEgressRule
+		for _, rule := range spec.Egress {
+			for _, peer := range allowed {
+				to, okPeers := utils.IntersectNetworkPolicyPeers(rule.To, peer.Peers, restriction.Namespace)
+				ports, okPorts := utils.IntersectNetworkPolicyPorts(rule.Ports, peer.Ports)
+				if okPeers && okPorts {
+					egress = append(egress, networkingv1.NetworkPolicyEgressRule{To: to, Ports: ports})
+				}
+			}
+		}
+		spec.Egress = append([]networkingv1.NetworkPolicyEgressRule{}, egress...)
+	}
+
+	return nil
+}
+
+// policyPeers are the NetworkPolicy peers and ports matching a party, e.g. one allowed by a namespace restriction.
+type policyPeers struct {
+	Peers []networkingv1.NetworkPolicyPeer
+	Ports []networkingv1.NetworkPolicyPort
+}
+
+// forgeAllowedPeers creates the NetworkPolicy peers matching each of the given parties,
+// skipping the ones not matching any traffic.
+func forgeAllowedPeers(
+	ctx context.Context,
+	cl client.Client,
+	opts *options.Options,
+	resolver *fqdn.Cache,
+	clusterID string,
+	parties []connectivityv1.Party,
+) ([]policyPeers, error) {
+	var allowed []policyPeers
+	for i := range parties {
+		peers, ports, err := ForgeNetworkPolicyPeer(ctx, cl, opts, resolver, clusterID, &parties[i])
+		switch {
+		case errors.Is(err, errNoMatchingPeers):
+			// The party cannot match any traffic, hence it is skipped.
+		case err != nil:
+			return nil, err
+		default:
+			allowed = append(allowed, policyPeers{Peers: peers, Ports: ports})
+		}
+	}
+	return allowed, nil
+}
+
+// ForgeNetworkPolicyPeer creates the NetworkPolicy peers and ports matching the given party. A nil party
+// matches any endpoint, hence it returns no peers and no ports, which NetworkPolicies interpret as any.
+func ForgeNetworkPolicyPeer(ctx context.Context, cl client.Client, opts *options.Options, resolver *fqdn.Cache, clusterID string, peer *connectivityv1.Party) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
 	if peer == nil {
-		return nil, nil, fmt.Errorf("party is nil")
+		return nil, nil, nil
 	}
 
 	if peer.Namespace != nil {
@@ -75,7 +263,52 @@ func ForgeNetworkPolicyPeer(ctx context.Context, cl client.Client, clusterID str
 	}
 
 	if peer.Group != nil {
-		return resourcegroups.ResourceGroupFuncts[*peer.Group].MakeNetworkPolicyRule(ctx, cl, clusterID)
+		// Groups without peers, like any-dns, match any peer on their ports.
+		peers, ports, err := resourcegroups.ResourceGroupFuncts[*peer.Group].MakeNetworkPolicyRule(ctx, cl, opts, clusterID)
+		if errors.Is(err, resourcegroups.ErrNoMatchingPeers) {
+			return nil, nil, errNoMatchingPeers
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return peers, ports, nil
+	}
+
+	if peer.Service != nil {
+		endpoints, err := utils.GetServiceEndpoints(ctx, cl, peer.Service.Namespace, peer.Service.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		endpoints = endpoints.FilterPorts(peer.Service.Ports)
+
+		// NetworkPolicies are enforced after the service translation, hence only the target ports are relevant.
+		// Without selected ports, the traffic on any port is allowed.
+		var ports []networkingv1.NetworkPolicyPort
+		if len(peer.Service.Ports) > 0 {
+			if len(endpoints.TargetPorts) == 0 {
+				return nil, nil, errNoMatchingPeers
+			}
+			ports = utils.ForgeNetworkPolicyPorts(endpoints.TargetPorts)
+		}
+
+		peers := utils.ForgeServiceNetworkPolicyPeers(endpoints)
+		if len(peers) == 0 {
+			return nil, nil, errNoMatchingPeers
+		}
+		return peers, ports, nil
+	}
+
+	if peer.FQDN != nil {
+		addresses, err := resolver.Lookup(ctx, string(*peer.FQDN))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		peers := utils.ForgeAddressNetworkPolicyPeers(addresses)
+		if len(peers) == 0 {
+			return nil, nil, errNoMatchingPeers
+		}
+		return peers, nil, nil
 	}
 
 	return nil, nil, fmt.Errorf("unsupported party configuration: %+v", peer)
@@ -20,6 +20,8 @@ import (
 	"github.com/liqotech/liqo/pkg/consts"
 	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/fqdn"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -31,11 +33,16 @@ const (
 	networkPolicyName = "liqo-connectivity-network-policy"
 )
 
+// ReconcileNetworkPolicies ensures that the NetworkPolicy exists in each of the namespaces offloaded
+// by the given cluster, forged from the chain of PeeringConnectivity resources of its tenant namespace
+// and restricted by the NamespacePeeringPolicy resources of the namespace, if any.
 func ReconcileNetworkPolicies(
 	ctx context.Context,
 	c client.Client,
 	scheme *runtime.Scheme,
-	cfg *connectivityv1.PeeringConnectivity,
+	opts *options.Options,
+	resolver *fqdn.Cache,
+	chain *utils.PeeringConnectivityChain,
 	clusterID string,
 ) error {
 	namespaces, err := utils.GetOffloadedNamespaces(ctx, c, clusterID)
@@ -44,7 +51,14 @@ func ReconcileNetworkPolicies(
 	}
 
 	for _, ns := range namespaces {
-		if _, err := reconcileNetworkPolicyInNamespace(ctx, c, scheme, cfg, clusterID, ns.Name); err != nil {
+		var restriction *utils.NamespaceRestriction
+		for i := range chain.Restrictions {
+			if chain.Restrictions[i].Namespace == ns.Name {
+				restriction = &chain.Restrictions[i]
+			}
+		}
+
+		if _, err := reconcileNetworkPolicyInNamespace(ctx, c, scheme, opts, resolver, chain.Effective, restriction, clusterID, ns.Name); err != nil {
 			return err
 		}
 	}
@@ -53,12 +67,16 @@ func ReconcileNetworkPolicies(
 }
 
 // reconcileNetworkPolicyInNamespace ensures that the NetworkPolicy exists in the given namespace
-// with the correct specification based on the PeeringConnectivity configuration.
+// with the correct specification based on the PeeringConnectivity configuration and on the
+// restriction of the namespace, if not nil.
 func reconcileNetworkPolicyInNamespace(
 	ctx context.Context,
 	c client.Client,
 	scheme *runtime.Scheme,
+	opts *options.Options,
+	resolver *fqdn.Cache,
 	cfg *connectivityv1.PeeringConnectivity,
+	restriction *utils.NamespaceRestriction,
 	clusterID string,
 	namespaceName string,
 ) (controllerutil.OperationResult, error) {
@@ -76,10 +94,17 @@ func reconcileNetworkPolicyInNamespace(
 		})
 
 		// Generate the NetworkPolicy spec based on the PeeringConnectivity rules.
-		spec, err := ForgeProviderNetworkPolicySpec(ctx, c, cfg, clusterID)
+		spec, err := ForgeProviderNetworkPolicySpec(ctx, c, opts, resolver, cfg, clusterID, namespaceName)
 		if err != nil {
 			return err
 		}
+
+		// Restrict the rules to the traffic allowed by the owners of the namespace.
+		if restriction != nil {
+			if err := RestrictNetworkPolicySpec(ctx, c, opts, resolver, spec, restriction, clusterID); err != nil {
+				return err
+			}
+		}
 		networkPolicy.Spec = *spec
 
 		return nil
@@ -125,3 +150,28 @@ func deleteNetworkPolicyInNamespace(
 	}
 	return nil
 }
+
+// EnsureStrayNetworkPoliciesDeleted deletes the NetworkPolicy resources generated for the given
+// cluster ID from all the namespaces, including the ones no longer labeled as offloaded by the
+// cluster, e.g. because the peering has already been torn down.
+func EnsureStrayNetworkPoliciesDeleted(
+	ctx context.Context,
+	c client.Client,
+	clusterID string,
+) error {
+	var networkPolicies networkingv1.NetworkPolicyList
+	if err := c.List(ctx, &networkPolicies, client.MatchingLabels{consts.RemoteClusterID: clusterID}); err != nil {
+		return err
+	}
+
+	for i := range networkPolicies.Items {
+		if networkPolicies.Items[i].Name != networkPolicyName {
+			continue
+		}
+		if err := c.Delete(ctx, &networkPolicies.Items[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -17,17 +17,28 @@ package controller
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	authv1beta1 "github.com/liqotech/liqo/apis/authentication/v1beta1"
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
 	ipamv1alpha1 "github.com/liqotech/liqo/apis/ipam/v1alpha1"
 	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
 	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
 	"github.com/liqotech/liqo/pkg/consts"
 	vkforge "github.com/liqotech/liqo/pkg/virtualKubelet/forge"
 	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/fabric"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/gateway"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/networkpolicy"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/counters"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/fqdn"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -36,10 +47,14 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 // PeeringConnectivityReconciler reconciles a PeeringConnectivity object.
@@ -49,10 +64,19 @@ type PeeringConnectivityReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+	Options  *options.Options
+	// FQDNResolver resolves the domain names of the FQDN parties, caching them according to their TTL.
+	FQDNResolver *fqdn.Cache
+	// CounterReader reads back the counters of the rules of the gateway. If nil, they are not read.
+	CounterReader counters.Reader
+	// CounterMetrics exposes the counters read by the CounterReader to Prometheus.
+	CounterMetrics *counters.Metrics
+	// ServiceReferences tracks the Services referenced by the chains, to enqueue them when the Services change.
+	ServiceReferences *utils.ServiceReferences
 }
 
 const (
-	// ConditionReasonClusterIDError indicates that the cluster ID could not be extracted from the namespace.
+	// ConditionReasonClusterIDError indicates that the cluster ID could not be resolved from the namespace.
 	ConditionReasonClusterIDError = "ClusterIDExtractionFailed"
 
 	// ConditionReasonGatewaySyncFailed indicates that the FirewallConfiguration failed to sync.
@@ -60,36 +84,102 @@ const (
 	// ConditionReasonNetworkPolicySyncFailed indicates that the NetworkPolicy failed to sync.
 	ConditionReasonNetworkPolicySyncFailed = "NetworkPolicySyncFailed"
 
+	// ConditionReasonMigrationFailed indicates that the legacy resources could not be removed.
+	ConditionReasonMigrationFailed = "MigrationFailed"
+
+	// ConditionReasonChainMergeFailed indicates that the PeeringConnectivity resources of the namespace could not be merged.
+	ConditionReasonChainMergeFailed = "ChainMergeFailed"
+
+	// ConditionReasonNamespaceRestrictionFailed indicates that the NamespacePeeringPolicy resources could not be merged.
+	ConditionReasonNamespaceRestrictionFailed = "NamespaceRestrictionFailed"
+
+	// ConditionReasonAccessGrantFailed indicates that the AccessGrant resources of the peering could not be retrieved.
+	ConditionReasonAccessGrantFailed = "AccessGrantFailed"
+
+	// ConditionReasonRevisionFailed indicates that the applied spec could not be recorded as a revision.
+	ConditionReasonRevisionFailed = "RevisionFailed"
+
 	// ConditionReasonSynced indicates that the resource has been successfully synced.
 	ConditionReasonSynced = "Synced"
 
 	// ConditionReasonDeletionFailed indicates that resource deletion failed during finalization.
 	ConditionReasonDeletionFailed = "DeletionFailed"
 
+	// ConditionReasonServiceLookupFailed indicates that the Services referenced by the chain could not be retrieved.
+	ConditionReasonServiceLookupFailed = "ServiceLookupFailed"
+
+	// ConditionTypeServicesFound indicates whether all the Services referenced by the chain exist.
+	ConditionTypeServicesFound = "ServicesFound"
+	// ConditionReasonServicesFound indicates that all the Services referenced by the chain exist.
+	ConditionReasonServicesFound = "ServicesFound"
+	// ConditionReasonServicesMissing indicates that some Services referenced by the chain do not exist.
+	ConditionReasonServicesMissing = "ServicesMissing"
+
 	// EventReasonReconcileError is emitted when a reconciliation error occurs.
 	EventReasonReconcileError = "ReconcileError"
 	// EventReasonDeletionError is emitted when a deletion error occurs.
 	EventReasonDeletionError = "DeletionError"
 	// EventReasonSynced is emitted when the FirewallConfiguration is successfully synced.
 	EventReasonSynced = "Synced"
+	// EventReasonRuleActivated is emitted when a window of a scheduled rule opens.
+	EventReasonRuleActivated = "RuleActivated"
+	// EventReasonRuleDeactivated is emitted when the windows of a scheduled rule close.
+	EventReasonRuleDeactivated = "RuleDeactivated"
+	// EventReasonRolledBack is emitted when the spec is rolled back to a previous revision.
+	EventReasonRolledBack = "RolledBack"
+	// EventReasonRollbackFailed is emitted when a rollback request cannot be fulfilled.
+	EventReasonRollbackFailed = "RollbackFailed"
 
 	// FinalizerName is the name of the finalizer added to PeeringConnectivity resources.
 	FinalizerName = "peeringconnectivity-controller.connectivity.liqo.io/finalizer"
+
+	// legacyMigrationRequeueDelay is the delay before checking again whether the legacy
+	// FirewallConfigurations have been deleted.
+	legacyMigrationRequeueDelay = 5 * time.Second
+
+	// minFQDNRequeueDelay is the minimum delay before resolving again the domain names of the FQDN parties.
+	minFQDNRequeueDelay = time.Second
+
+	// minScheduleRequeueDelay is the minimum delay before evaluating again the schedules of the rules.
+	minScheduleRequeueDelay = time.Second
+
+	// minAccessGrantRequeueDelay is the minimum delay before removing the rules of the expired AccessGrants.
+	minAccessGrantRequeueDelay = time.Second
 )
 
 // +kubebuilder:rbac:groups=connectivity.liqo.io,resources=peeringconnectivities,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=connectivity.liqo.io,resources=peeringconnectivities/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=connectivity.liqo.io,resources=peeringconnectivities/finalizers,verbs=update
+// +kubebuilder:rbac:groups=connectivity.liqo.io,resources=namespacepeeringpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=connectivity.liqo.io,resources=accessgrants,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ipam.liqo.io,resources=ips;networks,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core.liqo.io,resources=foreignclusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=authentication.liqo.io,resources=tenants,verbs=get;list;watch
+// +kubebuilder:rbac:groups=offloading.liqo.io,resources=virtualnodes,verbs=get;list;watch
 
 // NewPeeringConnectivityReconciler creates a new PeeringConnectivityReconciler.
 // It initializes the reconciler with the necessary client, scheme, and event recorder
-// from the provided controller manager.
-func NewPeeringConnectivityReconciler(mgr ctrl.Manager) *PeeringConnectivityReconciler {
-	return &PeeringConnectivityReconciler{
+// from the provided controller manager, and with the operator options.
+func NewPeeringConnectivityReconciler(mgr ctrl.Manager, opts *options.Options) *PeeringConnectivityReconciler {
+	r := &PeeringConnectivityReconciler{
 		Client:   mgr.GetClient(),
 		Scheme:   mgr.GetScheme(),
 		Recorder: mgr.GetEventRecorderFor("peeringconnectivity-controller"),
+		Options:  opts,
+
+		FQDNResolver:      fqdn.NewCacheFromOptions(&opts.FQDN),
+		ServiceReferences: utils.NewServiceReferences(),
+	}
+
+	if opts.Counters.ReporterEndpoint != "" {
+		r.CounterReader = counters.NewHTTPReader(opts.Counters.ReporterEndpoint, opts.Counters.Timeout)
+		r.CounterMetrics = counters.NewMetrics()
 	}
+	return r
 }
 
 // Reconcile is part of the main kubernetes reconciliation loop.
@@ -132,42 +222,26 @@ func (r *PeeringConnectivityReconciler) Reconcile(ctx context.Context, req ctrl.
 		// The object is being deleted
 		if controllerutil.ContainsFinalizer(cfg, FinalizerName) {
 			// Our finalizer is present, so let's remove the associated resources
-			clusterID, err := utils.ExtractClusterIDFromNamespace(req.Namespace)
+			clusterID, err := utils.GetClusterIDFromNamespace(ctx, r.Client, req.Namespace)
 			if err != nil {
-				// Something went wrong extracting the cluster ID, log and return the error to retry
-				logger.Error(err, "unable to extract cluster ID during finalization")
+				// Something went wrong resolving the cluster ID, log and return the error to retry
+				logger.Error(err, "unable to resolve cluster ID during finalization")
 				r.Recorder.Eventf(cfg, corev1.EventTypeWarning, EventReasonDeletionError, "Failed to extract cluster ID: %v", err)
 				return ctrl.Result{}, err
 			}
 
-			// Delete the associated resources
-			if err = gateway.EnsureGatewayFirewallConfigurationDeleted(ctx, r.Client, clusterID); err != nil {
-				return ctrl.Result{}, utils.HandleReconcileError(
-					ctx,
-					r.Client,
-					logger,
-					r.Recorder,
-					cfg,
-					err,
-					"error during FirewallConfiguration deletion",
-					EventReasonDeletionError,
-					ConditionReasonDeletionFailed,
-				)
+			// The generated resources are kept as long as other PeeringConnectivity resources
+			// of the namespace remain in the chain: they are forged again without this one.
+			chain, err := utils.GetPeeringConnectivityChain(ctx, r.Client, cfg.Namespace, nil)
+			if err != nil {
+				return ctrl.Result{}, err
 			}
-			if err = networkpolicy.EnsureNetworkPoliciesDeleted(ctx, r.Client, clusterID); err != nil {
-				return ctrl.Result{}, utils.HandleReconcileError(
-					ctx,
-					r.Client,
-					logger,
-					r.Recorder,
-					cfg,
-					err,
-					"error during NetworkPolicy deletion",
-					EventReasonDeletionError,
-					ConditionReasonDeletionFailed,
-				)
+			if chain != nil {
+				logger.Info("other PeeringConnectivity resources remain in the chain, keeping the associated resources",
+					"chain", len(chain.Members))
+			} else if err = r.deleteAssociatedResources(ctx, cfg, clusterID); err != nil {
+				return ctrl.Result{}, err
 			}
-			logger.Info("successfully deleted associated resources during finalization")
 
 			// Remove the finalizer to allow deletion to proceed
 			controllerutil.RemoveFinalizer(cfg, FinalizerName)
@@ -184,10 +258,16 @@ func (r *PeeringConnectivityReconciler) Reconcile(ctx context.Context, req ctrl.
 		return ctrl.Result{}, nil
 	}
 
+	// ROLLBACK: restore the spec of a recorded revision, if requested.
+	// The updated spec triggers a new reconciliation, which applies it.
+	if target, found := cfg.Annotations[utils.RollbackAnnotationKey]; found {
+		return ctrl.Result{}, r.rollback(ctx, cfg, target)
+	}
+
 	// ANALYZE: fetch necessary data.
-	// Extract Cluster ID from Namespace.
-	// The namespace should follow the pattern: liqo-tenant-<cluster-id>.
-	clusterID, err := utils.ExtractClusterIDFromNamespace(req.Namespace)
+	// Resolve the ID of the peered cluster from the tenant namespace,
+	// through the Liqo ForeignCluster and Tenant resources.
+	clusterID, err := utils.GetClusterIDFromNamespace(ctx, r.Client, req.Namespace)
 	if err != nil {
 		return ctrl.Result{}, utils.HandleReconcileError(
 			ctx,
@@ -196,17 +276,122 @@ func (r *PeeringConnectivityReconciler) Reconcile(ctx context.Context, req ctrl.
 			r.Recorder,
 			cfg,
 			err,
-			"unable to extract cluster ID from namespace",
+			"unable to resolve cluster ID from namespace",
 			EventReasonReconcileError,
 			ConditionReasonClusterIDError,
 		)
 	}
 
+	// MIGRATE: remove the FirewallConfigurations using the legacy shared table names.
+	// They must be fully deleted before being recreated, so that the old tables are
+	// removed from the nodes instead of being left behind.
+	migrating, err := r.ensureLegacyFirewallConfigurationsDeleted(ctx, cfg.Namespace, clusterID)
+	if err != nil {
+		return ctrl.Result{}, utils.HandleReconcileError(
+			ctx,
+			r.Client,
+			logger,
+			r.Recorder,
+			cfg,
+			err,
+			"unable to remove legacy firewall configurations",
+			EventReasonReconcileError,
+			ConditionReasonMigrationFailed,
+		)
+	}
+	if migrating {
+		logger.Info("waiting for the legacy firewall configurations to be deleted")
+		return ctrl.Result{RequeueAfter: legacyMigrationRequeueDelay}, nil
+	}
+
+	// MERGE: the PeeringConnectivity resources of the namespace are merged into a single chain,
+	// from which the resources are forged.
+	chain, err := utils.GetPeeringConnectivityChain(ctx, r.Client, cfg.Namespace, cfg)
+	if err != nil {
+		return ctrl.Result{}, utils.HandleReconcileError(
+			ctx,
+			r.Client,
+			logger,
+			r.Recorder,
+			cfg,
+			err,
+			"unable to merge the PeeringConnectivity resources of the namespace",
+			EventReasonReconcileError,
+			ConditionReasonChainMergeFailed,
+		)
+	}
+
+	// The restrictions delegated to the application namespaces are enforced after the chain.
+	chain.Restrictions, err = utils.GetNamespaceRestrictions(ctx, r.Client, clusterID)
+	if err != nil {
+		return ctrl.Result{}, utils.HandleReconcileError(
+			ctx,
+			r.Client,
+			logger,
+			r.Recorder,
+			cfg,
+			err,
+			"unable to merge the NamespacePeeringPolicy resources",
+			EventReasonReconcileError,
+			ConditionReasonNamespaceRestrictionFailed,
+		)
+	}
+
+	// GRANT: the rules of the active AccessGrant resources of the peering are evaluated ahead of the chain,
+	// until they expire.
+	now := time.Now()
+	grants, grantExpiration, err := utils.GetActiveAccessGrants(ctx, r.Client, clusterID, now)
+	if err != nil {
+		return ctrl.Result{}, utils.HandleReconcileError(
+			ctx,
+			r.Client,
+			logger,
+			r.Recorder,
+			cfg,
+			err,
+			"unable to retrieve the AccessGrant resources of the peering",
+			EventReasonReconcileError,
+			ConditionReasonAccessGrantFailed,
+		)
+	}
+	chain.InjectAccessGrants(grants)
+	services := referencedServices(chain, r.Options)
+	r.ServiceReferences.Set(client.ObjectKeyFromObject(cfg), services)
+
+	// The parties referencing missing Services match no traffic, so that the other rules keep being enforced.
+	missingServices, err := utils.GetMissingServices(ctx, r.Client, services)
+	if err != nil {
+		return ctrl.Result{}, utils.HandleReconcileError(
+			ctx,
+			r.Client,
+			logger,
+			r.Recorder,
+			cfg,
+			err,
+			"unable to retrieve the Services referenced by the chain",
+			EventReasonReconcileError,
+			ConditionReasonServiceLookupFailed,
+		)
+	}
+	if len(missingServices) > 0 {
+		logger.Info("some Services referenced by the chain do not exist, their parties match no traffic", "services", missingServices)
+	}
+
+	// SCHEDULE: the rules outside of their time windows are not enforced until a window opens.
+	// The indexes of the rendered rules in the chain are kept to map the counters back to the rules.
+	schedules, _ := utils.EvaluateSchedules(cfg.Spec.Rules, now)
+	rendered, nextTransition := utils.ActiveRuleIndexes(chain.Effective.Spec.Rules, now)
+	rules := chain.Effective.Spec.Rules
+	chain.Effective.Spec.Rules = make([]connectivityv1.Rule, len(rendered))
+	for i, index := range rendered {
+		chain.Effective.Spec.Rules[i] = rules[index]
+	}
+
 	// ACT: reconcile resources.
 	// Create or update the FirewallConfiguration and NetworkPolicy.
 	// The FirewallConfiguration is the Liqo resource that implements the actual
 	// firewall rules at the network level.
-	gatewayOp, err := gateway.ReconcileGatewayFirewallConfiguration(ctx, r.Client, r.Scheme, cfg, clusterID)
+	gatewayOp, firewallHash, err := gateway.ReconcileGatewayFirewallConfiguration(ctx, r.Client, r.Scheme, r.Options, r.FQDNResolver, chain, clusterID)
 	if err != nil {
 		return ctrl.Result{}, utils.HandleReconcileError(
 			ctx,
@@ -221,7 +406,7 @@ func (r *PeeringConnectivityReconciler) Reconcile(ctx context.Context, req ctrl.
 		)
 	}
 
-	err = networkpolicy.ReconcileNetworkPolicies(ctx, r.Client, r.Scheme, cfg, clusterID)
+	err = networkpolicy.ReconcileNetworkPolicies(ctx, r.Client, r.Scheme, r.Options, r.FQDNResolver, chain, clusterID)
 	if err != nil {
 		return ctrl.Result{}, utils.HandleReconcileError(
 			ctx,
@@ -236,10 +421,52 @@ func (r *PeeringConnectivityReconciler) Reconcile(ctx context.Context, req ctrl.
 		)
 	}
 
+	// RECORD: record the applied spec as a revision, which the resource can be rolled back to.
+	revisions, err := utils.RecordRevision(ctx, r.Client, r.Scheme, cfg, firewallHash, r.Options.History.RevisionLimit, now)
+	if err != nil {
+		return ctrl.Result{}, utils.HandleReconcileError(
+			ctx,
+			r.Client,
+			logger,
+			r.Recorder,
+			cfg,
+			err,
+			"unable to record the revision of the spec",
+			EventReasonReconcileError,
+			ConditionReasonRevisionFailed,
+		)
+	}
+
 	logger.Info("reconciliation completed", "gatewayOp", gatewayOp)
 
+	// COUNT: read back the counters of the rules at the gateway, at most once per interval,
+	// since updating them in the status triggers a new reconciliation.
+	var nextCounterRead time.Time
+	if r.CounterReader != nil {
+		interval := r.Options.Counters.Interval
+		if cfg.Status.Counters != nil && now.Sub(cfg.Status.Counters.LastUpdate.Time) < interval {
+			nextCounterRead = cfg.Status.Counters.LastUpdate.Add(interval)
+		} else {
+			snapshot, err := r.CounterReader.Read(ctx, clusterID, cfg.Namespace, gateway.ForgeGatewayTableName(clusterID))
+			if err != nil {
+				// The counters are informational: keep the previous ones and retry at the next interval.
+				logger.Error(err, "unable to read the counters of the rules")
+			} else {
+				r.CounterMetrics.Set(clusterID, snapshot)
+				cfg.Status.Counters = forgeCounterStatus(chain.Placements[cfg.Name], rendered,
+					gateway.AggregateRuleCounters(snapshot, clusterID), now)
+			}
+			nextCounterRead = now.Add(interval)
+		}
+	}
+
 	// Update status to reflect successful reconciliation.
 	cfg.Status.ObservedGeneration = cfg.Generation
+	cfg.Status.Placement = chain.Placements[cfg.Name]
+	r.recordScheduleTransitions(cfg, cfg.Status.Schedules, schedules)
+	cfg.Status.Schedules = schedules
+	cfg.Status.CurrentRevision = revisions[len(revisions)-1].Revision
+	cfg.Status.Revisions = utils.ForgeRevisionStatuses(revisions)
 
 	meta.SetStatusCondition(&cfg.Status.Conditions, metav1.Condition{
 		Type:    utils.ConditionTypeReady,
@@ -247,6 +474,7 @@ func (r *PeeringConnectivityReconciler) Reconcile(ctx context.Context, req ctrl.
 		Reason:  ConditionReasonSynced,
 		Message: "Resources successfully synced",
 	})
+	meta.SetStatusCondition(&cfg.Status.Conditions, forgeServicesCondition(missingServices))
 
 	if err := r.Status().Update(ctx, cfg); err != nil {
 		logger.Error(err, "failed to update status")
@@ -260,7 +488,232 @@ func (r *PeeringConnectivityReconciler) Reconcile(ctx context.Context, req ctrl.
 
 	// TODO: emit events for NetworkPolicy operations too.
 
-	return ctrl.Result{}, nil
+	// REQUEUE: resolve again the domain names of the FQDN parties when their TTL expires,
+	// evaluate again the schedules of the rules when a window opens or closes,
+	// remove the rules of the AccessGrants when they expire, and read the counters again.
+	var requeueAfter time.Duration
+	requeueAt := func(at time.Time, minDelay time.Duration) {
+		if at.IsZero() {
+			return
+		}
+		if delay := max(time.Until(at), minDelay); requeueAfter == 0 || delay < requeueAfter {
+			requeueAfter = delay
+		}
+	}
+	if expiration, found := r.FQDNResolver.NextExpiration(referencedFQDNs(chain)); found {
+		requeueAt(expiration, minFQDNRequeueDelay)
+	}
+	requeueAt(nextTransition, minScheduleRequeueDelay)
+	requeueAt(grantExpiration, minAccessGrantRequeueDelay)
+	requeueAt(nextCounterRead, minScheduleRequeueDelay)
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// recordScheduleTransitions emits an event for each scheduled rule of the PeeringConnectivity
+// that has been activated or deactivated since the previous reconciliation.
+func (r *PeeringConnectivityReconciler) recordScheduleTransitions(
+	cfg *connectivityv1.PeeringConnectivity, previous, current []connectivityv1.RuleScheduleStatus,
+) {
+	for _, status := range current {
+		i := slices.IndexFunc(previous, func(old connectivityv1.RuleScheduleStatus) bool { return old.Rule == status.Rule })
+		if i < 0 || previous[i].Active == status.Active {
+			continue
+		}
+
+		if status.Active {
+			r.Recorder.Eventf(cfg, corev1.EventTypeNormal, EventReasonRuleActivated, "Rule %d activated by its schedule", status.Rule)
+		} else {
+			r.Recorder.Eventf(cfg, corev1.EventTypeNormal, EventReasonRuleDeactivated, "Rule %d deactivated by its schedule", status.Rule)
+		}
+	}
+}
+
+// rollback restores the spec of the PeeringConnectivity to the recorded revision with the given number,
+// and removes the rollback request. A request that cannot be fulfilled is dropped, keeping the current spec.
+func (r *PeeringConnectivityReconciler) rollback(ctx context.Context, cfg *connectivityv1.PeeringConnectivity, target string) error {
+	logger := log.FromContext(ctx)
+
+	revision, err := strconv.ParseInt(target, 10, 64)
+	if err != nil {
+		err = fmt.Errorf("invalid revision %q: %w", target, err)
+	} else {
+		var spec *connectivityv1.PeeringConnectivitySpec
+		if spec, err = utils.GetRevisionSpec(ctx, r.Client, cfg, revision); err == nil {
+			cfg.Spec = *spec
+		}
+	}
+
+	delete(cfg.Annotations, utils.RollbackAnnotationKey)
+	if updateErr := r.Update(ctx, cfg); updateErr != nil {
+		return updateErr
+	}
+
+	if err != nil {
+		logger.Error(err, "unable to roll back the PeeringConnectivity", "revision", target)
+		r.Recorder.Eventf(cfg, corev1.EventTypeWarning, EventReasonRollbackFailed, "Failed to roll back to revision %s: %v", target, err)
+		return nil
+	}
+
+	logger.Info("rolled back the PeeringConnectivity", "revision", revision)
+	r.Recorder.Eventf(cfg, corev1.EventTypeNormal, EventReasonRolledBack, "Rolled back to revision %d", revision)
+	return nil
+}
+
+// forgeCounterStatus maps the counters of the rules of the gateway chain, keyed by the index of the rendered
+// rules, back to the rules of the PeeringConnectivity with the given placement in the chain. The rendered
+// rules are the indexes in the chain of the rules rendered at the gateway, excluding the inactive ones.
+func forgeCounterStatus(
+	placement *connectivityv1.ChainPlacement, rendered []int, aggregated map[int]counters.Counter, now time.Time,
+) *connectivityv1.CounterStatus {
+	status := &connectivityv1.CounterStatus{LastUpdate: metav1.NewTime(now)}
+	if placement == nil {
+		return status
+	}
+
+	for rule := range placement.Rules {
+		index, found := slices.BinarySearch(rendered, int(placement.FirstRule+rule))
+		if !found {
+			continue
+		}
+
+		counter := aggregated[index]
+		status.Rules = append(status.Rules, connectivityv1.RuleCounterStatus{
+			Rule:    rule,
+			Packets: int64(counter.Packets),
+			Bytes:   int64(counter.Bytes),
+		})
+	}
+	return status
+}
+
+// deleteAssociatedResources deletes the FirewallConfiguration and the NetworkPolicy resources
+// generated for the peering with the given cluster.
+func (r *PeeringConnectivityReconciler) deleteAssociatedResources(
+	ctx context.Context, cfg *connectivityv1.PeeringConnectivity, clusterID string,
+) error {
+	logger := log.FromContext(ctx)
+
+	if err := gateway.EnsureGatewayFirewallConfigurationDeleted(ctx, r.Client, cfg.Namespace, clusterID); err != nil {
+		return utils.HandleReconcileError(
+			ctx,
+			r.Client,
+			logger,
+			r.Recorder,
+			cfg,
+			err,
+			"error during FirewallConfiguration deletion",
+			EventReasonDeletionError,
+			ConditionReasonDeletionFailed,
+		)
+	}
+	if err := networkpolicy.EnsureNetworkPoliciesDeleted(ctx, r.Client, clusterID); err != nil {
+		return utils.HandleReconcileError(
+			ctx,
+			r.Client,
+			logger,
+			r.Recorder,
+			cfg,
+			err,
+			"error during NetworkPolicy deletion",
+			EventReasonDeletionError,
+			ConditionReasonDeletionFailed,
+		)
+	}
+	r.CounterMetrics.Delete(clusterID)
+	r.ServiceReferences.Delete(client.ObjectKeyFromObject(cfg))
+	logger.Info("successfully deleted associated resources during finalization")
+	return nil
+}
+
+// referencedFQDNs returns the domain names of the FQDN parties of the effective PeeringConnectivity
+// of the chain and of its namespace restrictions.
+func referencedFQDNs(chain *utils.PeeringConnectivityChain) []string {
+	var names []string
+	for i := range chain.Effective.Spec.Rules {
+		for _, party := range []*connectivityv1.Party{chain.Effective.Spec.Rules[i].Source, chain.Effective.Spec.Rules[i].Destination} {
+			if party != nil && party.FQDN != nil {
+				names = append(names, string(*party.FQDN))
+			}
+		}
+	}
+	for i := range chain.Restrictions {
+		for _, party := range slices.Concat(chain.Restrictions[i].Ingress, chain.Restrictions[i].Egress) {
+			if party.FQDN != nil {
+				names = append(names, string(*party.FQDN))
+			}
+		}
+	}
+	return names
+}
+
+// forgeServicesCondition creates the condition reporting the missing Services referenced by the chain.
+func forgeServicesCondition(missing []types.NamespacedName) metav1.Condition {
+	if len(missing) == 0 {
+		return metav1.Condition{
+			Type:    ConditionTypeServicesFound,
+			Status:  metav1.ConditionTrue,
+			Reason:  ConditionReasonServicesFound,
+			Message: "All the referenced Services exist",
+		}
+	}
+
+	names := make([]string, len(missing))
+	for i := range missing {
+		names[i] = missing[i].String()
+	}
+	return metav1.Condition{
+		Type:    ConditionTypeServicesFound,
+		Status:  metav1.ConditionFalse,
+		Reason:  ConditionReasonServicesMissing,
+		Message: fmt.Sprintf("Services not found, their parties match no traffic: %s", strings.Join(names, ", ")),
+	}
+}
+
+// referencedServices returns the Services of the parties of the effective PeeringConnectivity
+// of the chain and of its namespace restrictions, including the cluster DNS Service matched
+// by the nameserver group.
+func referencedServices(chain *utils.PeeringConnectivityChain, opts *options.Options) []types.NamespacedName {
+	var services []types.NamespacedName
+	reference := func(party *connectivityv1.Party) {
+		switch {
+		case party == nil:
+		case party.Service != nil:
+			services = append(services, types.NamespacedName{Namespace: party.Service.Namespace, Name: party.Service.Name})
+		case party.Group != nil && *party.Group == connectivityv1.ResourceGroupNameserver:
+			services = append(services, types.NamespacedName{Namespace: opts.DNS.ServiceNamespace, Name: opts.DNS.ServiceName})
+		}
+	}
+
+	for i := range chain.Effective.Spec.Rules {
+		reference(chain.Effective.Spec.Rules[i].Source)
+		reference(chain.Effective.Spec.Rules[i].Destination)
+	}
+	for i := range chain.Restrictions {
+		for _, party := range slices.Concat(chain.Restrictions[i].Ingress, chain.Restrictions[i].Egress) {
+			reference(&party)
+		}
+	}
+	return services
+}
+
+// ensureLegacyFirewallConfigurationsDeleted deletes the gateway and fabric FirewallConfigurations
+// of the given cluster that still use the legacy shared table name.
+// It returns true while at least one of them is still being deleted.
+func (r *PeeringConnectivityReconciler) ensureLegacyFirewallConfigurationsDeleted(
+	ctx context.Context, namespace, clusterID string,
+) (bool, error) {
+	gatewayPending, err := gateway.EnsureLegacyGatewayFirewallConfigurationDeleted(ctx, r.Client, namespace, clusterID)
+	if err != nil {
+		return false, err
+	}
+
+	fabricPending, err := fabric.EnsureLegacyFabricFirewallConfigurationDeleted(ctx, r.Client, namespace, clusterID)
+	if err != nil {
+		return false, err
+	}
+
+	return gatewayPending || fabricPending, nil
 }
 
 // podEnqueuer enqueues PeeringConnectivity reconciliation requests based on Pod changes.
@@ -285,14 +738,23 @@ func (r *PeeringConnectivityReconciler) podEnqueuer(ctx context.Context, obj cli
 	localPodLabel, exists := labels[consts.LocalPodLabelKey]
 	if exists && localPodLabel == consts.LocalPodLabelValue {
 		// The Pod is a shadow Pod on the consumer cluster.
-		// Enqueue the PeeringConnectivity for the provider cluster (node name).
+		// Enqueue the PeeringConnectivity for the provider cluster targeted by its virtual node.
 		nodeName := pod.Spec.NodeName
 		if nodeName == "" {
 			return nil
 		}
 
-		logger.Info("Enqueuing Configuration for Offloaded Pod", "pod", pod.Name, "node", nodeName)
-		return []ctrl.Request{{NamespacedName: types.NamespacedName{Name: nodeName, Namespace: utils.GetClusterNamespace(nodeName)}}}
+		providerCluster, err := utils.GetClusterIDFromNode(ctx, r.Client, nodeName)
+		if err != nil {
+			logger.Error(err, "unable to resolve the cluster ID of the virtual node", "node", nodeName)
+			return nil
+		}
+		if providerCluster == "" {
+			return nil
+		}
+
+		logger.Info("Enqueuing Configuration for Offloaded Pod", "pod", pod.Name, "node", nodeName, "providerCluster", providerCluster)
+		return r.clusterEnqueuer(ctx, providerCluster)
 	}
 
 	originClusterLabel, exists := labels[vkforge.LiqoOriginClusterIDKey]
@@ -300,12 +762,64 @@ func (r *PeeringConnectivityReconciler) podEnqueuer(ctx context.Context, obj cli
 		// The Pod is offloaded to the provider cluster.
 		// Enqueue the PeeringConnectivity for the consumer cluster (origin cluster).
 		logger.Info("Enqueuing Configuration for Pod from Consumer", "pod", pod.Name, "originCluster", originClusterLabel)
-		return []ctrl.Request{{NamespacedName: types.NamespacedName{Name: originClusterLabel, Namespace: utils.GetClusterNamespace(originClusterLabel)}}}
+		return r.clusterEnqueuer(ctx, originClusterLabel)
 	}
 
 	return nil
 }
 
+// restrictedPodEnqueuer enqueues the PeeringConnectivity resources of the peerings the NamespacePeeringPolicy
+// resources of the namespace of a Pod apply to, since the addresses of the pods are matched by their restrictions.
+func (r *PeeringConnectivityReconciler) restrictedPodEnqueuer(ctx context.Context, obj client.Object) []ctrl.Request {
+	logger := log.FromContext(ctx)
+
+	policyList := &connectivityv1.NamespacePeeringPolicyList{}
+	if err := r.Client.List(ctx, policyList, client.InNamespace(obj.GetNamespace())); err != nil {
+		logger.Error(err, "unable to list NamespacePeeringPolicy resources", "namespace", obj.GetNamespace())
+		return nil
+	}
+
+	var requests []ctrl.Request
+	for i := range policyList.Items {
+		requests = append(requests, r.namespacePolicyEnqueuer(ctx, &policyList.Items[i])...)
+	}
+	return requests
+}
+
+// namespacePolicyEnqueuer enqueues the PeeringConnectivity resources of the peerings a NamespacePeeringPolicy applies to.
+func (r *PeeringConnectivityReconciler) namespacePolicyEnqueuer(ctx context.Context, obj client.Object) []ctrl.Request {
+	logger := log.FromContext(ctx)
+
+	policy, ok := obj.(*connectivityv1.NamespacePeeringPolicy)
+	if !ok {
+		logger.Error(nil, "Expected a NamespacePeeringPolicy object but got a different type", "type", fmt.Sprintf("%T", obj))
+		return nil
+	}
+
+	if len(policy.Spec.ClusterIDs) == 0 {
+		return r.allPeeringConnectivityEnqueuer(ctx, obj)
+	}
+
+	var requests []ctrl.Request
+	for _, clusterID := range policy.Spec.ClusterIDs {
+		requests = append(requests, r.clusterEnqueuer(ctx, clusterID)...)
+	}
+	return requests
+}
+
+// accessGrantEnqueuer enqueues the PeeringConnectivity resources of the peering an AccessGrant refers to.
+func (r *PeeringConnectivityReconciler) accessGrantEnqueuer(ctx context.Context, obj client.Object) []ctrl.Request {
+	logger := log.FromContext(ctx)
+
+	grant, ok := obj.(*connectivityv1.AccessGrant)
+	if !ok {
+		logger.Error(nil, "Expected an AccessGrant object but got a different type", "type", fmt.Sprintf("%T", obj))
+		return nil
+	}
+
+	return r.clusterEnqueuer(ctx, grant.Spec.ClusterID)
+}
+
 // networkEnqueuer enqueues PeeringConnectivity reconciliation requests based on Network changes.
 // This function is called when a Liqo Network resource is created, updated, or deleted.
 // Network resources contain CIDR information that is used in firewall rules.
@@ -319,19 +833,80 @@ func (r *PeeringConnectivityReconciler) networkEnqueuer(ctx context.Context, obj
 	}
 
 	namespace := obj.GetNamespace()
-	if namespace == "liqo" {
-		// Ignore Network resources in the Liqo system namespace.
-		return nil
+	if namespace == r.Options.Liqo.Namespace {
+		// Network resources in the Liqo namespace describe the local cluster (e.g., its service CIDR),
+		// which is matched by the rules of all the PeeringConnectivity resources.
+		return r.allPeeringConnectivityEnqueuer(ctx, obj)
 	}
 
-	// Extract the cluster ID from the namespace and enqueue the corresponding PeeringConnectivity.
-	clusterId, err := utils.ExtractClusterIDFromNamespace(namespace)
+	// The Network describes the peered cluster of its tenant namespace,
+	// hence enqueue the PeeringConnectivity resources of that namespace.
+	return r.namespaceEnqueuer(ctx, namespace)
+}
+
+// clusterEnqueuer enqueues the PeeringConnectivity resources of the given peered cluster,
+// i.e., those in its tenant namespace.
+func (r *PeeringConnectivityReconciler) clusterEnqueuer(ctx context.Context, clusterID string) []ctrl.Request {
+	logger := log.FromContext(ctx)
+
+	namespace, err := utils.GetTenantNamespace(ctx, r.Client, clusterID)
 	if err != nil {
-		logger.Error(err, "unable to extract cluster ID from Network namespace", "namespace", namespace)
+		logger.Error(err, "unable to resolve the tenant namespace of the cluster", "clusterID", clusterID)
+		return nil
+	}
+
+	return r.namespaceEnqueuer(ctx, namespace)
+}
+
+// namespaceEnqueuer enqueues the PeeringConnectivity resources in the given tenant namespace.
+func (r *PeeringConnectivityReconciler) namespaceEnqueuer(ctx context.Context, namespace string) []ctrl.Request {
+	logger := log.FromContext(ctx)
+
+	peeringConnectivityList := &connectivityv1.PeeringConnectivityList{}
+	if err := r.Client.List(ctx, peeringConnectivityList, client.InNamespace(namespace)); err != nil {
+		logger.Error(err, "unable to list PeeringConnectivity resources", "namespace", namespace)
 		return nil
 	}
 
-	return []ctrl.Request{{NamespacedName: types.NamespacedName{Name: clusterId, Namespace: utils.GetClusterNamespace(clusterId)}}}
+	requests := make([]ctrl.Request, 0, len(peeringConnectivityList.Items))
+	for _, pc := range peeringConnectivityList.Items {
+		requests = append(requests, ctrl.Request{
+			NamespacedName: types.NamespacedName{Name: pc.Name, Namespace: pc.Namespace},
+		})
+	}
+
+	return requests
+}
+
+// siblingEnqueuer enqueues the PeeringConnectivity resources sharing the namespace of the changed one,
+// since they are merged into the same chain.
+func (r *PeeringConnectivityReconciler) siblingEnqueuer(ctx context.Context, obj client.Object) []ctrl.Request {
+	return r.namespaceEnqueuer(ctx, obj.GetNamespace())
+}
+
+// peeringIdentityEnqueuer enqueues the PeeringConnectivity resources of the cluster described by a
+// ForeignCluster, a Tenant or a VirtualNode, since they determine the tenant namespace and the
+// virtual nodes of the peering.
+func (r *PeeringConnectivityReconciler) peeringIdentityEnqueuer(ctx context.Context, obj client.Object) []ctrl.Request {
+	logger := log.FromContext(ctx)
+
+	switch o := obj.(type) {
+	case *liqov1beta1.ForeignCluster:
+		if namespace := o.Status.TenantNamespace.Local; namespace != "" {
+			return r.namespaceEnqueuer(ctx, namespace)
+		}
+		return r.clusterEnqueuer(ctx, string(o.Spec.ClusterID))
+	case *authv1beta1.Tenant:
+		if o.Status.TenantNamespace != "" {
+			return r.namespaceEnqueuer(ctx, o.Status.TenantNamespace)
+		}
+		return r.clusterEnqueuer(ctx, string(o.Spec.ClusterID))
+	case *offloadingv1beta1.VirtualNode:
+		return r.clusterEnqueuer(ctx, string(o.Spec.ClusterID))
+	default:
+		logger.Error(nil, "Expected a ForeignCluster, Tenant or VirtualNode object but got a different type", "type", fmt.Sprintf("%T", obj))
+		return nil
+	}
 }
 
 // allPeeringConnectivityEnqueuer enqueues reconciliation for all PeeringConnectivity resources.
@@ -360,6 +935,96 @@ func (r *PeeringConnectivityReconciler) allPeeringConnectivityEnqueuer(ctx conte
 	return requests
 }
 
+// serviceEnqueuer enqueues reconciliation when a Service or one of its EndpointSlices changes.
+// Changes to the cluster DNS Service affect the nameserver resource group, hence all the
+// PeeringConnectivity resources are enqueued; otherwise the PeeringConnectivity resources
+// referencing the Service as a party are enqueued, as well as the one of the consumer
+// cluster the Service is reflected from, if any.
+func (r *PeeringConnectivityReconciler) serviceEnqueuer(ctx context.Context, obj client.Object) []ctrl.Request {
+	logger := log.FromContext(ctx)
+
+	var serviceName string
+	switch obj.(type) {
+	case *corev1.Service:
+		serviceName = obj.GetName()
+	case *discoveryv1.EndpointSlice:
+		serviceName = obj.GetLabels()[discoveryv1.LabelServiceName]
+	default:
+		logger.Error(nil, "Expected a Service or EndpointSlice object but got a different type", "type", fmt.Sprintf("%T", obj))
+		return nil
+	}
+	if serviceName == "" {
+		return nil
+	}
+
+	if obj.GetNamespace() == r.Options.DNS.ServiceNamespace && serviceName == r.Options.DNS.ServiceName {
+		return r.allPeeringConnectivityEnqueuer(ctx, obj)
+	}
+
+	var requests []ctrl.Request
+	if originCluster, exists := obj.GetLabels()[vkforge.LiqoOriginClusterIDKey]; exists {
+		// The Service is reflected from the consumer cluster, hence it belongs to the remote-services group.
+		requests = append(requests, r.clusterEnqueuer(ctx, originCluster)...)
+	}
+
+	peeringConnectivityList := &connectivityv1.PeeringConnectivityList{}
+	if err := r.Client.List(ctx, peeringConnectivityList); err != nil {
+		logger.Error(err, "unable to list PeeringConnectivity resources for Service enqueuing")
+		return nil
+	}
+
+	for i := range peeringConnectivityList.Items {
+		pc := &peeringConnectivityList.Items[i]
+		service := types.NamespacedName{Namespace: obj.GetNamespace(), Name: serviceName}
+		if referencesService(pc, service) || r.ServiceReferences.References(client.ObjectKeyFromObject(pc), service) {
+			requests = append(requests, ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: pc.Name, Namespace: pc.Namespace},
+			})
+		}
+	}
+
+	return requests
+}
+
+// referencesService checks whether any rule of the PeeringConnectivity uses the given Service as a party.
+// The Services referenced through the AccessGrants and the namespace restrictions are tracked by the
+// ServiceReferences once the PeeringConnectivity is reconciled, while this covers the ones not yet reconciled.
+func referencesService(pc *connectivityv1.PeeringConnectivity, service types.NamespacedName) bool {
+	matches := func(party *connectivityv1.Party) bool {
+		return party != nil && party.Service != nil &&
+			party.Service.Namespace == service.Namespace && party.Service.Name == service.Name
+	}
+
+	for i := range pc.Spec.Rules {
+		if matches(pc.Spec.Rules[i].Source) || matches(pc.Spec.Rules[i].Destination) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeAddressesChanged filters the Node events, ignoring the updates that do not change the node addresses
+// (e.g., status heartbeats), since only the addresses are relevant for the local-nodes resource group.
+var nodeAddressesChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldNode, okOld := e.ObjectOld.(*corev1.Node)
+		newNode, okNew := e.ObjectNew.(*corev1.Node)
+		if !okOld || !okNew {
+			return true
+		}
+		return !slices.Equal(utils.NodeAddresses(oldNode), utils.NodeAddresses(newNode))
+	},
+}
+
+// peeringIdentityChanged filters the ForeignCluster, Tenant and VirtualNode events, ignoring the updates
+// that do not change the fields mapping the peerings to their tenant namespaces and virtual nodes
+// (e.g., the status of the peering modules), which are the only ones the reconciliation depends on.
+var peeringIdentityChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return utils.IdentityChanged(e.ObjectOld, e.ObjectNew)
+	},
+}
+
 func (r *PeeringConnectivityReconciler) networkPolicyEnqueuer(ctx context.Context, obj client.Object) []ctrl.Request {
 	logger := log.FromContext(ctx)
 
@@ -377,22 +1042,53 @@ func (r *PeeringConnectivityReconciler) networkPolicyEnqueuer(ctx context.Contex
 		return nil
 	}
 
-	return []ctrl.Request{{NamespacedName: types.NamespacedName{Name: clusterId, Namespace: utils.GetClusterNamespace(clusterId)}}}
+	return r.clusterEnqueuer(ctx, clusterId)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 // It configures the controller to:
 // - Reconcile PeeringConnectivity resources
-// - Own FirewallConfiguration resources (so they're deleted when the PC is deleted)
-// - Watch Pods, Networks, NetworkPolicies, and NamespaceOffloadings to trigger reconciliation when they change
+// - Own FirewallConfiguration resources (so they're deleted when all the PCs of the chain are deleted)
+// - Watch the spec of the other PCs of the same namespace, which are merged into the same chain
+// - Watch Pods, Networks, IPs, NetworkPolicies, NamespaceOffloadings, Services, EndpointSlices and Nodes
+// to trigger reconciliation when they change
+// - Watch the NamespacePeeringPolicies, which restrict the traffic of the pods of their namespace
+// - Watch the AccessGrants, whose rules are evaluated ahead of the chain of their peering
+// - Watch ForeignClusters, Tenants and VirtualNodes, which map the peered clusters to their tenant
+// namespaces and virtual nodes, through the identity indexes registered by utils.SetupIdentityIndexes
+// - Register the metrics of the counters of the rules, if they are read
 func (r *PeeringConnectivityReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.CounterMetrics != nil {
+		if err := metrics.Registry.Register(r.CounterMetrics); err != nil {
+			return err
+		}
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&connectivityv1.PeeringConnectivity{}).
-		Owns(&networkingv1beta1.FirewallConfiguration{}).
+		Owns(&networkingv1beta1.FirewallConfiguration{}, builder.MatchEveryOwner).
+		Watches(&connectivityv1.PeeringConnectivity{}, handler.EnqueueRequestsFromMapFunc(r.siblingEnqueuer),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.podEnqueuer)).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.restrictedPodEnqueuer)).
+		Watches(&connectivityv1.NamespacePeeringPolicy{}, handler.EnqueueRequestsFromMapFunc(r.namespacePolicyEnqueuer),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&connectivityv1.AccessGrant{}, handler.EnqueueRequestsFromMapFunc(r.accessGrantEnqueuer),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Watches(&ipamv1alpha1.Network{}, handler.EnqueueRequestsFromMapFunc(r.networkEnqueuer)).
 		Watches(&networkingv1.NetworkPolicy{}, handler.EnqueueRequestsFromMapFunc(r.networkPolicyEnqueuer)).
 		Watches(&offloadingv1beta1.NamespaceOffloading{}, handler.EnqueueRequestsFromMapFunc(r.allPeeringConnectivityEnqueuer)).
+		Watches(&corev1.Service{}, handler.EnqueueRequestsFromMapFunc(r.serviceEnqueuer)).
+		Watches(&discoveryv1.EndpointSlice{}, handler.EnqueueRequestsFromMapFunc(r.serviceEnqueuer)).
+		Watches(&ipamv1alpha1.IP{}, handler.EnqueueRequestsFromMapFunc(r.allPeeringConnectivityEnqueuer)).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(r.allPeeringConnectivityEnqueuer),
+			builder.WithPredicates(nodeAddressesChanged)).
+		Watches(&liqov1beta1.ForeignCluster{}, handler.EnqueueRequestsFromMapFunc(r.peeringIdentityEnqueuer),
+			builder.WithPredicates(peeringIdentityChanged)).
+		Watches(&authv1beta1.Tenant{}, handler.EnqueueRequestsFromMapFunc(r.peeringIdentityEnqueuer),
+			builder.WithPredicates(peeringIdentityChanged)).
+		Watches(&offloadingv1beta1.VirtualNode{}, handler.EnqueueRequestsFromMapFunc(r.peeringIdentityEnqueuer),
+			builder.WithPredicates(peeringIdentityChanged)).
 		Named("peeringconnectivity").
 		Complete(r)
 }
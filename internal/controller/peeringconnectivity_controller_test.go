@@ -16,13 +16,17 @@ package controller
 
 import (
 	"context"
+	"time"
 
 	ipamv1alpha1 "github.com/liqotech/liqo/apis/ipam/v1alpha1"
 	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
+	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	corev1 "k8s.io/api/core/v1"
@@ -30,7 +34,10 @@ import (
 	"k8s.io/utils/ptr"
 
 	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/gateway"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/counters"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 )
 
 var _ = Describe("PeeringConnectivity Controller", func() {
@@ -57,9 +64,10 @@ var _ = Describe("PeeringConnectivity Controller", func() {
 				Namespace: namespace,
 			}
 			reconciler = &PeeringConnectivityReconciler{
-				Client:   k8sClient,
+				Client:   indexedK8sClient,
 				Scheme:   k8sClient.Scheme(),
 				Recorder: k8sMgr.GetEventRecorderFor("peeringconnectivity-controller"),
+				Options:  options.NewDefaultOptions(),
 			}
 
 			// Create the namespaces if they do not exist
@@ -281,6 +289,206 @@ var _ = Describe("PeeringConnectivity Controller", func() {
 			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
 		})
 
+		It("should merge the PeeringConnectivity resources of the namespace by priority", func() {
+			By("creating an application and a platform PeeringConnectivity")
+			application := &connectivityv1.PeeringConnectivity{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespace},
+				Spec: connectivityv1.PeeringConnectivitySpec{
+					Rules: []connectivityv1.Rule{{
+						Action:      connectivityv1.ActionAllow,
+						Destination: &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupInternet)},
+					}},
+				},
+			}
+			platform := &connectivityv1.PeeringConnectivity{
+				ObjectMeta: metav1.ObjectMeta{Name: "platform", Namespace: namespace},
+				Spec: connectivityv1.PeeringConnectivitySpec{
+					Priority: 10,
+					Rules: []connectivityv1.Rule{
+						{Action: connectivityv1.ActionAllow, Destination: &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupNameserver)}},
+						{Action: connectivityv1.ActionAllow, Destination: &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupLocalServices)}},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, application)).To(Succeed())
+			Expect(k8sClient.Create(ctx, platform)).To(Succeed())
+
+			By("Reconciling both resources")
+			for _, pc := range []*connectivityv1.PeeringConnectivity{application, platform} {
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(pc)})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			By("Verifying the placement of the rules in the chain")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(platform), platform)).To(Succeed())
+			Expect(platform.Status.Placement).To(Equal(&connectivityv1.ChainPlacement{
+				Position: 0, FirstRule: 0, Rules: 2, ChainLength: 3, Chain: []string{"platform", resourceName},
+			}))
+			Expect(k8sClient.Get(ctx, namespacedName, application)).To(Succeed())
+			Expect(application.Status.Placement).To(Equal(&connectivityv1.ChainPlacement{
+				Position: 1, FirstRule: 2, Rules: 1, ChainLength: 3, Chain: []string{"platform", resourceName},
+			}))
+
+			By("Verifying the FirewallConfiguration is owned by both resources")
+			fwcfg := &networkingv1beta1.FirewallConfiguration{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: gateway.ForgeGatewayResourceName(clusterID), Namespace: namespace}, fwcfg)).To(Succeed())
+			Expect(fwcfg.OwnerReferences).To(HaveLen(2))
+
+			By("Deleting the platform PeeringConnectivity without deleting the FirewallConfiguration")
+			Expect(k8sClient.Delete(ctx, platform)).To(Succeed())
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(platform)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(fwcfg), fwcfg)).To(Succeed())
+		})
+
+		It("should keep enforcing the other rules when a referenced Service does not exist", func() {
+			resource := &connectivityv1.PeeringConnectivity{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespace},
+				Spec: connectivityv1.PeeringConnectivitySpec{
+					Rules: []connectivityv1.Rule{{
+						Action:      connectivityv1.ActionAllow,
+						Destination: &connectivityv1.Party{Service: &connectivityv1.ServiceReference{Namespace: "default", Name: "missing"}},
+					}, {
+						Action:      connectivityv1.ActionAllow,
+						Source:      &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupRemoteCluster)},
+						Destination: &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupLocalCluster)},
+					}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+
+			By("Reconciling the created resource")
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Verifying the missing Service matches no traffic")
+			fwcfg := &networkingv1beta1.FirewallConfiguration{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: gateway.ForgeGatewayResourceName(clusterID), Namespace: namespace}, fwcfg)).To(Succeed())
+			serviceSet := utils.ForgeServiceSetName("default", "missing")
+			Expect(fwcfg.Spec.Table.Sets).To(ContainElement(HaveField("Name", serviceSet)))
+			for i := range fwcfg.Spec.Table.Sets {
+				if fwcfg.Spec.Table.Sets[i].Name == serviceSet {
+					Expect(fwcfg.Spec.Table.Sets[i].Elements).To(BeEmpty())
+				}
+			}
+
+			By("Verifying the missing Service is reported in the status")
+			Expect(k8sClient.Get(ctx, namespacedName, resource)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(resource.Status.Conditions, utils.ConditionTypeReady)).To(BeTrue())
+			condition := meta.FindStatusCondition(resource.Status.Conditions, ConditionTypeServicesFound)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(ConditionReasonServicesMissing))
+			Expect(condition.Message).To(ContainSubstring("default/missing"))
+		})
+
+		It("should enforce the NamespacePeeringPolicy resources in a restriction chain", func() {
+			By("creating an application namespace with a NamespacePeeringPolicy")
+			appNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}}
+			if err := k8sClient.Create(ctx, appNamespace); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+			policy := &connectivityv1.NamespacePeeringPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "restrict", Namespace: appNamespace.Name},
+				Spec: connectivityv1.NamespacePeeringPolicySpec{
+					ClusterIDs: []string{clusterID},
+					Ingress:    []connectivityv1.Party{{Group: ptr.To(connectivityv1.ResourceGroupRemoteCluster)}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, policy)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, policy))).To(Succeed())
+			})
+
+			resource := &connectivityv1.PeeringConnectivity{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespace},
+				Spec: connectivityv1.PeeringConnectivitySpec{
+					Rules: []connectivityv1.Rule{{
+						Action:      connectivityv1.ActionAllow,
+						Source:      &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupRemoteCluster)},
+						Destination: &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupLocalCluster)},
+					}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+
+			By("Reconciling the created resource")
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Verifying the restriction chain follows the chain of the peering")
+			fwcfg := &networkingv1beta1.FirewallConfiguration{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: gateway.ForgeGatewayResourceName(clusterID), Namespace: namespace}, fwcfg)).To(Succeed())
+			Expect(fwcfg.Spec.Table.Chains).To(HaveLen(2))
+
+			restriction := fwcfg.Spec.Table.Chains[1]
+			Expect(*restriction.Name).To(Equal(gateway.ForgeGatewayRestrictionChainName(clusterID, appNamespace.Name, connectivityv1.NamespacePolicyTypeIngress, options.DefaultChainHook)))
+			Expect(*restriction.Policy).To(Equal(networkingv1beta1firewall.ChainPolicyAccept))
+			Expect(*restriction.Priority).To(BeNumerically(">", *fwcfg.Spec.Table.Chains[0].Priority))
+
+			rules := restriction.Rules.FilterRules
+			Expect(rules).NotTo(BeEmpty())
+			last := rules[len(rules)-1]
+			Expect(last.Action).To(Equal(networkingv1beta1firewall.ActionDrop))
+			Expect(last.Match).To(ConsistOf(networkingv1beta1firewall.Match{
+				IP: &networkingv1beta1firewall.MatchIP{
+					Value:    utils.ForgeSetReference(utils.ForgeNamespaceSetName(appNamespace.Name)),
+					Position: networkingv1beta1firewall.MatchPositionDst,
+				},
+				Op: networkingv1beta1firewall.MatchOperationEq,
+			}))
+		})
+
+		It("should evaluate the active AccessGrant resources of the peering ahead of the chain", func() {
+			By("creating an AccessGrant on the peering")
+			grant := &connectivityv1.AccessGrant{
+				ObjectMeta: metav1.ObjectMeta{Name: "incident"},
+				Spec: connectivityv1.AccessGrantSpec{
+					ClusterID: clusterID,
+					Rule: connectivityv1.Rule{
+						Action:      connectivityv1.ActionAllow,
+						Destination: &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupLocalServices)},
+					},
+					TTL:    metav1.Duration{Duration: time.Hour},
+					Reason: "debugging",
+				},
+			}
+			Expect(k8sClient.Create(ctx, grant)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, grant))).To(Succeed())
+			})
+
+			resource := &connectivityv1.PeeringConnectivity{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespace},
+				Spec: connectivityv1.PeeringConnectivitySpec{
+					Rules: []connectivityv1.Rule{{
+						Action:      connectivityv1.ActionAllow,
+						Destination: &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupInternet)},
+					}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+
+			By("Reconciling the created resource")
+			result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+
+			By("Verifying the rule of the grant precedes the rules of the chain")
+			Expect(k8sClient.Get(ctx, namespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Placement).To(Equal(&connectivityv1.ChainPlacement{
+				Position: 0, FirstRule: 1, Rules: 1, ChainLength: 2, Chain: []string{resourceName}, Grants: []string{grant.Name},
+			}))
+
+			By("Revoking the grant before it expires")
+			Expect(k8sClient.Delete(ctx, grant)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, namespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Placement.Grants).To(BeEmpty())
+			Expect(resource.Status.Placement.ChainLength).To(Equal(int32(1)))
+		})
+
 		It("should update FirewallConfiguration when PeeringConnectivity is updated", func() {
 			By("creating initial PeeringConnectivity")
 			resource := &connectivityv1.PeeringConnectivity{
@@ -357,3 +565,31 @@ var _ = Describe("PeeringConnectivity Controller", func() {
 		})
 	})
 })
+
+var _ = Describe("forgeCounterStatus", func() {
+	now := time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC)
+
+	It("should map the counters of the rendered rules back to the rules of the PeeringConnectivity", func() {
+		// The chain holds a grant rule and a sibling rule ahead of the three rules of the PeeringConnectivity,
+		// whose second rule is outside its schedule and thus not rendered.
+		placement := &connectivityv1.ChainPlacement{FirstRule: 2, Rules: 3, ChainLength: 5}
+		rendered := []int{0, 1, 2, 4}
+		aggregated := map[int]counters.Counter{
+			0: {Packets: 1, Bytes: 100},
+			2: {Packets: 10, Bytes: 1000},
+			3: {Packets: 30, Bytes: 3000},
+		}
+
+		status := forgeCounterStatus(placement, rendered, aggregated, now)
+		Expect(status.LastUpdate.Time).To(Equal(now))
+		Expect(status.Rules).To(Equal([]connectivityv1.RuleCounterStatus{
+			{Rule: 0, Packets: 10, Bytes: 1000},
+			{Rule: 2, Packets: 30, Bytes: 3000},
+		}))
+	})
+
+	It("should report no rules without a placement", func() {
+		status := forgeCounterStatus(nil, []int{0}, map[int]counters.Counter{0: {Packets: 1}}, now)
+		Expect(status.Rules).To(BeEmpty())
+	})
+})
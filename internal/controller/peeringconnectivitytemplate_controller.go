@@ -0,0 +1,205 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/template"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+)
+
+// PeeringConnectivityTemplateReconciler reports in the status of the PeeringConnectivityTemplate
+// resources the peerings they match and whether they are applied to them. The templates are merged
+// into the PeeringConnectivity resources by the ForeignClusterReconciler.
+type PeeringConnectivityTemplateReconciler struct {
+	client.Client
+}
+
+// +kubebuilder:rbac:groups=connectivity.liqo.io,resources=peeringconnectivitytemplates,verbs=get;list;watch
+// +kubebuilder:rbac:groups=connectivity.liqo.io,resources=peeringconnectivitytemplates/status,verbs=get;update;patch
+
+// NewPeeringConnectivityTemplateReconciler creates a new PeeringConnectivityTemplateReconciler.
+func NewPeeringConnectivityTemplateReconciler(mgr ctrl.Manager) *PeeringConnectivityTemplateReconciler {
+	return &PeeringConnectivityTemplateReconciler{Client: mgr.GetClient()}
+}
+
+// Reconcile updates the status of the PeeringConnectivityTemplate with the peerings it matches.
+func (r *PeeringConnectivityTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	tpl := &connectivityv1.PeeringConnectivityTemplate{}
+	if err := r.Client.Get(ctx, req.NamespacedName, tpl); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	original := tpl.Status.DeepCopy()
+
+	if _, err := template.Matches(tpl, &liqov1beta1.ForeignCluster{}); err != nil {
+		tpl.Status.Peerings = nil
+		tpl.Status.MatchedPeerings = 0
+		meta.SetStatusCondition(&tpl.Status.Conditions, metav1.Condition{
+			Type:    utils.ConditionTypeReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  connectivityv1.TemplateReasonInvalidSelector,
+			Message: err.Error(),
+		})
+		return ctrl.Result{}, r.updateStatus(ctx, tpl, original)
+	}
+
+	var foreignClusters liqov1beta1.ForeignClusterList
+	if err := r.Client.List(ctx, &foreignClusters); err != nil {
+		return ctrl.Result{}, fmt.Errorf("unable to list the ForeignCluster resources: %w", err)
+	}
+	var templates connectivityv1.PeeringConnectivityTemplateList
+	if err := r.Client.List(ctx, &templates); err != nil {
+		return ctrl.Result{}, fmt.Errorf("unable to list the PeeringConnectivityTemplate resources: %w", err)
+	}
+
+	peerings := []connectivityv1.TemplatePeeringStatus{}
+	for i := range foreignClusters.Items {
+		foreignCluster := &foreignClusters.Items[i]
+		if matches, _ := template.Matches(tpl, foreignCluster); !matches {
+			continue
+		}
+
+		peering, err := r.peeringStatus(ctx, tpl, foreignCluster, templates.Items)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		peerings = append(peerings, *peering)
+	}
+	slices.SortFunc(peerings, func(a, b connectivityv1.TemplatePeeringStatus) int {
+		return strings.Compare(a.ClusterID, b.ClusterID)
+	})
+
+	tpl.Status.Peerings = peerings
+	tpl.Status.MatchedPeerings = int32(len(peerings))
+	meta.SetStatusCondition(&tpl.Status.Conditions, metav1.Condition{
+		Type:    utils.ConditionTypeReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  connectivityv1.TemplateReasonApplied,
+		Message: fmt.Sprintf("The template matches %d peerings", len(peerings)),
+	})
+	return ctrl.Result{}, r.updateStatus(ctx, tpl, original)
+}
+
+// peeringStatus returns the state of the template in the peering described by the ForeignCluster.
+func (r *PeeringConnectivityTemplateReconciler) peeringStatus(
+	ctx context.Context, tpl *connectivityv1.PeeringConnectivityTemplate, foreignCluster *liqov1beta1.ForeignCluster,
+	templates []connectivityv1.PeeringConnectivityTemplate,
+) (*connectivityv1.TemplatePeeringStatus, error) {
+	clusterID := string(foreignCluster.Spec.ClusterID)
+	peering := &connectivityv1.TemplatePeeringStatus{ClusterID: clusterID}
+
+	if !utils.IsPeeringEstablished(foreignCluster) {
+		peering.Reason = connectivityv1.TemplateReasonNotEstablished
+		peering.Message = "The networking of the peering has not been established yet"
+		return peering, nil
+	}
+
+	peering.Namespace = foreignCluster.Status.TenantNamespace.Local
+	peeringConnectivityList := &connectivityv1.PeeringConnectivityList{}
+	if err := r.Client.List(ctx, peeringConnectivityList, client.InNamespace(peering.Namespace)); err != nil {
+		return nil, fmt.Errorf("unable to list the PeeringConnectivity resources in %q: %w", peering.Namespace, err)
+	}
+
+	var managed *connectivityv1.PeeringConnectivity
+	for i := range peeringConnectivityList.Items {
+		if IsManagedPeeringConnectivity(&peeringConnectivityList.Items[i], clusterID) {
+			managed = &peeringConnectivityList.Items[i]
+		}
+	}
+
+	switch {
+	case managed != nil && slices.Contains(template.AppliedTemplates(managed.Annotations), tpl.Name):
+		peering.PeeringConnectivity = managed.Name
+		peering.Applied = true
+		peering.Reason = connectivityv1.TemplateReasonApplied
+		if conflicts := template.Merge(template.Select(templates, foreignCluster)).Conflicts[tpl.Name]; len(conflicts) > 0 {
+			peering.Reason = connectivityv1.TemplateReasonConflict
+			peering.Message = strings.Join(conflicts, "; ")
+		}
+	case managed == nil && len(peeringConnectivityList.Items) > 0:
+		peering.Reason = connectivityv1.TemplateReasonUserManaged
+		peering.Message = "The tenant namespace contains PeeringConnectivity resources not managed by the templates"
+	default:
+		peering.Reason = connectivityv1.TemplateReasonPending
+		peering.Message = "The PeeringConnectivity of the peering has not been updated yet"
+	}
+	return peering, nil
+}
+
+// updateStatus updates the status of the template, if changed.
+func (r *PeeringConnectivityTemplateReconciler) updateStatus(
+	ctx context.Context, tpl *connectivityv1.PeeringConnectivityTemplate, original *connectivityv1.PeeringConnectivityTemplateStatus,
+) error {
+	tpl.Status.ObservedGeneration = tpl.Generation
+	if equality.Semantic.DeepEqual(&tpl.Status, original) {
+		return nil
+	}
+
+	if err := r.Status().Update(ctx, tpl); err != nil {
+		log.FromContext(ctx).Error(err, "failed to update status")
+		return err
+	}
+	return nil
+}
+
+// allTemplateEnqueuer enqueues all the PeeringConnectivityTemplate resources, as any of them may
+// match the peering the changed object refers to.
+func (r *PeeringConnectivityTemplateReconciler) allTemplateEnqueuer(ctx context.Context, _ client.Object) []ctrl.Request {
+	var templates connectivityv1.PeeringConnectivityTemplateList
+	if err := r.Client.List(ctx, &templates); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list the PeeringConnectivityTemplate resources")
+		return nil
+	}
+
+	requests := make([]ctrl.Request, len(templates.Items))
+	for i := range templates.Items {
+		requests[i] = ctrl.Request{NamespacedName: types.NamespacedName{Name: templates.Items[i].Name}}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+// It watches the ForeignCluster and the PeeringConnectivity resources, ignoring the status updates of
+// the latter, since they determine the peerings the templates match and apply to, as well as the
+// other templates, whose priority determines the conflicts.
+func (r *PeeringConnectivityTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&connectivityv1.PeeringConnectivityTemplate{}).
+		Watches(&connectivityv1.PeeringConnectivityTemplate{}, handler.EnqueueRequestsFromMapFunc(r.allTemplateEnqueuer),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&liqov1beta1.ForeignCluster{}, handler.EnqueueRequestsFromMapFunc(r.allTemplateEnqueuer)).
+		Watches(&connectivityv1.PeeringConnectivity{}, handler.EnqueueRequestsFromMapFunc(r.allTemplateEnqueuer),
+			builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{},
+				predicate.LabelChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		Named("peeringconnectivitytemplate").
+		Complete(r)
+}
@@ -0,0 +1,179 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/template"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+)
+
+var _ = Describe("PeeringConnectivityTemplate Controller", func() {
+	Context("When reporting the peerings matched by a template", func() {
+		const (
+			clusterID    = "template-cluster"
+			templateName = "trusted"
+		)
+
+		var (
+			ctx        context.Context
+			namespace  string
+			reconciler *PeeringConnectivityTemplateReconciler
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			namespace = "liqo-tenant-" + clusterID
+			reconciler = &PeeringConnectivityTemplateReconciler{Client: k8sClient}
+
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+			if err := k8sClient.Create(ctx, ns); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			foreignCluster := &liqov1beta1.ForeignCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: clusterID, Labels: map[string]string{"tier": "trusted"}},
+				Spec:       liqov1beta1.ForeignClusterSpec{ClusterID: clusterID},
+			}
+			Expect(k8sClient.Create(ctx, foreignCluster)).To(Succeed())
+			foreignCluster.Status.Modules.Networking.Enabled = true
+			foreignCluster.Status.TenantNamespace.Local = namespace
+			Expect(k8sClient.Status().Update(ctx, foreignCluster)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, &liqov1beta1.ForeignCluster{ObjectMeta: metav1.ObjectMeta{Name: clusterID}})).To(Succeed())
+			Expect(k8sClient.DeleteAllOf(ctx, &connectivityv1.PeeringConnectivity{}, client.InNamespace(namespace))).To(Succeed())
+			Expect(k8sClient.DeleteAllOf(ctx, &connectivityv1.PeeringConnectivityTemplate{})).To(Succeed())
+		})
+
+		createTemplate := func(selector metav1.LabelSelector) {
+			Expect(k8sClient.Create(ctx, &connectivityv1.PeeringConnectivityTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: templateName},
+				Spec: connectivityv1.PeeringConnectivityTemplateSpec{
+					ClusterSelector: selector,
+					Template: connectivityv1.PeeringConnectivitySpec{
+						Gateway: &connectivityv1.GatewaySettings{BypassUplinkTraffic: ptr.To(true)},
+					},
+				},
+			})).To(Succeed())
+		}
+
+		reconcileTemplate := func() *connectivityv1.PeeringConnectivityTemplate {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: templateName}})
+			Expect(err).NotTo(HaveOccurred())
+
+			tpl := &connectivityv1.PeeringConnectivityTemplate{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: templateName}, tpl)).To(Succeed())
+			return tpl
+		}
+
+		It("should report the template as applied to the managed PeeringConnectivity", func() {
+			createTemplate(metav1.LabelSelector{MatchLabels: map[string]string{"tier": "trusted"}})
+			Expect(k8sClient.Create(ctx, &connectivityv1.PeeringConnectivity{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        clusterID,
+					Namespace:   namespace,
+					Labels:      map[string]string{AutoCreatedLabelKey: "true"},
+					Annotations: map[string]string{template.AppliedTemplatesAnnotationKey: templateName},
+				},
+			})).To(Succeed())
+
+			tpl := reconcileTemplate()
+			Expect(tpl.Status.MatchedPeerings).To(BeEquivalentTo(1))
+			Expect(tpl.Status.Peerings).To(ConsistOf(connectivityv1.TemplatePeeringStatus{
+				ClusterID:           clusterID,
+				Namespace:           namespace,
+				PeeringConnectivity: clusterID,
+				Applied:             true,
+				Reason:              connectivityv1.TemplateReasonApplied,
+			}))
+			Expect(meta.IsStatusConditionTrue(tpl.Status.Conditions, utils.ConditionTypeReady)).To(BeTrue())
+		})
+
+		It("should report the conflicts with a template with a higher priority", func() {
+			createTemplate(metav1.LabelSelector{})
+			Expect(k8sClient.Create(ctx, &connectivityv1.PeeringConnectivityTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "strict"},
+				Spec: connectivityv1.PeeringConnectivityTemplateSpec{
+					Priority: 10,
+					Template: connectivityv1.PeeringConnectivitySpec{
+						Gateway: &connectivityv1.GatewaySettings{BypassUplinkTraffic: ptr.To(false)},
+					},
+				},
+			})).To(Succeed())
+			Expect(k8sClient.Create(ctx, &connectivityv1.PeeringConnectivity{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        clusterID,
+					Namespace:   namespace,
+					Labels:      map[string]string{AutoCreatedLabelKey: "true"},
+					Annotations: map[string]string{template.AppliedTemplatesAnnotationKey: "strict," + templateName},
+				},
+			})).To(Succeed())
+
+			tpl := reconcileTemplate()
+			Expect(tpl.Status.Peerings).To(HaveLen(1))
+			Expect(tpl.Status.Peerings[0].Applied).To(BeTrue())
+			Expect(tpl.Status.Peerings[0].Reason).To(Equal(connectivityv1.TemplateReasonConflict))
+			Expect(tpl.Status.Peerings[0].Message).To(ContainSubstring("strict"))
+		})
+
+		It("should report the namespaces with user-managed PeeringConnectivity resources", func() {
+			createTemplate(metav1.LabelSelector{})
+			Expect(k8sClient.Create(ctx, &connectivityv1.PeeringConnectivity{
+				ObjectMeta: metav1.ObjectMeta{Name: "custom", Namespace: namespace},
+			})).To(Succeed())
+
+			tpl := reconcileTemplate()
+			Expect(tpl.Status.Peerings).To(HaveLen(1))
+			Expect(tpl.Status.Peerings[0].Applied).To(BeFalse())
+			Expect(tpl.Status.Peerings[0].Reason).To(Equal(connectivityv1.TemplateReasonUserManaged))
+		})
+
+		It("should not report the peerings not matched by the selector", func() {
+			createTemplate(metav1.LabelSelector{MatchLabels: map[string]string{"tier": "public"}})
+
+			tpl := reconcileTemplate()
+			Expect(tpl.Status.MatchedPeerings).To(BeZero())
+			Expect(tpl.Status.Peerings).To(BeEmpty())
+		})
+
+		It("should report an invalid selector", func() {
+			createTemplate(metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "tier", Operator: metav1.LabelSelectorOpIn},
+			}})
+
+			tpl := reconcileTemplate()
+			condition := meta.FindStatusCondition(tpl.Status.Conditions, utils.ConditionTypeReady)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(connectivityv1.TemplateReasonInvalidSelector))
+		})
+	})
+})
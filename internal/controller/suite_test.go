@@ -44,6 +44,8 @@ var (
 	cfg       *rest.Config
 	k8sClient client.Client
 	k8sMgr    ctrl.Manager
+	// indexedK8sClient is the client used by the reconcilers under test, which relies on the field indexes.
+	indexedK8sClient client.Client
 )
 
 func TestControllers(t *testing.T) {
@@ -90,8 +92,32 @@ var _ = BeforeSuite(func() {
 		Scheme: scheme.Scheme,
 	})
 	Expect(err).NotTo(HaveOccurred())
+
+	Expect(utils.SetupIdentityIndexes(ctx, k8sMgr.GetFieldIndexer())).To(Succeed())
+	indexedK8sClient = &indexedClient{Client: k8sClient, cache: k8sMgr.GetCache()}
+
+	go func() {
+		defer GinkgoRecover()
+		Expect(k8sMgr.Start(ctx)).To(Succeed())
+	}()
 })
 
+// indexedClient serves the List requests with field selectors from the cache of the manager,
+// which maintains the field indexes, and all the other requests from the API server, so that
+// the tests observe their own writes immediately.
+type indexedClient struct {
+	client.Client
+	cache client.Reader
+}
+
+func (c *indexedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := (&client.ListOptions{}).ApplyOptions(opts)
+	if listOpts.FieldSelector != nil && !listOpts.FieldSelector.Empty() {
+		return c.cache.List(ctx, list, opts...)
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
 var _ = AfterSuite(func() {
 	By("tearing down the test environment")
 	cancel()
@@ -0,0 +1,125 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+)
+
+// AppliedTemplatesAnnotationKey lists, comma-separated, the templates merged into a PeeringConnectivity.
+const AppliedTemplatesAnnotationKey = "connectivity.liqo.io/templates"
+
+// Result is the outcome of the merge of the templates matching a peering.
+type Result struct {
+	// Spec is the merged PeeringConnectivity spec.
+	Spec connectivityv1.PeeringConnectivitySpec
+	// Templates are the names of the merged templates, by decreasing priority.
+	Templates []string
+	// Conflicts maps the names of the templates to their gateway settings overridden by a
+	// template with a higher priority.
+	Conflicts map[string][]string
+}
+
+// Matches returns whether the template applies to the peering described by the ForeignCluster.
+// It returns an error if the cluster selector of the template is invalid.
+func Matches(tpl *connectivityv1.PeeringConnectivityTemplate, fc *liqov1beta1.ForeignCluster) (bool, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&tpl.Spec.ClusterSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid cluster selector of template %q: %w", tpl.Name, err)
+	}
+	if !selector.Matches(labels.Set(fc.Labels)) {
+		return false, nil
+	}
+	if len(tpl.Spec.Roles) == 0 {
+		return true, nil
+	}
+
+	for _, role := range tpl.Spec.Roles {
+		switch role {
+		case connectivityv1.PeeringRoleConsumer:
+			if fc.Status.Role == liqov1beta1.ConsumerRole || fc.Status.Role == liqov1beta1.ConsumerAndProviderRole {
+				return true, nil
+			}
+		case connectivityv1.PeeringRoleProvider:
+			if fc.Status.Role == liqov1beta1.ProviderRole || fc.Status.Role == liqov1beta1.ConsumerAndProviderRole {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Select returns the templates applying to the peering described by the ForeignCluster, sorted by
+// decreasing priority and then by name. The templates with an invalid cluster selector are skipped.
+func Select(
+	templates []connectivityv1.PeeringConnectivityTemplate, fc *liqov1beta1.ForeignCluster,
+) []*connectivityv1.PeeringConnectivityTemplate {
+	var selected []*connectivityv1.PeeringConnectivityTemplate
+	for i := range templates {
+		if !templates[i].DeletionTimestamp.IsZero() {
+			continue
+		}
+		if matches, err := Matches(&templates[i], fc); err == nil && matches {
+			selected = append(selected, &templates[i])
+		}
+	}
+
+	slices.SortFunc(selected, func(a, b *connectivityv1.PeeringConnectivityTemplate) int {
+		if a.Spec.Priority != b.Spec.Priority {
+			return int(b.Spec.Priority) - int(a.Spec.Priority)
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return selected
+}
+
+// Merge merges the given templates, sorted by decreasing priority, into a single spec.
+// The rules are concatenated, so that the ones of the templates with a higher priority are evaluated
+// first, and each gateway setting is taken from the first template setting it. The lower-priority
+// templates setting it to a different value are reported as conflicting.
+func Merge(templates []*connectivityv1.PeeringConnectivityTemplate) *Result {
+	specs := make([]utils.NamedSpec, len(templates))
+	names := make([]string, len(templates))
+	for i, tpl := range templates {
+		specs[i] = utils.NamedSpec{Name: tpl.Name, Spec: &tpl.Spec.Template}
+		names[i] = tpl.Name
+	}
+
+	spec, conflicts := utils.MergeSpecs(specs)
+	return &Result{Spec: *spec, Templates: names, Conflicts: conflicts}
+}
+
+// AppliedTemplates returns the names of the templates merged into the given annotations.
+func AppliedTemplates(annotations map[string]string) []string {
+	value := annotations[AppliedTemplatesAnnotationKey]
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// FormatAppliedTemplates returns the annotation value listing the given templates.
+func FormatAppliedTemplates(templates []string) string {
+	return strings.Join(templates, ",")
+}
@@ -0,0 +1,167 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import (
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+)
+
+var _ = Describe("Templates", func() {
+	newForeignCluster := func(role liqov1beta1.RoleType, labels map[string]string) *liqov1beta1.ForeignCluster {
+		return &liqov1beta1.ForeignCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-a", Labels: labels},
+			Status:     liqov1beta1.ForeignClusterStatus{Role: role},
+		}
+	}
+
+	newTemplate := func(name string, priority int32, selector map[string]string,
+		roles ...connectivityv1.PeeringRole) connectivityv1.PeeringConnectivityTemplate {
+		return connectivityv1.PeeringConnectivityTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: connectivityv1.PeeringConnectivityTemplateSpec{
+				ClusterSelector: metav1.LabelSelector{MatchLabels: selector},
+				Roles:           roles,
+				Priority:        priority,
+			},
+		}
+	}
+
+	Describe("Matches", func() {
+		It("should match the ForeignCluster labels", func() {
+			tpl := newTemplate("trusted", 0, map[string]string{"tier": "trusted"})
+
+			Expect(Matches(&tpl, newForeignCluster(liqov1beta1.ProviderRole, map[string]string{"tier": "trusted"}))).To(BeTrue())
+			Expect(Matches(&tpl, newForeignCluster(liqov1beta1.ProviderRole, map[string]string{"tier": "public"}))).To(BeFalse())
+		})
+
+		It("should match all the peerings with an empty selector", func() {
+			tpl := newTemplate("all", 0, nil)
+			Expect(Matches(&tpl, newForeignCluster(liqov1beta1.UnknownRole, nil))).To(BeTrue())
+		})
+
+		DescribeTable("should match the peering roles",
+			func(role liqov1beta1.RoleType, templateRole connectivityv1.PeeringRole, expected bool) {
+				tpl := newTemplate("roles", 0, nil, templateRole)
+				Expect(Matches(&tpl, newForeignCluster(role, nil))).To(Equal(expected))
+			},
+			Entry("consumer as consumer", liqov1beta1.ConsumerRole, connectivityv1.PeeringRoleConsumer, true),
+			Entry("consumer as provider", liqov1beta1.ConsumerRole, connectivityv1.PeeringRoleProvider, false),
+			Entry("provider as provider", liqov1beta1.ProviderRole, connectivityv1.PeeringRoleProvider, true),
+			Entry("provider as consumer", liqov1beta1.ProviderRole, connectivityv1.PeeringRoleConsumer, false),
+			Entry("bidirectional as consumer", liqov1beta1.ConsumerAndProviderRole, connectivityv1.PeeringRoleConsumer, true),
+			Entry("bidirectional as provider", liqov1beta1.ConsumerAndProviderRole, connectivityv1.PeeringRoleProvider, true),
+			Entry("unknown", liqov1beta1.UnknownRole, connectivityv1.PeeringRoleConsumer, false),
+		)
+
+		It("should return an error with an invalid selector", func() {
+			tpl := newTemplate("invalid", 0, nil)
+			tpl.Spec.ClusterSelector.MatchExpressions = []metav1.LabelSelectorRequirement{{Key: "tier", Operator: "Unknown"}}
+
+			_, err := Matches(&tpl, newForeignCluster(liqov1beta1.ProviderRole, nil))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Select", func() {
+		It("should return the matching templates by decreasing priority and name", func() {
+			invalid := newTemplate("invalid", 100, nil)
+			invalid.Spec.ClusterSelector.MatchExpressions = []metav1.LabelSelectorRequirement{{Key: "tier", Operator: "Unknown"}}
+
+			templates := []connectivityv1.PeeringConnectivityTemplate{
+				newTemplate("b-base", 0, nil),
+				newTemplate("a-base", 0, nil),
+				newTemplate("trusted", 10, map[string]string{"tier": "trusted"}),
+				newTemplate("public", 20, map[string]string{"tier": "public"}),
+				invalid,
+			}
+
+			selected := Select(templates, newForeignCluster(liqov1beta1.ProviderRole, map[string]string{"tier": "trusted"}))
+			names := make([]string, len(selected))
+			for i := range selected {
+				names[i] = selected[i].Name
+			}
+			Expect(names).To(Equal([]string{"trusted", "a-base", "b-base"}))
+		})
+	})
+
+	Describe("Merge", func() {
+		allow := func(group connectivityv1.ResourceGroup) connectivityv1.Rule {
+			return connectivityv1.Rule{
+				Action:      connectivityv1.ActionAllow,
+				Destination: &connectivityv1.Party{Group: ptr.To(group)},
+			}
+		}
+
+		It("should concatenate the rules by priority", func() {
+			high := newTemplate("high", 10, nil)
+			high.Spec.Template.Rules = []connectivityv1.Rule{allow(connectivityv1.ResourceGroupLocalCluster)}
+			low := newTemplate("low", 0, nil)
+			low.Spec.Template.Rules = []connectivityv1.Rule{allow(connectivityv1.ResourceGroupInternet)}
+
+			result := Merge([]*connectivityv1.PeeringConnectivityTemplate{&high, &low})
+			Expect(result.Templates).To(Equal([]string{"high", "low"}))
+			Expect(result.Spec.Rules).To(Equal([]connectivityv1.Rule{
+				allow(connectivityv1.ResourceGroupLocalCluster),
+				allow(connectivityv1.ResourceGroupInternet),
+			}))
+			Expect(result.Spec.Gateway).To(BeNil())
+			Expect(result.Conflicts).To(BeEmpty())
+		})
+
+		It("should take each gateway setting from the template with the highest priority", func() {
+			high := newTemplate("high", 10, nil)
+			high.Spec.Template.Gateway = &connectivityv1.GatewaySettings{BypassUplinkTraffic: ptr.To(false)}
+			low := newTemplate("low", 0, nil)
+			low.Spec.Template.Gateway = &connectivityv1.GatewaySettings{
+				BypassUplinkTraffic:    ptr.To(true),
+				BypassNonTunnelTraffic: ptr.To(false),
+				UplinkInterfaces:       []connectivityv1.InterfaceName{"eth1"},
+			}
+
+			result := Merge([]*connectivityv1.PeeringConnectivityTemplate{&high, &low})
+			Expect(result.Spec.Gateway).To(Equal(&connectivityv1.GatewaySettings{
+				BypassUplinkTraffic:    ptr.To(false),
+				BypassNonTunnelTraffic: ptr.To(false),
+				UplinkInterfaces:       []connectivityv1.InterfaceName{"eth1"},
+			}))
+			Expect(result.Conflicts).To(HaveKey("low"))
+			Expect(result.Conflicts["low"]).To(ConsistOf(ContainSubstring("bypassUplinkTraffic")))
+			Expect(result.Conflicts).NotTo(HaveKey("high"))
+		})
+
+		It("should not report the same value set by several templates as a conflict", func() {
+			high := newTemplate("high", 10, nil)
+			high.Spec.Template.Gateway = &connectivityv1.GatewaySettings{UplinkInterfaces: []connectivityv1.InterfaceName{"eth0"}}
+			low := newTemplate("low", 0, nil)
+			low.Spec.Template.Gateway = &connectivityv1.GatewaySettings{UplinkInterfaces: []connectivityv1.InterfaceName{"eth0"}}
+
+			Expect(Merge([]*connectivityv1.PeeringConnectivityTemplate{&high, &low}).Conflicts).To(BeEmpty())
+		})
+	})
+
+	Describe("AppliedTemplates", func() {
+		It("should round-trip the annotation", func() {
+			annotations := map[string]string{AppliedTemplatesAnnotationKey: FormatAppliedTemplates([]string{"a", "b"})}
+			Expect(AppliedTemplates(annotations)).To(Equal([]string{"a", "b"}))
+			Expect(AppliedTemplates(nil)).To(BeEmpty())
+		})
+	})
+})
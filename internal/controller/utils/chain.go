@@ -0,0 +1,226 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+)
+
+// NamedSpec is a PeeringConnectivity spec along with the name of the object defining it.
+type NamedSpec struct {
+	Name string
+	Spec *connectivityv1.PeeringConnectivitySpec
+}
+
+// MergeSpecs merges the given specs, sorted by decreasing priority, into a single spec.
+// The rules are concatenated, so that the ones of the specs with a higher priority are evaluated
+// first, and each gateway setting is taken from the first spec setting it. The lower-priority specs
+// setting it to a different value are reported as conflicting, keyed by name.
+// The priority of the merged spec is left unset.
+func MergeSpecs(specs []NamedSpec) (*connectivityv1.PeeringConnectivitySpec, map[string][]string) {
+	merged := &connectivityv1.PeeringConnectivitySpec{}
+	conflicts := map[string][]string{}
+
+	// owners tracks the spec each gateway setting was taken from.
+	owners := map[string]string{}
+	gateway := &connectivityv1.GatewaySettings{}
+	conntrack := &connectivityv1.ConntrackSettings{}
+	conflict := func(name, setting string) {
+		conflicts[name] = append(conflicts[name], fmt.Sprintf("gateway setting %s is overridden by %q", setting, owners[setting]))
+	}
+
+	for _, named := range specs {
+		for i := range named.Spec.Rules {
+			merged.Rules = append(merged.Rules, *named.Spec.Rules[i].DeepCopy())
+		}
+
+		settings := named.Spec.Gateway
+		if settings == nil {
+			continue
+		}
+
+		if settings.BypassNonTunnelTraffic != nil {
+			if gateway.BypassNonTunnelTraffic == nil {
+				gateway.BypassNonTunnelTraffic = ptr.To(*settings.BypassNonTunnelTraffic)
+				owners["bypassNonTunnelTraffic"] = named.Name
+			} else if *gateway.BypassNonTunnelTraffic != *settings.BypassNonTunnelTraffic {
+				conflict(named.Name, "bypassNonTunnelTraffic")
+			}
+		}
+		if settings.BypassUplinkTraffic != nil {
+			if gateway.BypassUplinkTraffic == nil {
+				gateway.BypassUplinkTraffic = ptr.To(*settings.BypassUplinkTraffic)
+				owners["bypassUplinkTraffic"] = named.Name
+			} else if *gateway.BypassUplinkTraffic != *settings.BypassUplinkTraffic {
+				conflict(named.Name, "bypassUplinkTraffic")
+			}
+		}
+		if len(settings.UplinkInterfaces) > 0 {
+			if len(gateway.UplinkInterfaces) == 0 {
+				gateway.UplinkInterfaces = slices.Clone(settings.UplinkInterfaces)
+				owners["uplinkInterfaces"] = named.Name
+			} else if !slices.Equal(gateway.UplinkInterfaces, settings.UplinkInterfaces) {
+				conflict(named.Name, "uplinkInterfaces")
+			}
+		}
+
+		if settings.Conntrack != nil {
+			mergeConntrackSettings(conntrack, settings.Conntrack, named.Name, owners, conflict)
+		}
+	}
+
+	if *conntrack != (connectivityv1.ConntrackSettings{}) {
+		gateway.Conntrack = conntrack
+	}
+	if gateway.BypassNonTunnelTraffic != nil || gateway.BypassUplinkTraffic != nil || len(gateway.UplinkInterfaces) > 0 ||
+		gateway.Conntrack != nil {
+		merged.Gateway = gateway
+	}
+	return merged, conflicts
+}
+
+// mergeConntrackSettings merges each of the given connection tracking settings into the merged ones, unless
+// already taken from a spec of higher priority, in which case a differing value is reported as a conflict.
+func mergeConntrackSettings(
+	merged, settings *connectivityv1.ConntrackSettings, name string,
+	owners map[string]string, conflict func(name, setting string),
+) {
+	if settings.Mode != "" {
+		if merged.Mode == "" {
+			merged.Mode = settings.Mode
+			owners["conntrack.mode"] = name
+		} else if merged.Mode != settings.Mode {
+			conflict(name, "conntrack.mode")
+		}
+	}
+	if settings.Related != nil {
+		if merged.Related == nil {
+			merged.Related = ptr.To(*settings.Related)
+			owners["conntrack.related"] = name
+		} else if *merged.Related != *settings.Related {
+			conflict(name, "conntrack.related")
+		}
+	}
+	if settings.DropInvalid != nil {
+		if merged.DropInvalid == nil {
+			merged.DropInvalid = ptr.To(*settings.DropInvalid)
+			owners["conntrack.dropInvalid"] = name
+		} else if *merged.DropInvalid != *settings.DropInvalid {
+			conflict(name, "conntrack.dropInvalid")
+		}
+	}
+	if settings.ExistingConnections != "" {
+		if merged.ExistingConnections == "" {
+			merged.ExistingConnections = settings.ExistingConnections
+			owners["conntrack.existingConnections"] = name
+		} else if merged.ExistingConnections != settings.ExistingConnections {
+			conflict(name, "conntrack.existingConnections")
+		}
+	}
+}
+
+// PeeringConnectivityChain is the effective policy of a tenant namespace, obtained by merging its
+// PeeringConnectivity resources by decreasing priority.
+type PeeringConnectivityChain struct {
+	// Effective is the PeeringConnectivity with the merged spec, which the resources are forged from.
+	Effective *connectivityv1.PeeringConnectivity
+	// Members are the merged PeeringConnectivity resources, in chain order.
+	Members []*connectivityv1.PeeringConnectivity
+	// Placements maps the names of the members to the position of their rules in the chain.
+	Placements map[string]*connectivityv1.ChainPlacement
+	// Restrictions are the further restrictions delegated to the application namespaces
+	// through NamespacePeeringPolicy resources, enforced after the chain.
+	Restrictions []NamespaceRestriction
+}
+
+// GetPeeringConnectivityChain returns the chain of the PeeringConnectivity resources in the given
+// namespace, excluding the ones being deleted. The current PeeringConnectivity, if not nil, replaces
+// the listed copy, which may be stale. It returns nil if there are no PeeringConnectivity resources.
+func GetPeeringConnectivityChain(
+	ctx context.Context, cl client.Client, namespace string, current *connectivityv1.PeeringConnectivity,
+) (*PeeringConnectivityChain, error) {
+	var peeringConnectivityList connectivityv1.PeeringConnectivityList
+	if err := cl.List(ctx, &peeringConnectivityList, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("unable to list the PeeringConnectivity resources in %q: %w", namespace, err)
+	}
+
+	var members []*connectivityv1.PeeringConnectivity
+	if current != nil && current.DeletionTimestamp.IsZero() {
+		members = append(members, current)
+	}
+	for i := range peeringConnectivityList.Items {
+		pc := &peeringConnectivityList.Items[i]
+		if pc.DeletionTimestamp.IsZero() && (current == nil || pc.Name != current.Name) {
+			members = append(members, pc)
+		}
+	}
+	return NewPeeringConnectivityChain(members), nil
+}
+
+// NewPeeringConnectivityChain merges the given PeeringConnectivity resources of the same namespace
+// into a chain, ordering them by decreasing priority and then by name. It returns nil if there are none.
+func NewPeeringConnectivityChain(members []*connectivityv1.PeeringConnectivity) *PeeringConnectivityChain {
+	if len(members) == 0 {
+		return nil
+	}
+
+	members = slices.Clone(members)
+	slices.SortFunc(members, func(a, b *connectivityv1.PeeringConnectivity) int {
+		if a.Spec.Priority != b.Spec.Priority {
+			return int(b.Spec.Priority) - int(a.Spec.Priority)
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	names := make([]string, len(members))
+	specs := make([]NamedSpec, len(members))
+	for i, member := range members {
+		names[i] = member.Name
+		specs[i] = NamedSpec{Name: member.Name, Spec: &member.Spec}
+	}
+	spec, conflicts := MergeSpecs(specs)
+
+	placements := make(map[string]*connectivityv1.ChainPlacement, len(members))
+	firstRule := 0
+	for i, member := range members {
+		placements[member.Name] = &connectivityv1.ChainPlacement{
+			Position:    int32(i),
+			FirstRule:   int32(firstRule),
+			Rules:       int32(len(member.Spec.Rules)),
+			ChainLength: int32(len(spec.Rules)),
+			Chain:       names,
+			Conflicts:   conflicts[member.Name],
+		}
+		firstRule += len(member.Spec.Rules)
+	}
+
+	// The effective PeeringConnectivity takes the identity of the first member of the chain.
+	effective := members[0].DeepCopy()
+	effective.Spec = *spec
+
+	return &PeeringConnectivityChain{
+		Effective:  effective,
+		Members:    members,
+		Placements: placements,
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+)
+
+var _ = Describe("Chain Utilities", func() {
+	const namespace = "liqo-tenant-cluster-a"
+
+	allow := func(group connectivityv1.ResourceGroup) connectivityv1.Rule {
+		return connectivityv1.Rule{
+			Action:      connectivityv1.ActionAllow,
+			Destination: &connectivityv1.Party{Group: ptr.To(group)},
+		}
+	}
+
+	newPeeringConnectivity := func(name string, priority int32, rules ...connectivityv1.Rule) *connectivityv1.PeeringConnectivity {
+		return &connectivityv1.PeeringConnectivity{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       connectivityv1.PeeringConnectivitySpec{Priority: priority, Rules: rules},
+		}
+	}
+
+	Describe("MergeSpecs", func() {
+		It("should concatenate the rules in order", func() {
+			spec, conflicts := MergeSpecs([]NamedSpec{
+				{Name: "high", Spec: &connectivityv1.PeeringConnectivitySpec{Rules: []connectivityv1.Rule{allow(connectivityv1.ResourceGroupLocalCluster)}}},
+				{Name: "low", Spec: &connectivityv1.PeeringConnectivitySpec{Rules: []connectivityv1.Rule{allow(connectivityv1.ResourceGroupInternet)}}},
+			})
+			Expect(spec.Rules).To(Equal([]connectivityv1.Rule{
+				allow(connectivityv1.ResourceGroupLocalCluster),
+				allow(connectivityv1.ResourceGroupInternet),
+			}))
+			Expect(spec.Gateway).To(BeNil())
+			Expect(conflicts).To(BeEmpty())
+		})
+
+		It("should take each gateway setting from the first spec setting it", func() {
+			spec, conflicts := MergeSpecs([]NamedSpec{
+				{Name: "high", Spec: &connectivityv1.PeeringConnectivitySpec{
+					Gateway: &connectivityv1.GatewaySettings{BypassNonTunnelTraffic: ptr.To(false)},
+				}},
+				{Name: "low", Spec: &connectivityv1.PeeringConnectivitySpec{
+					Gateway: &connectivityv1.GatewaySettings{
+						BypassNonTunnelTraffic: ptr.To(true),
+						UplinkInterfaces:       []connectivityv1.InterfaceName{"eth1"},
+					},
+				}},
+			})
+			Expect(spec.Gateway).To(Equal(&connectivityv1.GatewaySettings{
+				BypassNonTunnelTraffic: ptr.To(false),
+				UplinkInterfaces:       []connectivityv1.InterfaceName{"eth1"},
+			}))
+			Expect(conflicts).To(HaveKeyWithValue("low", ConsistOf(And(
+				ContainSubstring("bypassNonTunnelTraffic"), ContainSubstring(`"high"`)))))
+		})
+
+		It("should take each conntrack setting from the first spec setting it", func() {
+			spec, conflicts := MergeSpecs([]NamedSpec{
+				{Name: "high", Spec: &connectivityv1.PeeringConnectivitySpec{
+					Gateway: &connectivityv1.GatewaySettings{Conntrack: &connectivityv1.ConntrackSettings{
+						Mode:        connectivityv1.ConntrackModeStateful,
+						DropInvalid: ptr.To(true),
+					}},
+				}},
+				{Name: "low", Spec: &connectivityv1.PeeringConnectivitySpec{
+					Gateway: &connectivityv1.GatewaySettings{Conntrack: &connectivityv1.ConntrackSettings{
+						Mode:                connectivityv1.ConntrackModeStateful,
+						DropInvalid:         ptr.To(false),
+						Related:             ptr.To(false),
+						ExistingConnections: connectivityv1.ExistingConnectionsReevaluate,
+					}},
+				}},
+			})
+			Expect(spec.Gateway).To(Equal(&connectivityv1.GatewaySettings{Conntrack: &connectivityv1.ConntrackSettings{
+				Mode:                connectivityv1.ConntrackModeStateful,
+				DropInvalid:         ptr.To(true),
+				Related:             ptr.To(false),
+				ExistingConnections: connectivityv1.ExistingConnectionsReevaluate,
+			}}))
+			Expect(conflicts).To(HaveKeyWithValue("low", ConsistOf(And(
+				ContainSubstring("conntrack.dropInvalid"), ContainSubstring(`"high"`)))))
+		})
+	})
+
+	Describe("NewPeeringConnectivityChain", func() {
+		It("should return nil without PeeringConnectivity resources", func() {
+			Expect(NewPeeringConnectivityChain(nil)).To(BeNil())
+		})
+
+		It("should order the members by decreasing priority and name", func() {
+			chain := NewPeeringConnectivityChain([]*connectivityv1.PeeringConnectivity{
+				newPeeringConnectivity("b-app", 0, allow(connectivityv1.ResourceGroupInternet)),
+				newPeeringConnectivity("a-app", 0, allow(connectivityv1.ResourceGroupAnyDNS), allow(connectivityv1.ResourceGroupNameserver)),
+				newPeeringConnectivity("platform", 100, allow(connectivityv1.ResourceGroupLocalCluster)),
+			})
+
+			Expect(chain.Effective.Spec.Rules).To(Equal([]connectivityv1.Rule{
+				allow(connectivityv1.ResourceGroupLocalCluster),
+				allow(connectivityv1.ResourceGroupAnyDNS),
+				allow(connectivityv1.ResourceGroupNameserver),
+				allow(connectivityv1.ResourceGroupInternet),
+			}))
+			Expect(chain.Effective.Namespace).To(Equal(namespace))
+			Expect(chain.Members).To(HaveLen(3))
+			Expect(chain.Members[0].Name).To(Equal("platform"))
+
+			chainNames := []string{"platform", "a-app", "b-app"}
+			Expect(chain.Placements).To(HaveKeyWithValue("platform", &connectivityv1.ChainPlacement{
+				Position: 0, FirstRule: 0, Rules: 1, ChainLength: 4, Chain: chainNames,
+			}))
+			Expect(chain.Placements).To(HaveKeyWithValue("a-app", &connectivityv1.ChainPlacement{
+				Position: 1, FirstRule: 1, Rules: 2, ChainLength: 4, Chain: chainNames,
+			}))
+			Expect(chain.Placements).To(HaveKeyWithValue("b-app", &connectivityv1.ChainPlacement{
+				Position: 2, FirstRule: 3, Rules: 1, ChainLength: 4, Chain: chainNames,
+			}))
+		})
+
+		It("should not modify the members", func() {
+			member := newPeeringConnectivity("app", 0, allow(connectivityv1.ResourceGroupInternet))
+			chain := NewPeeringConnectivityChain([]*connectivityv1.PeeringConnectivity{member})
+
+			chain.Effective.Spec.Rules = nil
+			Expect(member.Spec.Rules).To(HaveLen(1))
+		})
+	})
+
+	Describe("GetPeeringConnectivityChain", func() {
+		var (
+			ctx    context.Context
+			scheme *runtime.Scheme
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			scheme = runtime.NewScheme()
+			RegisterScheme(scheme)
+		})
+
+		It("should exclude the PeeringConnectivity resources being deleted", func() {
+			deleting := newPeeringConnectivity("deleting", 0)
+			deleting.DeletionTimestamp = ptr.To(metav1.Now())
+			deleting.Finalizers = []string{"test"}
+
+			cl := newFakeClientBuilder(scheme).WithObjects(
+				newPeeringConnectivity("app", 0),
+				deleting,
+			).Build()
+
+			chain, err := GetPeeringConnectivityChain(ctx, cl, namespace, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(chain.Members).To(HaveLen(1))
+			Expect(chain.Members[0].Name).To(Equal("app"))
+		})
+
+		It("should return nil if no PeeringConnectivity remains", func() {
+			cl := newFakeClientBuilder(scheme).Build()
+
+			chain, err := GetPeeringConnectivityChain(ctx, cl, namespace, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(chain).To(BeNil())
+		})
+
+		It("should replace the listed copy with the current PeeringConnectivity", func() {
+			cl := newFakeClientBuilder(scheme).WithObjects(newPeeringConnectivity("app", 0)).Build()
+			current := newPeeringConnectivity("app", 0, allow(connectivityv1.ResourceGroupInternet))
+
+			chain, err := GetPeeringConnectivityChain(ctx, cl, namespace, current)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(chain.Members).To(ConsistOf(current))
+			Expect(chain.Effective.Spec.Rules).To(HaveLen(1))
+		})
+	})
+})
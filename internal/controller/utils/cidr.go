@@ -17,28 +17,23 @@ package utils
 import (
 	"context"
 	"fmt"
+	"net/netip"
+	"slices"
 
 	ipamv1alpha1 "github.com/liqotech/liqo/apis/ipam/v1alpha1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-)
-
-const (
-	// localPodCIDRNetworkName is the name of the Network resource that contains
-	// the local cluster's pod CIDR information.
-	localPodCIDRNetworkName = "pod-cidr"
 
-	// localPodCIDRNetworkNamespace is the namespace where the local pod CIDR Network resource is stored.
-	localPodCIDRNetworkNamespace = "liqo"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 )
 
 // GetCurrentClusterPodCIDR retrieves the pod CIDR for the current (local) cluster.
-// It reads the Network resource in the liqo namespace to obtain the CIDR.
-func GetCurrentClusterPodCIDR(ctx context.Context, cl client.Client) (string, error) {
+// It reads the pod CIDR Network resource in the Liqo namespace to obtain the CIDR.
+func GetCurrentClusterPodCIDR(ctx context.Context, cl client.Client, liqoOpts *options.LiqoOptions) (string, error) {
 	var network ipamv1alpha1.Network
 
 	if err := cl.Get(ctx, client.ObjectKey{
-		Namespace: localPodCIDRNetworkNamespace,
-		Name:      localPodCIDRNetworkName,
+		Namespace: liqoOpts.Namespace,
+		Name:      liqoOpts.PodCIDRNetworkName,
 	}, &network); err != nil {
 		return "", err
 	}
@@ -46,14 +41,14 @@ func GetCurrentClusterPodCIDR(ctx context.Context, cl client.Client) (string, er
 	return string(network.Status.CIDR), nil
 }
 
-// GetRemoteClusterPodCIDR retrieves the pod CIDR for a remote peered cluster.
-// It reads the Network resource in the tenant namespace for the specified cluster ID.
-func GetRemoteClusterPodCIDR(ctx context.Context, cl client.Client, clusterID string) (string, error) {
+// GetCurrentClusterServiceCIDR retrieves the service CIDR for the current (local) cluster.
+// It reads the service CIDR Network resource in the Liqo namespace to obtain the CIDR.
+func GetCurrentClusterServiceCIDR(ctx context.Context, cl client.Client, liqoOpts *options.LiqoOptions) (string, error) {
 	var network ipamv1alpha1.Network
 
 	if err := cl.Get(ctx, client.ObjectKey{
-		Namespace: GetClusterNamespace(clusterID),
-		Name:      fmt.Sprintf("%s-pod", clusterID),
+		Namespace: liqoOpts.Namespace,
+		Name:      liqoOpts.ServiceCIDRNetworkName,
 	}, &network); err != nil {
 		return "", err
 	}
@@ -61,17 +56,94 @@ func GetRemoteClusterPodCIDR(ctx context.Context, cl client.Client, clusterID st
 	return string(network.Status.CIDR), nil
 }
 
-// GetRemoteClusterExternalCIDR retrieves the external CIDR for the remote cluster.
-// It reads the Network resource in the liqo namespace to obtain the CIDR.
-func GetRemoteClusterExternalCIDR(ctx context.Context, cl client.Client, clusterID string) (string, error) {
+// GetRemoteClusterPodCIDR retrieves the pod CIDR for a remote peered cluster.
+// It reads the Network resource in the tenant namespace for the specified cluster ID,
+// returning the CIDR remapped by Liqo, which is the one carried by the packets in the local cluster.
+func GetRemoteClusterPodCIDR(ctx context.Context, cl client.Client, liqoOpts *options.LiqoOptions, clusterID string) (string, error) {
+	namespace, err := GetTenantNamespace(ctx, cl, clusterID)
+	if err != nil {
+		return "", err
+	}
+
 	var network ipamv1alpha1.Network
+	if err := cl.Get(ctx, client.ObjectKey{
+		Namespace: namespace,
+		Name:      liqoOpts.RemotePodCIDRNetworkName(clusterID),
+	}, &network); err != nil {
+		return "", err
+	}
+
+	return getNetworkStatusCIDR(&network)
+}
+
+// GetRemoteClusterExternalCIDR retrieves the external CIDR for the remote cluster.
+// It reads the Network resource in the tenant namespace for the specified cluster ID,
+// returning the CIDR remapped by Liqo.
+func GetRemoteClusterExternalCIDR(ctx context.Context, cl client.Client, liqoOpts *options.LiqoOptions, clusterID string) (string, error) {
+	namespace, err := GetTenantNamespace(ctx, cl, clusterID)
+	if err != nil {
+		return "", err
+	}
 
+	var network ipamv1alpha1.Network
 	if err := cl.Get(ctx, client.ObjectKey{
-		Namespace: GetClusterNamespace(clusterID),
-		Name:      fmt.Sprintf("%s-external", clusterID),
+		Namespace: namespace,
+		Name:      liqoOpts.RemoteExternalCIDRNetworkName(clusterID),
 	}, &network); err != nil {
 		return "", err
 	}
 
+	return getNetworkStatusCIDR(&network)
+}
+
+// getNetworkStatusCIDR returns the CIDR of the given remote Network as remapped by Liqo.
+// It fails if the CIDR has not been allocated yet, rather than producing rules matching nothing.
+func getNetworkStatusCIDR(network *ipamv1alpha1.Network) (string, error) {
+	if network.Status.CIDR == "" {
+		return "", fmt.Errorf("the CIDR of Network %s/%s has not been allocated yet", network.Namespace, network.Name)
+	}
 	return string(network.Status.CIDR), nil
 }
+
+// CollapseCIDRs parses the given CIDRs and removes the ones contained in other CIDRs of the list,
+// so that the result can be used as the elements of an interval set, which rejects overlapping
+// elements. The result is split by address family and sorted.
+func CollapseCIDRs(cidrs []string) (ipv4, ipv6 []string, err error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	// Sorting by address and then by prefix length guarantees that each prefix
+	// comes after all the prefixes containing it.
+	slices.SortFunc(prefixes, func(a, b netip.Prefix) int {
+		if c := a.Addr().Compare(b.Addr()); c != 0 {
+			return c
+		}
+		return a.Bits() - b.Bits()
+	})
+
+	var collapsed []netip.Prefix
+	for _, prefix := range prefixes {
+		if len(collapsed) > 0 {
+			last := collapsed[len(collapsed)-1]
+			if last.Addr().Is4() == prefix.Addr().Is4() && last.Bits() <= prefix.Bits() && last.Contains(prefix.Addr()) {
+				continue
+			}
+		}
+		collapsed = append(collapsed, prefix)
+	}
+
+	for _, prefix := range collapsed {
+		if prefix.Addr().Is4() {
+			ipv4 = append(ipv4, prefix.String())
+		} else {
+			ipv6 = append(ipv6, prefix.String())
+		}
+	}
+	return ipv4, ipv6, nil
+}
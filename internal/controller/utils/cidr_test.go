@@ -23,7 +23,6 @@ import (
 	. "github.com/onsi/gomega"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 var _ = Describe("CIDR Utilities", func() {
@@ -50,17 +49,17 @@ var _ = Describe("CIDR Utilities", func() {
 				},
 			}
 
-			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(network).Build()
+			cl := newFakeClientBuilder(scheme).WithObjects(network).Build()
 
-			cidr, err := GetCurrentClusterPodCIDR(ctx, cl)
+			cidr, err := GetCurrentClusterPodCIDR(ctx, cl, liqoOpts)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(cidr).To(Equal("10.0.0.0/16"))
 		})
 
 		It("should return error when Network resource does not exist", func() {
-			cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+			cl := newFakeClientBuilder(scheme).Build()
 
-			_, err := GetCurrentClusterPodCIDR(ctx, cl)
+			_, err := GetCurrentClusterPodCIDR(ctx, cl, liqoOpts)
 			Expect(err).To(HaveOccurred())
 		})
 
@@ -75,9 +74,9 @@ var _ = Describe("CIDR Utilities", func() {
 				},
 			}
 
-			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(network).Build()
+			cl := newFakeClientBuilder(scheme).WithObjects(network).Build()
 
-			cidr, err := GetCurrentClusterPodCIDR(ctx, cl)
+			cidr, err := GetCurrentClusterPodCIDR(ctx, cl, liqoOpts)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(cidr).To(Equal("2001:db8::/32"))
 		})
@@ -96,17 +95,17 @@ var _ = Describe("CIDR Utilities", func() {
 				},
 			}
 
-			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(network).Build()
+			cl := newFakeClientBuilder(scheme).WithObjects(network).Build()
 
-			cidr, err := GetRemoteClusterPodCIDR(ctx, cl, clusterID)
+			cidr, err := GetRemoteClusterPodCIDR(ctx, cl, liqoOpts, clusterID)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(cidr).To(Equal("10.1.0.0/16"))
 		})
 
 		It("should return error when Network resource does not exist", func() {
-			cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+			cl := newFakeClientBuilder(scheme).Build()
 
-			_, err := GetRemoteClusterPodCIDR(ctx, cl, "nonexistent-cluster")
+			_, err := GetRemoteClusterPodCIDR(ctx, cl, liqoOpts, "nonexistent-cluster")
 			Expect(err).To(HaveOccurred())
 		})
 
@@ -122,11 +121,130 @@ var _ = Describe("CIDR Utilities", func() {
 				},
 			}
 
-			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(network).Build()
+			cl := newFakeClientBuilder(scheme).WithObjects(network).Build()
 
-			cidr, err := GetRemoteClusterPodCIDR(ctx, cl, clusterID)
+			cidr, err := GetRemoteClusterPodCIDR(ctx, cl, liqoOpts, clusterID)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(cidr).To(Equal("10.2.0.0/16"))
 		})
+
+		It("should return the remapped CIDR of an overlapping peering", func() {
+			network := &ipamv1alpha1.Network{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cluster-abc-pod",
+					Namespace: "liqo-tenant-cluster-abc",
+				},
+				Spec:   ipamv1alpha1.NetworkSpec{CIDR: networkingv1beta1.CIDR("10.0.0.0/16")},
+				Status: ipamv1alpha1.NetworkStatus{CIDR: networkingv1beta1.CIDR("10.70.0.0/16")},
+			}
+
+			cl := newFakeClientBuilder(scheme).WithObjects(network).Build()
+
+			cidr, err := GetRemoteClusterPodCIDR(ctx, cl, liqoOpts, "cluster-abc")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cidr).To(Equal("10.70.0.0/16"))
+		})
+
+		It("should return error when the remapped CIDR has not been allocated yet", func() {
+			network := &ipamv1alpha1.Network{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cluster-abc-pod",
+					Namespace: "liqo-tenant-cluster-abc",
+				},
+				Spec: ipamv1alpha1.NetworkSpec{CIDR: networkingv1beta1.CIDR("10.0.0.0/16")},
+			}
+
+			cl := newFakeClientBuilder(scheme).WithObjects(network).Build()
+
+			_, err := GetRemoteClusterPodCIDR(ctx, cl, liqoOpts, "cluster-abc")
+			Expect(err).To(MatchError(ContainSubstring("not been allocated")))
+		})
+	})
+
+	Describe("GetCurrentClusterServiceCIDR", func() {
+		It("should retrieve the local service CIDR from the Network resource", func() {
+			network := &ipamv1alpha1.Network{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service-cidr",
+					Namespace: "liqo",
+				},
+				Status: ipamv1alpha1.NetworkStatus{
+					CIDR: networkingv1beta1.CIDR("10.96.0.0/12"),
+				},
+			}
+
+			cl := newFakeClientBuilder(scheme).WithObjects(network).Build()
+
+			cidr, err := GetCurrentClusterServiceCIDR(ctx, cl, liqoOpts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cidr).To(Equal("10.96.0.0/12"))
+		})
+
+		It("should return error when Network resource does not exist", func() {
+			cl := newFakeClientBuilder(scheme).Build()
+
+			_, err := GetCurrentClusterServiceCIDR(ctx, cl, liqoOpts)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should read the Network resource of a custom Liqo installation", func() {
+			network := &ipamv1alpha1.Network{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "services",
+					Namespace: "liqo-system",
+				},
+				Status: ipamv1alpha1.NetworkStatus{
+					CIDR: networkingv1beta1.CIDR("10.96.0.0/12"),
+				},
+			}
+
+			cl := newFakeClientBuilder(scheme).WithObjects(network).Build()
+
+			customOpts := *liqoOpts
+			customOpts.Namespace = "liqo-system"
+			customOpts.ServiceCIDRNetworkName = "services"
+			cidr, err := GetCurrentClusterServiceCIDR(ctx, cl, &customOpts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cidr).To(Equal("10.96.0.0/12"))
+		})
+	})
+
+	Describe("CollapseCIDRs", func() {
+		It("should remove the CIDRs contained in other CIDRs", func() {
+			ipv4, ipv6, err := CollapseCIDRs([]string{"10.96.0.0/12", "10.0.0.0/8", "10.1.2.0/24", "192.168.0.0/16"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ipv4).To(Equal([]string{"10.0.0.0/8", "192.168.0.0/16"}))
+			Expect(ipv6).To(BeEmpty())
+		})
+
+		It("should remove duplicated CIDRs", func() {
+			ipv4, _, err := CollapseCIDRs([]string{"100.64.0.0/10", "100.64.0.0/10"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ipv4).To(Equal([]string{"100.64.0.0/10"}))
+		})
+
+		It("should split and sort the CIDRs by address family", func() {
+			ipv4, ipv6, err := CollapseCIDRs([]string{"fc00::/7", "192.168.0.0/16", "fd00::/8", "::1/128", "10.0.0.0/8"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ipv4).To(Equal([]string{"10.0.0.0/8", "192.168.0.0/16"}))
+			Expect(ipv6).To(Equal([]string{"::1/128", "fc00::/7"}))
+		})
+
+		It("should keep adjacent CIDRs", func() {
+			ipv4, _, err := CollapseCIDRs([]string{"198.18.0.0/15", "198.20.0.0/16"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ipv4).To(Equal([]string{"198.18.0.0/15", "198.20.0.0/16"}))
+		})
+
+		It("should mask the host bits", func() {
+			ipv4, _, err := CollapseCIDRs([]string{"10.1.2.3/8"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ipv4).To(Equal([]string{"10.0.0.0/8"}))
+		})
+
+		It("should return error for an invalid CIDR", func() {
+			_, _, err := CollapseCIDRs([]string{"not-a-cidr"})
+			Expect(err).To(HaveOccurred())
+		})
 	})
 })
@@ -22,7 +22,8 @@ import (
 
 // GetClusterNamespace returns the Liqo tenant namespace for a given cluster ID.
 // Liqo uses namespaces with the format "liqo-tenant-<cluster-id>" to isolate
-// resources for each peered cluster.
+// resources for each peered cluster, unless configured otherwise: use GetTenantNamespace
+// to resolve the actual tenant namespace of a peering.
 func GetClusterNamespace(clusterID string) string {
 	return fmt.Sprintf("%s-%s", tenantnamespace.NamePrefix, clusterID)
 }
@@ -30,6 +31,7 @@ func GetClusterNamespace(clusterID string) string {
 // ExtractClusterIDFromNamespace extracts the cluster ID from a Liqo tenant namespace name.
 // It removes the "liqo-tenant-" prefix to obtain the cluster ID.
 // Returns an error if the namespace doesn't follow the expected format.
+// Use GetClusterIDFromNamespace to resolve the cluster ID of any tenant namespace.
 func ExtractClusterIDFromNamespace(namespace string) (string, error) {
 	const prefix = tenantnamespace.NamePrefix + "-"
 
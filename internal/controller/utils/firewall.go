@@ -0,0 +1,88 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+
+	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SpecHashAnnotationKey annotates the generated FirewallConfigurations with the hash of the spec they were rendered with.
+const SpecHashAnnotationKey = "connectivity.liqo.io/spec-hash"
+
+// SetFirewallConfigurationSpec sets the given rendered spec to the FirewallConfiguration, recording its hash in the
+// SpecHashAnnotationKey annotation, and returns the hash. If the recorded hash matches the rendered spec, and the
+// stored spec still matches the recorded hash, the FirewallConfiguration is left untouched, so that the write is
+// skipped, since every update makes the gateway reprogram its firewall. A stored spec not matching the recorded hash
+// was edited out of band, hence it is replaced by the rendered one.
+func SetFirewallConfigurationSpec(
+	fwcfg *networkingv1beta1.FirewallConfiguration, spec *networkingv1beta1.FirewallConfigurationSpec,
+) (string, error) {
+	hash, err := ForgeHash(spec)
+	if err != nil {
+		return "", err
+	}
+
+	annotations := fwcfg.GetAnnotations()
+	if annotations[SpecHashAnnotationKey] == hash {
+		stored, err := ForgeHash(&fwcfg.Spec)
+		if err != nil {
+			return "", err
+		}
+		if stored == hash {
+			return hash, nil
+		}
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[SpecHashAnnotationKey] = hash
+	fwcfg.SetAnnotations(annotations)
+	fwcfg.Spec = *spec
+	return hash, nil
+}
+
+// EnsureLegacyFirewallConfigurationDeleted deletes the FirewallConfiguration with the given key
+// if it programs the given legacy table name, so that the table is removed from the nodes
+// before a FirewallConfiguration with the new table name is created.
+// It returns true while the legacy FirewallConfiguration still exists, meaning that the caller
+// must wait for its deletion to complete before recreating it.
+func EnsureLegacyFirewallConfigurationDeleted(
+	ctx context.Context,
+	c client.Client,
+	key types.NamespacedName,
+	legacyTableName string,
+) (pending bool, err error) {
+	fwcfg := networkingv1beta1.FirewallConfiguration{}
+	if err := c.Get(ctx, key, &fwcfg); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	if fwcfg.Spec.Table.Name == nil || *fwcfg.Spec.Table.Name != legacyTableName {
+		return false, nil
+	}
+
+	if fwcfg.DeletionTimestamp.IsZero() {
+		if err := c.Delete(ctx, &fwcfg); client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
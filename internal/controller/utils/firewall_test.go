@@ -0,0 +1,174 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+
+	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
+	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Firewall Utilities", func() {
+	const legacyTableName = "legacy-table"
+
+	var (
+		ctx    context.Context
+		scheme *runtime.Scheme
+		key    types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		RegisterScheme(scheme)
+		key = types.NamespacedName{Name: "fwcfg", Namespace: "liqo-tenant-cluster-a"}
+	})
+
+	forgeFirewallConfiguration := func(tableName string, finalizers ...string) *networkingv1beta1.FirewallConfiguration {
+		return &networkingv1beta1.FirewallConfiguration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       key.Name,
+				Namespace:  key.Namespace,
+				Finalizers: finalizers,
+			},
+			Spec: networkingv1beta1.FirewallConfigurationSpec{
+				Table: networkingv1beta1firewall.Table{Name: ptr.To(tableName)},
+			},
+		}
+	}
+
+	Describe("EnsureLegacyFirewallConfigurationDeleted", func() {
+		It("should do nothing if the FirewallConfiguration does not exist", func() {
+			cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+			pending, err := EnsureLegacyFirewallConfigurationDeleted(ctx, cl, key, legacyTableName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pending).To(BeFalse())
+		})
+
+		It("should keep a FirewallConfiguration using a different table name", func() {
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(forgeFirewallConfiguration("new-table")).Build()
+
+			pending, err := EnsureLegacyFirewallConfigurationDeleted(ctx, cl, key, legacyTableName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pending).To(BeFalse())
+			Expect(cl.Get(ctx, key, &networkingv1beta1.FirewallConfiguration{})).To(Succeed())
+		})
+
+		It("should delete a FirewallConfiguration using the legacy table name", func() {
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(forgeFirewallConfiguration(legacyTableName)).Build()
+
+			pending, err := EnsureLegacyFirewallConfigurationDeleted(ctx, cl, key, legacyTableName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pending).To(BeTrue())
+
+			err = cl.Get(ctx, key, &networkingv1beta1.FirewallConfiguration{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should report pending while the legacy FirewallConfiguration is being finalized", func() {
+			cl := fake.NewClientBuilder().WithScheme(scheme).
+				WithObjects(forgeFirewallConfiguration(legacyTableName, "liqo.io/firewall")).Build()
+
+			pending, err := EnsureLegacyFirewallConfigurationDeleted(ctx, cl, key, legacyTableName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pending).To(BeTrue())
+
+			pending, err = EnsureLegacyFirewallConfigurationDeleted(ctx, cl, key, legacyTableName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pending).To(BeTrue())
+		})
+	})
+
+	Describe("SetFirewallConfigurationSpec", func() {
+		It("should set the spec and record its hash", func() {
+			fwcfg := forgeFirewallConfiguration("table")
+			spec := forgeFirewallConfiguration("rendered").Spec
+
+			hash, err := SetFirewallConfigurationSpec(fwcfg, &spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hash).NotTo(BeEmpty())
+			Expect(fwcfg.Spec).To(Equal(spec))
+			Expect(fwcfg.Annotations).To(HaveKeyWithValue(SpecHashAnnotationKey, hash))
+		})
+
+		It("should leave the FirewallConfiguration untouched if it was rendered with the same spec", func() {
+			fwcfg := forgeFirewallConfiguration("table")
+			spec := forgeFirewallConfiguration("rendered").Spec
+			_, err := SetFirewallConfigurationSpec(fwcfg, &spec)
+			Expect(err).NotTo(HaveOccurred())
+			stored := fwcfg.DeepCopy()
+
+			_, err = SetFirewallConfigurationSpec(fwcfg, forgeFirewallConfiguration("rendered").Spec.DeepCopy())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fwcfg).To(Equal(stored))
+		})
+
+		It("should restore the rendered spec if the stored one was edited out of band", func() {
+			fwcfg := forgeFirewallConfiguration("table")
+			spec := forgeFirewallConfiguration("rendered").Spec
+			hash, err := SetFirewallConfigurationSpec(fwcfg, &spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			// The edit leaves the recorded hash untouched, which no longer matches the stored spec.
+			fwcfg.Spec.Table.Family = ptr.To(networkingv1beta1firewall.TableFamilyIPv6)
+			fwcfg.Spec.Table.Sets = []networkingv1beta1firewall.Set{{Name: "injected"}}
+
+			rendered, err := SetFirewallConfigurationSpec(fwcfg, &spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered).To(Equal(hash))
+			Expect(fwcfg.Spec).To(Equal(spec))
+			Expect(fwcfg.Annotations).To(HaveKeyWithValue(SpecHashAnnotationKey, hash))
+		})
+
+		It("should restore the rendered spec if the recorded hash was removed", func() {
+			fwcfg := forgeFirewallConfiguration("table")
+			spec := forgeFirewallConfiguration("rendered").Spec
+			hash, err := SetFirewallConfigurationSpec(fwcfg, &spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			delete(fwcfg.Annotations, SpecHashAnnotationKey)
+			fwcfg.Spec.Table.Name = ptr.To("edited")
+
+			_, err = SetFirewallConfigurationSpec(fwcfg, &spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fwcfg.Spec).To(Equal(spec))
+			Expect(fwcfg.Annotations).To(HaveKeyWithValue(SpecHashAnnotationKey, hash))
+		})
+
+		It("should replace the stored spec when the rendered one changes", func() {
+			fwcfg := forgeFirewallConfiguration("table")
+			spec := forgeFirewallConfiguration("rendered").Spec
+			hash, err := SetFirewallConfigurationSpec(fwcfg, &spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			spec = forgeFirewallConfiguration("changed").Spec
+			changed, err := SetFirewallConfigurationSpec(fwcfg, &spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).NotTo(Equal(hash))
+			Expect(fwcfg.Spec).To(Equal(spec))
+			Expect(fwcfg.Annotations).To(HaveKeyWithValue(SpecHashAnnotationKey, changed))
+		})
+	})
+})
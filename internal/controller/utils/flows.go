@@ -0,0 +1,51 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+)
+
+// RuleFlow is a direction of the connections matched by a rule, from the party initiating them
+// to the party accepting them. A nil party matches any endpoint.
+type RuleFlow struct {
+	// Initiator is the party opening the connections, matched as the source of their packets.
+	Initiator *connectivityv1.Party
+	// Responder is the party accepting the connections, matched as the destination of their packets.
+	Responder *connectivityv1.Party
+}
+
+// ForgeRuleFlows returns the flows matched by the given rule according to its direction, which is
+// relative to its source. All the forges translate the rules through their flows, so that they
+// implement the same semantic. A bidirectional rule with the same source and destination has a
+// single flow, as the second one would match the same connections.
+func ForgeRuleFlows(rule *connectivityv1.Rule) []RuleFlow {
+	egress := RuleFlow{Initiator: rule.Source, Responder: rule.Destination}
+	ingress := RuleFlow{Initiator: rule.Destination, Responder: rule.Source}
+
+	switch rule.Direction {
+	case connectivityv1.DirectionIngress:
+		return []RuleFlow{ingress}
+	case connectivityv1.DirectionBidirectional:
+		if equality.Semantic.DeepEqual(rule.Source, rule.Destination) {
+			return []RuleFlow{egress}
+		}
+		return []RuleFlow{egress, ingress}
+	default:
+		return []RuleFlow{egress}
+	}
+}
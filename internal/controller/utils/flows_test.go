@@ -0,0 +1,50 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+)
+
+var _ = Describe("Rule flows", func() {
+	var (
+		offloaded = &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupOffloaded)}
+		namespace = &connectivityv1.Party{Namespace: ptr.To("alpha")}
+	)
+
+	DescribeTable("ForgeRuleFlows",
+		func(direction connectivityv1.Direction, source, destination *connectivityv1.Party, expected []RuleFlow) {
+			rule := connectivityv1.Rule{Action: connectivityv1.ActionAllow, Source: source, Destination: destination, Direction: direction}
+			Expect(ForgeRuleFlows(&rule)).To(Equal(expected))
+		},
+		Entry("an omitted direction matches the egress of the source", connectivityv1.Direction(""), offloaded, namespace,
+			[]RuleFlow{{Initiator: offloaded, Responder: namespace}}),
+		Entry("egress matches the connections initiated by the source", connectivityv1.DirectionEgress, offloaded, namespace,
+			[]RuleFlow{{Initiator: offloaded, Responder: namespace}}),
+		Entry("ingress matches the connections initiated by the destination", connectivityv1.DirectionIngress, offloaded, namespace,
+			[]RuleFlow{{Initiator: namespace, Responder: offloaded}}),
+		Entry("bidirectional matches both", connectivityv1.DirectionBidirectional, offloaded, namespace,
+			[]RuleFlow{{Initiator: offloaded, Responder: namespace}, {Initiator: namespace, Responder: offloaded}}),
+		Entry("bidirectional with an omitted party matches both", connectivityv1.DirectionBidirectional, nil, namespace,
+			[]RuleFlow{{Initiator: nil, Responder: namespace}, {Initiator: namespace, Responder: nil}}),
+		Entry("bidirectional with the same parties has a single flow", connectivityv1.DirectionBidirectional,
+			offloaded, &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupOffloaded)},
+			[]RuleFlow{{Initiator: offloaded, Responder: offloaded}}),
+	)
+})
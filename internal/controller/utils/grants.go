@@ -0,0 +1,97 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+)
+
+// AccessGrantExpiration returns the time the given AccessGrant expires, i.e. its creation time plus its TTL.
+func AccessGrantExpiration(grant *connectivityv1.AccessGrant) time.Time {
+	return grant.CreationTimestamp.Add(grant.Spec.TTL.Duration)
+}
+
+// IsAccessGrantActive checks whether the given AccessGrant is active at the given time,
+// i.e. it is not being deleted and its TTL has not elapsed.
+func IsAccessGrantActive(grant *connectivityv1.AccessGrant, now time.Time) bool {
+	return grant.DeletionTimestamp.IsZero() && now.Before(AccessGrantExpiration(grant))
+}
+
+// GetActiveAccessGrants returns the AccessGrant resources of the peering with the given cluster
+// that are active at the given time, ordered by creation time and then by name, along with the
+// time the first of them expires, if any.
+func GetActiveAccessGrants(
+	ctx context.Context, cl client.Client, clusterID string, now time.Time,
+) ([]*connectivityv1.AccessGrant, time.Time, error) {
+	var grantList connectivityv1.AccessGrantList
+	if err := cl.List(ctx, &grantList); err != nil {
+		return nil, time.Time{}, fmt.Errorf("unable to list the AccessGrant resources: %w", err)
+	}
+
+	var (
+		grants []*connectivityv1.AccessGrant
+		next   time.Time
+	)
+	for i := range grantList.Items {
+		grant := &grantList.Items[i]
+		if grant.Spec.ClusterID != clusterID || !IsAccessGrantActive(grant, now) {
+			continue
+		}
+
+		grants = append(grants, grant)
+		if expiration := AccessGrantExpiration(grant); next.IsZero() || expiration.Before(next) {
+			next = expiration
+		}
+	}
+
+	slices.SortFunc(grants, func(a, b *connectivityv1.AccessGrant) int {
+		if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+			return a.CreationTimestamp.Compare(b.CreationTimestamp.Time)
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return grants, next, nil
+}
+
+// InjectAccessGrants prepends the rules of the given AccessGrant resources to the effective
+// PeeringConnectivity of the chain, so that they are evaluated ahead of the rules of its members,
+// and shifts the placements of the members accordingly.
+func (c *PeeringConnectivityChain) InjectAccessGrants(grants []*connectivityv1.AccessGrant) {
+	if len(grants) == 0 {
+		return
+	}
+
+	names := make([]string, len(grants))
+	rules := make([]connectivityv1.Rule, 0, len(grants)+len(c.Effective.Spec.Rules))
+	for i, grant := range grants {
+		names[i] = grant.Name
+		rules = append(rules, *grant.Spec.Rule.DeepCopy())
+	}
+	c.Effective.Spec.Rules = append(rules, c.Effective.Spec.Rules...)
+
+	for _, placement := range c.Placements {
+		placement.FirstRule += int32(len(grants))
+		placement.ChainLength += int32(len(grants))
+		placement.Grants = names
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+)
+
+var _ = Describe("AccessGrant Utilities", func() {
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	newGrant := func(name, clusterID string, created time.Time, ttl time.Duration) *connectivityv1.AccessGrant {
+		return &connectivityv1.AccessGrant{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(created)},
+			Spec: connectivityv1.AccessGrantSpec{
+				ClusterID: clusterID,
+				Rule: connectivityv1.Rule{
+					Action:      connectivityv1.ActionAllow,
+					Destination: &connectivityv1.Party{Namespace: ptr.To(name)},
+				},
+				TTL:    metav1.Duration{Duration: ttl},
+				Reason: "test",
+			},
+		}
+	}
+
+	Describe("IsAccessGrantActive", func() {
+		It("should be active until its TTL elapses", func() {
+			grant := newGrant("a", "cluster", now.Add(-time.Hour), 2*time.Hour)
+			Expect(AccessGrantExpiration(grant)).To(Equal(now.Add(time.Hour)))
+			Expect(IsAccessGrantActive(grant, now)).To(BeTrue())
+			Expect(IsAccessGrantActive(grant, now.Add(time.Hour))).To(BeFalse())
+		})
+
+		It("should not be active while being deleted", func() {
+			grant := newGrant("a", "cluster", now, time.Hour)
+			grant.DeletionTimestamp = ptr.To(metav1.NewTime(now))
+			Expect(IsAccessGrantActive(grant, now)).To(BeFalse())
+		})
+	})
+
+	Describe("GetActiveAccessGrants", func() {
+		It("should return the active grants of the cluster by creation time and the first expiration", func() {
+			scheme := runtime.NewScheme()
+			Expect(connectivityv1.AddToScheme(scheme)).To(Succeed())
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newGrant("late", "cluster", now.Add(-time.Minute), 2*time.Hour),
+				newGrant("early", "cluster", now.Add(-time.Hour), 3*time.Hour),
+				newGrant("expired", "cluster", now.Add(-2*time.Hour), time.Hour),
+				newGrant("other", "other-cluster", now, time.Hour),
+			).Build()
+
+			grants, next, err := GetActiveAccessGrants(context.Background(), cl, "cluster", now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(grants).To(HaveLen(2))
+			Expect(grants[0].Name).To(Equal("early"))
+			Expect(grants[1].Name).To(Equal("late"))
+			Expect(next).To(BeTemporally("==", now.Add(time.Hour+59*time.Minute)))
+		})
+	})
+
+	Describe("InjectAccessGrants", func() {
+		It("should prepend the rules of the grants and shift the placements", func() {
+			member := &connectivityv1.PeeringConnectivity{
+				ObjectMeta: metav1.ObjectMeta{Name: "pc"},
+				Spec: connectivityv1.PeeringConnectivitySpec{Rules: []connectivityv1.Rule{{
+					Action: connectivityv1.ActionAllow, Destination: &connectivityv1.Party{Namespace: ptr.To("pc")},
+				}}},
+			}
+			chain := NewPeeringConnectivityChain([]*connectivityv1.PeeringConnectivity{member})
+			grants := []*connectivityv1.AccessGrant{newGrant("first", "cluster", now, time.Hour), newGrant("second", "cluster", now, time.Hour)}
+
+			chain.InjectAccessGrants(grants)
+
+			Expect(chain.Effective.Spec.Rules).To(Equal([]connectivityv1.Rule{grants[0].Spec.Rule, grants[1].Spec.Rule, member.Spec.Rules[0]}))
+			Expect(member.Spec.Rules).To(HaveLen(1))
+			Expect(chain.Placements["pc"]).To(Equal(&connectivityv1.ChainPlacement{
+				Position: 0, FirstRule: 2, Rules: 1, ChainLength: 3, Chain: []string{"pc"}, Grants: []string{"first", "second"},
+			}))
+		})
+
+		It("should leave the chain untouched without grants", func() {
+			chain := NewPeeringConnectivityChain([]*connectivityv1.PeeringConnectivity{{ObjectMeta: metav1.ObjectMeta{Name: "pc"}}})
+			chain.InjectAccessGrants(nil)
+			Expect(chain.Placements["pc"].Grants).To(BeNil())
+		})
+	})
+})
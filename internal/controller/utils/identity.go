@@ -0,0 +1,194 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+
+	authv1beta1 "github.com/liqotech/liqo/apis/authentication/v1beta1"
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ForeignClusterClusterIDIndex indexes the ForeignClusters by the ID of the remote cluster.
+	ForeignClusterClusterIDIndex = "spec.clusterID"
+	// ForeignClusterTenantNamespaceIndex indexes the ForeignClusters by their local tenant namespace.
+	ForeignClusterTenantNamespaceIndex = "status.tenantNamespace.local"
+	// TenantClusterIDIndex indexes the Tenants by the ID of the consumer cluster.
+	TenantClusterIDIndex = "spec.clusterID"
+	// TenantNamespaceIndex indexes the Tenants by their tenant namespace.
+	TenantNamespaceIndex = "status.tenantNamespace"
+	// VirtualNodeClusterIDIndex indexes the VirtualNodes by the ID of the provider cluster.
+	VirtualNodeClusterIDIndex = "spec.clusterID"
+	// VirtualNodeNodeNameIndex indexes the VirtualNodes by the name of the Node they create,
+	// which Liqo names after the VirtualNode itself.
+	VirtualNodeNodeNameIndex = "nodeName"
+)
+
+// identityIndex describes a field index used to resolve the identity of the peerings.
+type identityIndex struct {
+	obj     client.Object
+	field   string
+	extract client.IndexerFunc
+}
+
+// identityIndexes are the field indexes SetupIdentityIndexes registers.
+var identityIndexes = []identityIndex{
+	{&liqov1beta1.ForeignCluster{}, ForeignClusterClusterIDIndex, func(obj client.Object) []string {
+		return nonEmpty(string(obj.(*liqov1beta1.ForeignCluster).Spec.ClusterID))
+	}},
+	{&liqov1beta1.ForeignCluster{}, ForeignClusterTenantNamespaceIndex, func(obj client.Object) []string {
+		return nonEmpty(obj.(*liqov1beta1.ForeignCluster).Status.TenantNamespace.Local)
+	}},
+	{&authv1beta1.Tenant{}, TenantClusterIDIndex, func(obj client.Object) []string {
+		return nonEmpty(string(obj.(*authv1beta1.Tenant).Spec.ClusterID))
+	}},
+	{&authv1beta1.Tenant{}, TenantNamespaceIndex, func(obj client.Object) []string {
+		return nonEmpty(obj.(*authv1beta1.Tenant).Status.TenantNamespace)
+	}},
+	{&offloadingv1beta1.VirtualNode{}, VirtualNodeClusterIDIndex, func(obj client.Object) []string {
+		return nonEmpty(string(obj.(*offloadingv1beta1.VirtualNode).Spec.ClusterID))
+	}},
+	{&offloadingv1beta1.VirtualNode{}, VirtualNodeNodeNameIndex, func(obj client.Object) []string {
+		return nonEmpty(obj.GetName())
+	}},
+}
+
+// SetupIdentityIndexes registers the field indexes used to map the cluster IDs to the tenant
+// namespaces and to the virtual nodes, and vice versa.
+func SetupIdentityIndexes(ctx context.Context, indexer client.FieldIndexer) error {
+	for _, index := range identityIndexes {
+		if err := indexer.IndexField(ctx, index.obj, index.field, index.extract); err != nil {
+			return fmt.Errorf("unable to index %T by %s: %w", index.obj, index.field, err)
+		}
+	}
+	return nil
+}
+
+// IdentityChanged returns whether the update of a ForeignCluster, a Tenant or a VirtualNode changed
+// any of the fields indexed by SetupIdentityIndexes, which are the only ones mapping the peerings to
+// their tenant namespaces and virtual nodes.
+func IdentityChanged(oldObj, newObj client.Object) bool {
+	for _, index := range identityIndexes {
+		if reflect.TypeOf(index.obj) != reflect.TypeOf(newObj) || reflect.TypeOf(oldObj) != reflect.TypeOf(newObj) {
+			continue
+		}
+		if !slices.Equal(index.extract(oldObj), index.extract(newObj)) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTenantNamespace returns the tenant namespace of the given remote cluster.
+// It is read from the ForeignCluster of the cluster or, on the provider side, from its Tenant.
+// If neither of them reports it, e.g. while the peering is being torn down, the default
+// Liqo tenant namespace name is returned.
+func GetTenantNamespace(ctx context.Context, cl client.Client, clusterID string) (string, error) {
+	var foreignClusters liqov1beta1.ForeignClusterList
+	if err := cl.List(ctx, &foreignClusters, client.MatchingFields{ForeignClusterClusterIDIndex: clusterID}); err != nil {
+		return "", err
+	}
+	for i := range foreignClusters.Items {
+		if namespace := foreignClusters.Items[i].Status.TenantNamespace.Local; namespace != "" {
+			return namespace, nil
+		}
+	}
+
+	var tenants authv1beta1.TenantList
+	if err := cl.List(ctx, &tenants, client.MatchingFields{TenantClusterIDIndex: clusterID}); err != nil {
+		return "", err
+	}
+	for i := range tenants.Items {
+		if namespace := tenants.Items[i].Status.TenantNamespace; namespace != "" {
+			return namespace, nil
+		}
+	}
+
+	return GetClusterNamespace(clusterID), nil
+}
+
+// GetClusterIDFromNamespace returns the ID of the remote cluster the given tenant namespace belongs to.
+// It is read from the ForeignCluster or the Tenant using the namespace. If none of them does, the
+// cluster ID is extracted from the default Liqo tenant namespace name.
+func GetClusterIDFromNamespace(ctx context.Context, cl client.Client, namespace string) (string, error) {
+	var foreignClusters liqov1beta1.ForeignClusterList
+	if err := cl.List(ctx, &foreignClusters, client.MatchingFields{ForeignClusterTenantNamespaceIndex: namespace}); err != nil {
+		return "", err
+	}
+	if len(foreignClusters.Items) > 0 {
+		return string(foreignClusters.Items[0].Spec.ClusterID), nil
+	}
+
+	var tenants authv1beta1.TenantList
+	if err := cl.List(ctx, &tenants, client.MatchingFields{TenantNamespaceIndex: namespace}); err != nil {
+		return "", err
+	}
+	for i := range tenants.Items {
+		if clusterID := tenants.Items[i].Spec.ClusterID; clusterID != "" {
+			return string(clusterID), nil
+		}
+	}
+
+	return ExtractClusterIDFromNamespace(namespace)
+}
+
+// GetClusterIDFromNode returns the ID of the provider cluster targeted by the given virtual node.
+// It returns an empty string if the node is not a Liqo virtual node.
+func GetClusterIDFromNode(ctx context.Context, cl client.Client, nodeName string) (string, error) {
+	var virtualNodes offloadingv1beta1.VirtualNodeList
+	if err := cl.List(ctx, &virtualNodes, client.MatchingFields{VirtualNodeNodeNameIndex: nodeName}); err != nil {
+		return "", err
+	}
+	for i := range virtualNodes.Items {
+		if clusterID := virtualNodes.Items[i].Spec.ClusterID; clusterID != "" {
+			return string(clusterID), nil
+		}
+	}
+	return "", nil
+}
+
+// GetVirtualNodeNames returns the names of the virtual nodes targeting the given provider cluster.
+func GetVirtualNodeNames(ctx context.Context, cl client.Client, clusterID string) ([]string, error) {
+	var virtualNodes offloadingv1beta1.VirtualNodeList
+	if err := cl.List(ctx, &virtualNodes, client.MatchingFields{VirtualNodeClusterIDIndex: clusterID}); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(virtualNodes.Items))
+	for i := range virtualNodes.Items {
+		names[i] = virtualNodes.Items[i].Name
+	}
+	return names, nil
+}
+
+// IsPeeringEstablished returns whether the networking of the peering described by the ForeignCluster
+// has been established and its tenant namespace created.
+func IsPeeringEstablished(fc *liqov1beta1.ForeignCluster) bool {
+	return fc.Status.Modules.Networking.Enabled && fc.Status.TenantNamespace.Local != ""
+}
+
+// nonEmpty returns the given value as an index entry, or no entries if it is empty.
+func nonEmpty(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
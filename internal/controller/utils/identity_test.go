@@ -0,0 +1,231 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+
+	authv1beta1 "github.com/liqotech/liqo/apis/authentication/v1beta1"
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	ipamv1alpha1 "github.com/liqotech/liqo/apis/ipam/v1alpha1"
+	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var _ = Describe("Identity Utilities", func() {
+	var (
+		ctx    context.Context
+		scheme *runtime.Scheme
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		RegisterScheme(scheme)
+	})
+
+	newForeignCluster := func(clusterID, tenantNamespace string) *liqov1beta1.ForeignCluster {
+		return &liqov1beta1.ForeignCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterID},
+			Spec:       liqov1beta1.ForeignClusterSpec{ClusterID: liqov1beta1.ClusterID(clusterID)},
+			Status: liqov1beta1.ForeignClusterStatus{
+				TenantNamespace: liqov1beta1.TenantNamespaceType{Local: tenantNamespace},
+			},
+		}
+	}
+
+	newTenant := func(clusterID, tenantNamespace string) *authv1beta1.Tenant {
+		return &authv1beta1.Tenant{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterID, Namespace: tenantNamespace},
+			Spec:       authv1beta1.TenantSpec{ClusterID: liqov1beta1.ClusterID(clusterID)},
+			Status:     authv1beta1.TenantStatus{TenantNamespace: tenantNamespace},
+		}
+	}
+
+	newVirtualNode := func(name, clusterID string) *offloadingv1beta1.VirtualNode {
+		return &offloadingv1beta1.VirtualNode{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "tenant-" + clusterID},
+			Spec:       offloadingv1beta1.VirtualNodeSpec{ClusterID: liqov1beta1.ClusterID(clusterID)},
+		}
+	}
+
+	Describe("GetTenantNamespace", func() {
+		It("should return the tenant namespace of the ForeignCluster", func() {
+			cl := newFakeClientBuilder(scheme).WithObjects(
+				newForeignCluster("cluster-a", "custom-tenant-a"),
+				newForeignCluster("cluster-b", "custom-tenant-b"),
+			).Build()
+
+			namespace, err := GetTenantNamespace(ctx, cl, "cluster-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(namespace).To(Equal("custom-tenant-a"))
+		})
+
+		It("should return the tenant namespace of the Tenant on the provider side", func() {
+			cl := newFakeClientBuilder(scheme).WithObjects(
+				newForeignCluster("cluster-a", ""),
+				newTenant("cluster-a", "custom-tenant-a"),
+			).Build()
+
+			namespace, err := GetTenantNamespace(ctx, cl, "cluster-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(namespace).To(Equal("custom-tenant-a"))
+		})
+
+		It("should fall back to the default tenant namespace", func() {
+			cl := newFakeClientBuilder(scheme).Build()
+
+			namespace, err := GetTenantNamespace(ctx, cl, "cluster-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(namespace).To(Equal("liqo-tenant-cluster-a"))
+		})
+	})
+
+	Describe("GetClusterIDFromNamespace", func() {
+		It("should return the cluster ID of the ForeignCluster using the namespace", func() {
+			cl := newFakeClientBuilder(scheme).WithObjects(
+				newForeignCluster("cluster-a", "custom-tenant-a"),
+				newForeignCluster("cluster-b", "liqo-tenant-cluster-a"),
+			).Build()
+
+			clusterID, err := GetClusterIDFromNamespace(ctx, cl, "custom-tenant-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(clusterID).To(Equal("cluster-a"))
+
+			// The Liqo resources take precedence over the namespace name.
+			clusterID, err = GetClusterIDFromNamespace(ctx, cl, "liqo-tenant-cluster-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(clusterID).To(Equal("cluster-b"))
+		})
+
+		It("should return the cluster ID of the Tenant using the namespace", func() {
+			cl := newFakeClientBuilder(scheme).WithObjects(newTenant("consumer", "custom-tenant")).Build()
+
+			clusterID, err := GetClusterIDFromNamespace(ctx, cl, "custom-tenant")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(clusterID).To(Equal("consumer"))
+		})
+
+		It("should fall back to the default tenant namespace name", func() {
+			cl := newFakeClientBuilder(scheme).Build()
+
+			clusterID, err := GetClusterIDFromNamespace(ctx, cl, "liqo-tenant-cluster-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(clusterID).To(Equal("cluster-a"))
+
+			_, err = GetClusterIDFromNamespace(ctx, cl, "default")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("GetClusterIDFromNode", func() {
+		It("should return the cluster ID targeted by the virtual node", func() {
+			cl := newFakeClientBuilder(scheme).WithObjects(newVirtualNode("liqo-renamed", "cluster-a")).Build()
+
+			clusterID, err := GetClusterIDFromNode(ctx, cl, "liqo-renamed")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(clusterID).To(Equal("cluster-a"))
+		})
+
+		It("should return an empty cluster ID for the nodes that are not virtual", func() {
+			cl := newFakeClientBuilder(scheme).WithObjects(newVirtualNode("liqo-renamed", "cluster-a")).Build()
+
+			clusterID, err := GetClusterIDFromNode(ctx, cl, "worker-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(clusterID).To(BeEmpty())
+		})
+	})
+
+	Describe("GetVirtualNodeNames", func() {
+		It("should return all the virtual nodes targeting the cluster", func() {
+			cl := newFakeClientBuilder(scheme).WithObjects(
+				newVirtualNode("liqo-a-1", "cluster-a"),
+				newVirtualNode("liqo-a-2", "cluster-a"),
+				newVirtualNode("liqo-b", "cluster-b"),
+			).Build()
+
+			names, err := GetVirtualNodeNames(ctx, cl, "cluster-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names).To(ConsistOf("liqo-a-1", "liqo-a-2"))
+		})
+	})
+
+	Describe("IdentityChanged", func() {
+		It("should ignore the updates not changing the identity of the ForeignCluster", func() {
+			oldFC := newForeignCluster("cluster-a", "")
+			newFC := oldFC.DeepCopy()
+			newFC.Labels = map[string]string{"tier": "trusted"}
+			newFC.Status.Modules.Networking.Enabled = true
+			Expect(IdentityChanged(oldFC, newFC)).To(BeFalse())
+
+			newFC.Status.TenantNamespace.Local = "custom-tenant-a"
+			Expect(IdentityChanged(oldFC, newFC)).To(BeTrue())
+		})
+
+		It("should detect the changes of the tenant namespace of the Tenant", func() {
+			oldTenant := newTenant("cluster-a", "")
+			newTenant := oldTenant.DeepCopy()
+			newTenant.ResourceVersion = "2"
+			Expect(IdentityChanged(oldTenant, newTenant)).To(BeFalse())
+
+			newTenant.Status.TenantNamespace = "custom-tenant-a"
+			Expect(IdentityChanged(oldTenant, newTenant)).To(BeTrue())
+		})
+
+		It("should detect the changes of the cluster targeted by the VirtualNode", func() {
+			oldVirtualNode := newVirtualNode("liqo-a", "cluster-a")
+			newVirtualNode := oldVirtualNode.DeepCopy()
+			newVirtualNode.Labels = map[string]string{"ready": "true"}
+			Expect(IdentityChanged(oldVirtualNode, newVirtualNode)).To(BeFalse())
+
+			newVirtualNode.Spec.ClusterID = "cluster-b"
+			Expect(IdentityChanged(oldVirtualNode, newVirtualNode)).To(BeTrue())
+		})
+	})
+
+	Describe("IsPeeringEstablished", func() {
+		It("should require both the networking and the tenant namespace", func() {
+			fc := &liqov1beta1.ForeignCluster{}
+			Expect(IsPeeringEstablished(fc)).To(BeFalse())
+
+			fc.Status.Modules.Networking.Enabled = true
+			Expect(IsPeeringEstablished(fc)).To(BeFalse())
+
+			fc.Status.TenantNamespace.Local = "liqo-tenant-cluster-a"
+			Expect(IsPeeringEstablished(fc)).To(BeTrue())
+		})
+	})
+
+	Describe("GetRemoteClusterPodCIDR", func() {
+		It("should read the Network in the custom tenant namespace", func() {
+			cl := newFakeClientBuilder(scheme).WithObjects(
+				newForeignCluster("cluster-a", "custom-tenant-a"),
+				&ipamv1alpha1.Network{
+					ObjectMeta: metav1.ObjectMeta{Name: "cluster-a-pod", Namespace: "custom-tenant-a"},
+					Spec:       ipamv1alpha1.NetworkSpec{CIDR: networkingv1beta1.CIDR("10.0.0.0/16")},
+					Status:     ipamv1alpha1.NetworkStatus{CIDR: networkingv1beta1.CIDR("10.70.0.0/16")},
+				},
+			).Build()
+
+			cidr, err := GetRemoteClusterPodCIDR(ctx, cl, liqoOpts, "cluster-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cidr).To(Equal("10.70.0.0/16"))
+		})
+	})
+})
@@ -0,0 +1,83 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"strconv"
+
+	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
+)
+
+// CombineMatchAlternatives combines the match alternatives of the source and the destination of a rule.
+// Each alternative is a list of matches that must all be satisfied, hence the result contains an
+// alternative for each pair of source and destination alternatives, so that it can be rendered as
+// one firewall rule per alternative. A nil list of alternatives matches any traffic, while an empty
+// list of alternatives matches no traffic.
+func CombineMatchAlternatives(source, destination [][]networkingv1beta1firewall.Match) [][]networkingv1beta1firewall.Match {
+	if source == nil {
+		source = [][]networkingv1beta1firewall.Match{nil}
+	}
+	if destination == nil {
+		destination = [][]networkingv1beta1firewall.Match{nil}
+	}
+
+	combined := make([][]networkingv1beta1firewall.Match, 0, len(source)*len(destination))
+	for _, src := range source {
+		for _, dst := range destination {
+			matches := make([]networkingv1beta1firewall.Match, 0, len(src)+len(dst))
+			combined = append(combined, append(append(matches, src...), dst...))
+		}
+	}
+	return combined
+}
+
+// ForgePortMatchAlternatives extends the given matches with a protocol and port match for each of the given ports,
+// returning one alternative per port. Ports with a protocol the firewall cannot match are skipped, as well as
+// ports with the same protocol and number of a previous one.
+func ForgePortMatchAlternatives(
+	matches []networkingv1beta1firewall.Match,
+	ports []ServicePort,
+	position networkingv1beta1firewall.MatchPosition,
+) [][]networkingv1beta1firewall.Match {
+	alternatives := make([][]networkingv1beta1firewall.Match, 0, len(ports))
+	seen := make(map[ServicePort]struct{}, len(ports))
+	for _, port := range ports {
+		proto, ok := ForgeL4Proto(port.Protocol)
+		if !ok {
+			continue
+		}
+
+		key := ServicePort{Protocol: port.Protocol, Port: port.Port}
+		if _, found := seen[key]; found {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		alternative := make([]networkingv1beta1firewall.Match, 0, len(matches)+2)
+		alternative = append(alternative, matches...)
+		alternative = append(alternative, networkingv1beta1firewall.Match{
+			Proto: &networkingv1beta1firewall.MatchProto{Value: proto},
+			Op:    networkingv1beta1firewall.MatchOperationEq,
+		}, networkingv1beta1firewall.Match{
+			Port: &networkingv1beta1firewall.MatchPort{
+				Value:    strconv.Itoa(int(port.Port)),
+				Position: position,
+			},
+			Op: networkingv1beta1firewall.MatchOperationEq,
+		})
+		alternatives = append(alternatives, alternative)
+	}
+	return alternatives
+}
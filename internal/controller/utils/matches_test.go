@@ -0,0 +1,93 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Matches Utilities", func() {
+	Describe("CombineMatchAlternatives", func() {
+		var (
+			srcIP = networkingv1beta1firewall.Match{
+				IP: &networkingv1beta1firewall.MatchIP{Value: "10.0.0.0/8", Position: networkingv1beta1firewall.MatchPositionSrc},
+			}
+			dstTCP = networkingv1beta1firewall.Match{
+				Proto: &networkingv1beta1firewall.MatchProto{Value: networkingv1beta1firewall.L4ProtoTCP},
+			}
+			dstUDP = networkingv1beta1firewall.Match{
+				Proto: &networkingv1beta1firewall.MatchProto{Value: networkingv1beta1firewall.L4ProtoUDP},
+			}
+		)
+
+		It("should return a single empty alternative when both parties match anything", func() {
+			Expect(CombineMatchAlternatives(nil, nil)).To(Equal([][]networkingv1beta1firewall.Match{{}}))
+		})
+
+		It("should keep the alternatives of a single party", func() {
+			result := CombineMatchAlternatives(nil, [][]networkingv1beta1firewall.Match{{dstTCP}, {dstUDP}})
+			Expect(result).To(Equal([][]networkingv1beta1firewall.Match{{dstTCP}, {dstUDP}}))
+		})
+
+		It("should combine each source alternative with each destination alternative", func() {
+			result := CombineMatchAlternatives(
+				[][]networkingv1beta1firewall.Match{{srcIP}},
+				[][]networkingv1beta1firewall.Match{{dstTCP}, {dstUDP}},
+			)
+			Expect(result).To(Equal([][]networkingv1beta1firewall.Match{{srcIP, dstTCP}, {srcIP, dstUDP}}))
+		})
+
+		It("should not share the underlying arrays between alternatives", func() {
+			source := [][]networkingv1beta1firewall.Match{make([]networkingv1beta1firewall.Match, 1, 4)}
+			source[0][0] = srcIP
+			result := CombineMatchAlternatives(source, [][]networkingv1beta1firewall.Match{{dstTCP}, {dstUDP}})
+			Expect(result[0]).To(Equal([]networkingv1beta1firewall.Match{srcIP, dstTCP}))
+			Expect(result[1]).To(Equal([]networkingv1beta1firewall.Match{srcIP, dstUDP}))
+		})
+	})
+
+	Describe("ForgePortMatchAlternatives", func() {
+		ipMatch := networkingv1beta1firewall.Match{
+			IP: &networkingv1beta1firewall.MatchIP{Value: "@svc", Position: networkingv1beta1firewall.MatchPositionDst},
+		}
+
+		It("should create one alternative per protocol and port", func() {
+			result := ForgePortMatchAlternatives([]networkingv1beta1firewall.Match{ipMatch}, []ServicePort{
+				{Name: "dns-tcp", Protocol: corev1.ProtocolTCP, Port: 53},
+				{Name: "dns", Protocol: corev1.ProtocolUDP, Port: 53},
+				{Name: "other", Protocol: corev1.ProtocolUDP, Port: 53},
+				{Name: "sctp", Protocol: corev1.ProtocolSCTP, Port: 9000},
+			}, networkingv1beta1firewall.MatchPositionDst)
+
+			Expect(result).To(HaveLen(2))
+			for i, proto := range []networkingv1beta1firewall.L4Proto{networkingv1beta1firewall.L4ProtoTCP, networkingv1beta1firewall.L4ProtoUDP} {
+				Expect(result[i]).To(HaveLen(3))
+				Expect(result[i][0]).To(Equal(ipMatch))
+				Expect(result[i][1].Proto.Value).To(Equal(proto))
+				Expect(result[i][2].Port.Value).To(Equal("53"))
+				Expect(result[i][2].Port.Position).To(Equal(networkingv1beta1firewall.MatchPositionDst))
+			}
+		})
+
+		It("should match nothing when no port can be matched", func() {
+			result := ForgePortMatchAlternatives(nil, nil, networkingv1beta1firewall.MatchPositionDst)
+			Expect(result).NotTo(BeNil())
+			Expect(result).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,101 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
+)
+
+const (
+	// maxNftablesNameLength is the maximum length of the nftables table and chain names
+	// generated by the connectivity engine. It is well below the kernel limit (NFT_NAME_MAXLEN)
+	// to keep the names readable when listing the ruleset.
+	maxNftablesNameLength = 63
+
+	// maxSetNameLength is the maximum length of the nftables set names generated by the
+	// connectivity engine. It matches the set name limit of older kernels (NFT_SET_MAXNAMELEN),
+	// which is the strictest one.
+	maxSetNameLength = 31
+
+	// nameHashLength is the number of hexadecimal characters of the hash appended to the table and chain names.
+	nameHashLength = 10
+
+	// setNameHashLength is the number of hexadecimal characters of the hash appended to the set names.
+	setNameHashLength = 8
+)
+
+// ForgeClusterScopedName generates a name unique to the given cluster ID, in the format
+// <prefix>-<cluster-id>-<hash>. The cluster ID is truncated if the name would exceed
+// maxNftablesNameLength characters, while the hash of the full cluster ID guarantees that
+// different clusters always produce different names.
+func ForgeClusterScopedName(prefix, clusterID string) string {
+	return forgeHashedName(prefix, clusterID, maxNftablesNameLength, nameHashLength)
+}
+
+// ForgeHookScopedValue scopes the value the name of a chain is generated from to the given hook.
+// The chains attached to the default hook keep the names they had before the hook was configurable.
+func ForgeHookScopedValue(value, hook string) string {
+	if hook == options.DefaultChainHook {
+		return value
+	}
+	return value + "/" + hook
+}
+
+// ForgeSetName generates the name of the nftables set representing the given value
+// (e.g., a namespace name), in the format <prefix>-<value>-<hash>. The value is sanitized
+// and truncated to fit maxSetNameLength characters, while the hash of the original value
+// guarantees that values colliding after sanitization or truncation produce different names.
+func ForgeSetName(prefix, value string) string {
+	return forgeHashedName(prefix, value, maxSetNameLength, setNameHashLength)
+}
+
+// ForgeSetReference returns the expression referencing the given set in a match rule.
+func ForgeSetReference(setName string) string {
+	return "@" + setName
+}
+
+// forgeHashedName generates a name in the format <prefix>-<value>-<hash>, where value is
+// sanitized and truncated so that the name does not exceed maxLength characters.
+func forgeHashedName(prefix, value string, maxLength, hashLength int) string {
+	sum := sha256.Sum256([]byte(value))
+	hash := hex.EncodeToString(sum[:])[:hashLength]
+
+	readable := strings.Trim(sanitizeName(value), "-_")
+	if available := maxLength - len(prefix) - len(hash) - 2; len(readable) > available {
+		readable = strings.TrimRight(readable[:max(available, 0)], "-_")
+	}
+
+	if readable == "" {
+		return fmt.Sprintf("%s-%s", prefix, hash)
+	}
+	return fmt.Sprintf("%s-%s-%s", prefix, readable, hash)
+}
+
+// sanitizeName replaces the characters not allowed in nftables identifiers with underscores.
+func sanitizeName(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, value)
+}
@@ -0,0 +1,91 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Names Utilities", func() {
+	Describe("ForgeClusterScopedName", func() {
+		It("should include the prefix and the cluster ID", func() {
+			name := ForgeClusterScopedName("connectivity-gw", "cluster-a")
+			Expect(name).To(HavePrefix("connectivity-gw-cluster-a-"))
+			Expect(name).To(HaveLen(len("connectivity-gw-cluster-a-") + nameHashLength))
+		})
+
+		It("should be deterministic", func() {
+			Expect(ForgeClusterScopedName("prefix", "cluster-a")).To(Equal(ForgeClusterScopedName("prefix", "cluster-a")))
+		})
+
+		It("should generate different names for different clusters", func() {
+			Expect(ForgeClusterScopedName("prefix", "cluster-a")).NotTo(Equal(ForgeClusterScopedName("prefix", "cluster-b")))
+		})
+
+		It("should not exceed the maximum length for long cluster IDs", func() {
+			longID := strings.Repeat("a", 253)
+			name := ForgeClusterScopedName("connectivity-fabric-filter", longID)
+			Expect(len(name)).To(BeNumerically("<=", maxNftablesNameLength))
+			Expect(name).To(HavePrefix("connectivity-fabric-filter-aaa"))
+		})
+
+		It("should distinguish long cluster IDs sharing the same prefix", func() {
+			base := strings.Repeat("a", 100)
+			Expect(ForgeClusterScopedName("prefix", base+"-1")).NotTo(Equal(ForgeClusterScopedName("prefix", base+"-2")))
+		})
+
+		It("should not leave dangling hyphens when truncating", func() {
+			id := strings.Repeat("a", 35) + "-" + strings.Repeat("b", 40)
+			name := ForgeClusterScopedName("prefix-with-sixteen", id)
+			Expect(name).NotTo(ContainSubstring("--"))
+		})
+	})
+
+	Describe("ForgeSetName", func() {
+		It("should include the prefix and the value", func() {
+			name := ForgeSetName("ns", "default")
+			Expect(name).To(HavePrefix("ns-default-"))
+			Expect(name).To(HaveLen(len("ns-default-") + setNameHashLength))
+		})
+
+		It("should not exceed the maximum set name length", func() {
+			name := ForgeSetName("ns", strings.Repeat("a", 63))
+			Expect(len(name)).To(BeNumerically("<=", maxSetNameLength))
+		})
+
+		It("should distinguish values colliding after truncation", func() {
+			base := strings.Repeat("a", 40)
+			Expect(ForgeSetName("ns", base+"-x")).NotTo(Equal(ForgeSetName("ns", base+"-y")))
+		})
+
+		It("should sanitize invalid characters", func() {
+			name := ForgeSetName("fqdn", "api.example.com")
+			Expect(name).To(HavePrefix("fqdn-api_example_com-"))
+		})
+
+		It("should distinguish values colliding after sanitization", func() {
+			Expect(ForgeSetName("fqdn", "a.b")).NotTo(Equal(ForgeSetName("fqdn", "a_b")))
+		})
+	})
+
+	Describe("ForgeSetReference", func() {
+		It("should prepend the set marker", func() {
+			Expect(ForgeSetReference("ns-default-12345678")).To(Equal("@ns-default-12345678"))
+		})
+	})
+})
@@ -0,0 +1,574 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/netip"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrNotExpressible is returned when the traffic denied by a rule cannot be excluded from the peers or the ports
+// of a NetworkPolicy rule, e.g. when only some of its ports are denied.
+var ErrNotExpressible = errors.New("the denied traffic cannot be excluded from the NetworkPolicy")
+
+// IntersectNetworkPolicyPeers returns the NetworkPolicy peers matching the traffic matched by both the given
+// lists of peers of a NetworkPolicy in the given namespace. An empty list of peers matches any traffic.
+// The intersection of peers that cannot be expressed as a peer (e.g., an IP block and a selector) is
+// considered empty, so that the result never matches traffic not matched by both lists. It returns false
+// if the intersection is empty.
+func IntersectNetworkPolicyPeers(a, b []networkingv1.NetworkPolicyPeer, namespace string) ([]networkingv1.NetworkPolicyPeer, bool) {
+	switch {
+	case len(a) == 0:
+		return clonePeers(b), true
+	case len(b) == 0:
+		return clonePeers(a), true
+	}
+
+	var peers []networkingv1.NetworkPolicyPeer
+	for i := range a {
+		for j := range b {
+			peer, ok := intersectNetworkPolicyPeer(&a[i], &b[j], namespace)
+			if ok && !slices.ContainsFunc(peers, func(p networkingv1.NetworkPolicyPeer) bool { return equality.Semantic.DeepEqual(p, peer) }) {
+				peers = append(peers, peer)
+			}
+		}
+	}
+	return peers, len(peers) > 0
+}
+
+// IntersectNetworkPolicyPorts returns the NetworkPolicy ports matching the traffic matched by both the given
+// lists of ports. An empty list of ports matches any port. Only the ports listed by both lists are kept, hence
+// the result never matches traffic not matched by both lists. It returns false if the intersection is empty.
+func IntersectNetworkPolicyPorts(a, b []networkingv1.NetworkPolicyPort) ([]networkingv1.NetworkPolicyPort, bool) {
+	switch {
+	case len(a) == 0:
+		return clonePorts(b), true
+	case len(b) == 0:
+		return clonePorts(a), true
+	}
+
+	var ports []networkingv1.NetworkPolicyPort
+	for i := range a {
+		if slices.ContainsFunc(b, func(port networkingv1.NetworkPolicyPort) bool { return samePort(&a[i], &port) }) {
+			ports = append(ports, *a[i].DeepCopy())
+		}
+	}
+	return ports, len(ports) > 0
+}
+
+// intersectNetworkPolicyPeer returns the peer matching the traffic matched by both the given peers
+// of a NetworkPolicy in the given namespace, if it can be expressed as a peer.
+func intersectNetworkPolicyPeer(a, b *networkingv1.NetworkPolicyPeer, namespace string) (networkingv1.NetworkPolicyPeer, bool) {
+	switch {
+	case a.IPBlock != nil && b.IPBlock != nil:
+		block, ok := intersectIPBlocks(a.IPBlock, b.IPBlock)
+		return networkingv1.NetworkPolicyPeer{IPBlock: block}, ok
+	case a.IPBlock != nil || b.IPBlock != nil:
+		return networkingv1.NetworkPolicyPeer{}, false
+	}
+
+	namespaces, ok := intersectLabelSelectors(peerNamespaceSelector(a, namespace), peerNamespaceSelector(b, namespace))
+	if !ok {
+		return networkingv1.NetworkPolicyPeer{}, false
+	}
+	pods, ok := intersectLabelSelectors(a.PodSelector, b.PodSelector)
+	if !ok {
+		return networkingv1.NetworkPolicyPeer{}, false
+	}
+	return networkingv1.NetworkPolicyPeer{NamespaceSelector: namespaces, PodSelector: pods}, true
+}
+
+// intersectLabelSelectors returns the label selector matching the objects matched by both the given ones.
+// A nil selector matches any object. It returns false if the selectors require different values for the same label.
+func intersectLabelSelectors(a, b *metav1.LabelSelector) (*metav1.LabelSelector, bool) {
+	switch {
+	case a == nil:
+		return b.DeepCopy(), true
+	case b == nil:
+		return a.DeepCopy(), true
+	}
+
+	selector := a.DeepCopy()
+	for key, value := range b.MatchLabels {
+		if existing, found := selector.MatchLabels[key]; found && existing != value {
+			return nil, false
+		}
+		if selector.MatchLabels == nil {
+			selector.MatchLabels = make(map[string]string, len(b.MatchLabels))
+		}
+		selector.MatchLabels[key] = value
+	}
+	for i := range b.MatchExpressions {
+		if !slices.ContainsFunc(selector.MatchExpressions, func(req metav1.LabelSelectorRequirement) bool {
+			return equality.Semantic.DeepEqual(req, b.MatchExpressions[i])
+		}) {
+			selector.MatchExpressions = append(selector.MatchExpressions, *b.MatchExpressions[i].DeepCopy())
+		}
+	}
+	return selector, true
+}
+
+// intersectIPBlocks returns the IP block matching the addresses matched by both the given ones.
+// As CIDRs are either nested or disjoint, the result is the narrower CIDR, excluding the exceptions
+// of both blocks within it. It returns false if the blocks do not overlap or the result is fully excluded.
+func intersectIPBlocks(a, b *networkingv1.IPBlock) (*networkingv1.IPBlock, bool) {
+	prefixA, errA := netip.ParsePrefix(a.CIDR)
+	prefixB, errB := netip.ParsePrefix(b.CIDR)
+	if errA != nil || errB != nil || !prefixA.Overlaps(prefixB) {
+		return nil, false
+	}
+
+	prefix, block := prefixA.Masked(), a
+	if prefixB.Bits() > prefixA.Bits() {
+		prefix, block = prefixB.Masked(), b
+	}
+
+	exceptions := map[netip.Prefix]struct{}{}
+	for _, except := range slices.Concat(a.Except, b.Except) {
+		exception, err := netip.ParsePrefix(except)
+		if err != nil || !exception.Overlaps(prefix) {
+			continue
+		}
+		exception = exception.Masked()
+		if exception.Bits() <= prefix.Bits() {
+			// The exception covers the whole block.
+			return nil, false
+		}
+		exceptions[exception] = struct{}{}
+	}
+
+	return &networkingv1.IPBlock{CIDR: block.CIDR, Except: sortedPrefixes(exceptions)}, true
+}
+
+// sortedPrefixes returns the given prefixes as strings, sorted by address and length.
+func sortedPrefixes(prefixes map[netip.Prefix]struct{}) []string {
+	var sorted []string
+	for _, prefix := range slices.SortedFunc(maps.Keys(prefixes), func(x, y netip.Prefix) int {
+		return cmp.Or(x.Addr().Compare(y.Addr()), cmp.Compare(x.Bits(), y.Bits()))
+	}) {
+		sorted = append(sorted, prefix.String())
+	}
+	return sorted
+}
+
+// peerNamespaceSelector returns the namespace selector of the given peer of a NetworkPolicy in the given namespace:
+// a peer without a namespace selector selects the pods of the namespace of the NetworkPolicy.
+func peerNamespaceSelector(peer *networkingv1.NetworkPolicyPeer, namespace string) *metav1.LabelSelector {
+	if peer.NamespaceSelector == nil {
+		return &metav1.LabelSelector{MatchLabels: map[string]string{corev1.LabelMetadataName: namespace}}
+	}
+	return peer.NamespaceSelector
+}
+
+// anyNetworkPolicyPeers returns the peers matching any traffic, expressed as peers the denied traffic
+// can be excluded from: the pods of any namespace, and any address.
+func anyNetworkPolicyPeers() []networkingv1.NetworkPolicyPeer {
+	return []networkingv1.NetworkPolicyPeer{
+		{NamespaceSelector: &metav1.LabelSelector{}},
+		{IPBlock: &networkingv1.IPBlock{CIDR: "0.0.0.0/0"}},
+		{IPBlock: &networkingv1.IPBlock{CIDR: "::/0"}},
+	}
+}
+
+// SubtractNetworkPolicyPeers returns the NetworkPolicy peers matching the traffic matched by the given peers but
+// not by the denied ones, of a NetworkPolicy in the given namespace. An empty list of peers matches any traffic.
+// The selectors are narrowed by negating each of the requirements of the denied selectors, and the IP blocks by
+// excepting the denied blocks and the addresses of the denied pods, as the gateway firewall matches them. The
+// pods selected by label cannot be excepted by address, hence it returns ErrNotExpressible if their addresses
+// are denied by an IP block. It returns false if the difference is empty.
+func SubtractNetworkPolicyPeers(
+	ctx context.Context, cl client.Client, peers, denied []networkingv1.NetworkPolicyPeer, namespace string,
+) ([]networkingv1.NetworkPolicyPeer, bool, error) {
+	if len(denied) == 0 {
+		return nil, false, nil
+	}
+
+	result := clonePeers(peers)
+	if len(result) == 0 {
+		result = anyNetworkPolicyPeers()
+	}
+	for i := range denied {
+		var narrowed []networkingv1.NetworkPolicyPeer
+		for j := range result {
+			difference, err := subtractNetworkPolicyPeer(ctx, cl, &result[j], &denied[i], namespace)
+			if err != nil {
+				return nil, false, err
+			}
+			for _, peer := range difference {
+				if !slices.ContainsFunc(narrowed, func(p networkingv1.NetworkPolicyPeer) bool { return equality.Semantic.DeepEqual(p, peer) }) {
+					narrowed = append(narrowed, peer)
+				}
+			}
+		}
+		result = narrowed
+	}
+	return result, len(result) > 0, nil
+}
+
+// subtractNetworkPolicyPeer returns the peers matching the traffic matched by the given peer but not by the
+// denied one, of a NetworkPolicy in the given namespace. The peer is returned unchanged if they are disjoint.
+func subtractNetworkPolicyPeer(
+	ctx context.Context, cl client.Client, peer, denied *networkingv1.NetworkPolicyPeer, namespace string,
+) ([]networkingv1.NetworkPolicyPeer, error) {
+	switch {
+	case peer.IPBlock != nil && denied.IPBlock != nil:
+		return forgeIPBlockPeers(subtractIPBlocks(peer.IPBlock, denied.IPBlock)), nil
+
+	case peer.IPBlock != nil:
+		// The addresses of the denied pods are excepted from the block.
+		addresses, err := getPeerPodAddresses(ctx, cl, denied, namespace)
+		if err != nil {
+			return nil, err
+		}
+		blocks := []*networkingv1.IPBlock{peer.IPBlock.DeepCopy()}
+		for _, address := range addresses {
+			excluded := &networkingv1.IPBlock{CIDR: netip.PrefixFrom(address, address.BitLen()).String()}
+			var narrowed []*networkingv1.IPBlock
+			for _, block := range blocks {
+				narrowed = append(narrowed, subtractIPBlocks(block, excluded)...)
+			}
+			blocks = narrowed
+		}
+		return forgeIPBlockPeers(blocks), nil
+
+	case denied.IPBlock != nil:
+		// The pods selected by label cannot be excepted by address.
+		addresses, err := getPeerPodAddresses(ctx, cl, peer, namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, address := range addresses {
+			if ipBlockContains(denied.IPBlock, address) {
+				return nil, fmt.Errorf("%w: the address %s of a selected pod is denied by the IP block %s",
+					ErrNotExpressible, address, denied.IPBlock.CIDR)
+			}
+		}
+		return []networkingv1.NetworkPolicyPeer{*peer.DeepCopy()}, nil
+
+	default:
+		return subtractSelectorPeer(peer, denied, namespace), nil
+	}
+}
+
+// subtractSelectorPeer returns the peers selecting the pods selected by the given peer but not by the denied one,
+// of a NetworkPolicy in the given namespace: the pods of the namespaces not matching one of the namespace
+// requirements of the denied peer, and the pods of its namespaces not matching one of its pod requirements.
+func subtractSelectorPeer(peer, denied *networkingv1.NetworkPolicyPeer, namespace string) []networkingv1.NetworkPolicyPeer {
+	namespaces, pods := peerNamespaceSelector(peer, namespace), peer.PodSelector
+	deniedNamespaces, deniedPods := peerNamespaceSelector(denied, namespace), denied.PodSelector
+
+	sharedNamespaces, ok := intersectLabelSelectors(namespaces, deniedNamespaces)
+	if !ok || contradictoryLabelSelector(sharedNamespaces) {
+		return []networkingv1.NetworkPolicyPeer{*peer.DeepCopy()}
+	}
+	if sharedPods, ok := intersectLabelSelectors(pods, deniedPods); !ok || contradictoryLabelSelector(sharedPods) {
+		return []networkingv1.NetworkPolicyPeer{*peer.DeepCopy()}
+	}
+
+	var result []networkingv1.NetworkPolicyPeer
+	for _, requirement := range labelSelectorRequirements(deniedNamespaces) {
+		if selector, ok := requireLabel(namespaces, negateRequirement(requirement)); ok {
+			result = append(result, networkingv1.NetworkPolicyPeer{NamespaceSelector: selector, PodSelector: pods.DeepCopy()})
+		}
+	}
+	for _, requirement := range labelSelectorRequirements(deniedPods) {
+		if selector, ok := requireLabel(pods, negateRequirement(requirement)); ok {
+			result = append(result, networkingv1.NetworkPolicyPeer{NamespaceSelector: sharedNamespaces.DeepCopy(), PodSelector: selector})
+		}
+	}
+	return result
+}
+
+// labelSelectorRequirements returns the requirements of the given label selector, including its match labels.
+// A nil selector has no requirements, as it matches any object.
+func labelSelectorRequirements(selector *metav1.LabelSelector) []metav1.LabelSelectorRequirement {
+	if selector == nil {
+		return nil
+	}
+
+	var requirements []metav1.LabelSelectorRequirement
+	for _, key := range slices.Sorted(maps.Keys(selector.MatchLabels)) {
+		requirements = append(requirements, metav1.LabelSelectorRequirement{
+			Key:      key,
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   []string{selector.MatchLabels[key]},
+		})
+	}
+	return append(requirements, selector.MatchExpressions...)
+}
+
+// negateRequirement returns the requirement matching the objects not matched by the given one.
+func negateRequirement(requirement metav1.LabelSelectorRequirement) metav1.LabelSelectorRequirement {
+	negated := *requirement.DeepCopy()
+	switch requirement.Operator {
+	case metav1.LabelSelectorOpIn:
+		negated.Operator = metav1.LabelSelectorOpNotIn
+	case metav1.LabelSelectorOpNotIn:
+		negated.Operator = metav1.LabelSelectorOpIn
+	case metav1.LabelSelectorOpExists:
+		negated.Operator = metav1.LabelSelectorOpDoesNotExist
+	case metav1.LabelSelectorOpDoesNotExist:
+		negated.Operator = metav1.LabelSelectorOpExists
+	}
+	return negated
+}
+
+// requireLabel returns the given label selector, a nil one matching any object, with the given requirement added.
+// It returns false if the result cannot match any object.
+func requireLabel(selector *metav1.LabelSelector, requirement metav1.LabelSelectorRequirement) (*metav1.LabelSelector, bool) {
+	result := &metav1.LabelSelector{}
+	if selector != nil {
+		result = selector.DeepCopy()
+	}
+	if !slices.ContainsFunc(result.MatchExpressions, func(req metav1.LabelSelectorRequirement) bool {
+		return equality.Semantic.DeepEqual(req, requirement)
+	}) {
+		result.MatchExpressions = append(result.MatchExpressions, requirement)
+	}
+	return result, !contradictoryLabelSelector(result)
+}
+
+// contradictoryLabelSelector returns whether the given label selector cannot match any object,
+// since two of its requirements on the same label contradict each other.
+func contradictoryLabelSelector(selector *metav1.LabelSelector) bool {
+	requirements := labelSelectorRequirements(selector)
+	for i := range requirements {
+		for j := range requirements {
+			if i != j && requirements[i].Key == requirements[j].Key && contradictoryRequirements(&requirements[i], &requirements[j]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// contradictoryRequirements returns whether no object can match both the given requirements on the same label.
+func contradictoryRequirements(a, b *metav1.LabelSelectorRequirement) bool {
+	switch {
+	case a.Operator == metav1.LabelSelectorOpIn && b.Operator == metav1.LabelSelectorOpIn:
+		return !slices.ContainsFunc(a.Values, func(value string) bool { return slices.Contains(b.Values, value) })
+	case a.Operator == metav1.LabelSelectorOpIn && b.Operator == metav1.LabelSelectorOpNotIn:
+		return !slices.ContainsFunc(a.Values, func(value string) bool { return !slices.Contains(b.Values, value) })
+	case a.Operator == metav1.LabelSelectorOpDoesNotExist:
+		return b.Operator == metav1.LabelSelectorOpIn || b.Operator == metav1.LabelSelectorOpExists
+	default:
+		return false
+	}
+}
+
+// subtractIPBlocks returns the IP blocks matching the addresses matched by the given block but not by the denied
+// one. The denied block is excepted from the block, while the exceptions of the denied block within the block
+// are still matched. The block is returned unchanged if they do not overlap.
+func subtractIPBlocks(block, denied *networkingv1.IPBlock) []*networkingv1.IPBlock {
+	prefix, errBlock := netip.ParsePrefix(block.CIDR)
+	deniedPrefix, errDenied := netip.ParsePrefix(denied.CIDR)
+	if errBlock != nil || errDenied != nil || !prefix.Overlaps(deniedPrefix) {
+		return []*networkingv1.IPBlock{block.DeepCopy()}
+	}
+
+	var blocks []*networkingv1.IPBlock
+	if deniedPrefix.Bits() > prefix.Bits() {
+		// The denied block is nested in the block, hence it is excepted from it, replacing the exceptions within it.
+		deniedPrefix = deniedPrefix.Masked()
+		exceptions := map[netip.Prefix]struct{}{deniedPrefix: {}}
+		for _, except := range block.Except {
+			exception, err := netip.ParsePrefix(except)
+			if err != nil {
+				continue
+			}
+			exception = exception.Masked()
+			switch {
+			case exception.Overlaps(deniedPrefix) && exception.Bits() <= deniedPrefix.Bits():
+				// The denied block is excepted already.
+				delete(exceptions, deniedPrefix)
+				exceptions[exception] = struct{}{}
+			case !exception.Overlaps(deniedPrefix):
+				exceptions[exception] = struct{}{}
+			}
+		}
+		blocks = append(blocks, &networkingv1.IPBlock{CIDR: block.CIDR, Except: sortedPrefixes(exceptions)})
+	}
+
+	for _, except := range denied.Except {
+		if allowed, ok := intersectIPBlocks(block, &networkingv1.IPBlock{CIDR: except}); ok {
+			blocks = append(blocks, allowed)
+		}
+	}
+	return blocks
+}
+
+// ipBlockContains returns whether the given IP block matches the given address.
+func ipBlockContains(block *networkingv1.IPBlock, address netip.Addr) bool {
+	prefix, err := netip.ParsePrefix(block.CIDR)
+	if err != nil || !prefix.Contains(address) {
+		return false
+	}
+	return !slices.ContainsFunc(block.Except, func(except string) bool {
+		exception, err := netip.ParsePrefix(except)
+		return err == nil && exception.Contains(address)
+	})
+}
+
+// forgeIPBlockPeers returns a NetworkPolicy peer for each of the given IP blocks.
+func forgeIPBlockPeers(blocks []*networkingv1.IPBlock) []networkingv1.NetworkPolicyPeer {
+	peers := make([]networkingv1.NetworkPolicyPeer, 0, len(blocks))
+	for _, block := range blocks {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{IPBlock: block})
+	}
+	return peers
+}
+
+// getPeerPodAddresses returns the addresses of the pods selected by the given selector peer
+// of a NetworkPolicy in the given namespace.
+func getPeerPodAddresses(
+	ctx context.Context, cl client.Client, peer *networkingv1.NetworkPolicyPeer, namespace string,
+) ([]netip.Addr, error) {
+	namespaceSelector, err := metav1.LabelSelectorAsSelector(peerNamespaceSelector(peer, namespace))
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace selector: %w", err)
+	}
+	podSelector := labels.Everything()
+	if peer.PodSelector != nil {
+		if podSelector, err = metav1.LabelSelectorAsSelector(peer.PodSelector); err != nil {
+			return nil, fmt.Errorf("invalid pod selector: %w", err)
+		}
+	}
+
+	var namespaces corev1.NamespaceList
+	if err := cl.List(ctx, &namespaces, client.MatchingLabelsSelector{Selector: namespaceSelector}); err != nil {
+		return nil, fmt.Errorf("unable to list the namespaces: %w", err)
+	}
+
+	var addresses []netip.Addr
+	for i := range namespaces.Items {
+		var pods corev1.PodList
+		if err := cl.List(ctx, &pods, client.InNamespace(namespaces.Items[i].Name), client.MatchingLabelsSelector{Selector: podSelector}); err != nil {
+			return nil, fmt.Errorf("unable to list the pods in namespace %q: %w", namespaces.Items[i].Name, err)
+		}
+		for _, ip := range GetPodIPs(pods.Items) {
+			if address, err := netip.ParseAddr(ip); err == nil {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// SplitNetworkPolicyPorts splits the given NetworkPolicy ports into the ones whose traffic is matched by the denied
+// ports as well, and the ones whose traffic is not. An empty list of ports matches any port. It returns false if
+// the traffic of a port is only partially matched by the denied ports, e.g. any port but a denied one, which
+// cannot be expressed as NetworkPolicy ports.
+func SplitNetworkPolicyPorts(ports, denied []networkingv1.NetworkPolicyPort) (covered, uncovered []networkingv1.NetworkPolicyPort, ok bool) {
+	switch {
+	case len(denied) == 0:
+		return clonePorts(ports), nil, true
+	case len(ports) == 0:
+		return nil, nil, false
+	}
+
+	for i := range ports {
+		switch {
+		case slices.ContainsFunc(denied, func(port networkingv1.NetworkPolicyPort) bool { return portCovers(&port, &ports[i]) }):
+			covered = append(covered, *ports[i].DeepCopy())
+		case slices.ContainsFunc(denied, func(port networkingv1.NetworkPolicyPort) bool { return portsOverlap(&port, &ports[i]) }):
+			return nil, nil, false
+		default:
+			uncovered = append(uncovered, *ports[i].DeepCopy())
+		}
+	}
+	return covered, uncovered, true
+}
+
+// portProtocol returns the protocol of the given NetworkPolicy port, which defaults to TCP.
+func portProtocol(port *networkingv1.NetworkPolicyPort) corev1.Protocol {
+	if port.Protocol == nil {
+		return corev1.ProtocolTCP
+	}
+	return *port.Protocol
+}
+
+// portRange returns the range of port numbers matched by the given NetworkPolicy port.
+// It returns false for the named ports, whose numbers are not known.
+func portRange(port *networkingv1.NetworkPolicyPort) (first, last int32, ok bool) {
+	switch {
+	case port.Port == nil:
+		return 1, 65535, true
+	case port.Port.Type != intstr.Int:
+		return 0, 0, false
+	}
+	first = port.Port.IntVal
+	return first, ptr.Deref(port.EndPort, first), true
+}
+
+// portCovers returns whether the traffic of the given NetworkPolicy port is matched by the covering one.
+func portCovers(covering, port *networkingv1.NetworkPolicyPort) bool {
+	if portProtocol(covering) != portProtocol(port) {
+		return false
+	}
+	coveringFirst, coveringLast, okCovering := portRange(covering)
+	first, last, ok := portRange(port)
+	if !okCovering || !ok {
+		return covering.Port == nil || samePort(covering, port)
+	}
+	return coveringFirst <= first && last <= coveringLast
+}
+
+// portsOverlap returns whether some traffic may be matched by both the given NetworkPolicy ports.
+// The named ports are considered to overlap any port of their protocol, as their numbers are not known.
+func portsOverlap(a, b *networkingv1.NetworkPolicyPort) bool {
+	if portProtocol(a) != portProtocol(b) {
+		return false
+	}
+	firstA, lastA, okA := portRange(a)
+	firstB, lastB, okB := portRange(b)
+	return !okA || !okB || (firstA <= lastB && firstB <= lastA)
+}
+
+// samePort checks whether the given NetworkPolicy ports match the same traffic.
+func samePort(a, b *networkingv1.NetworkPolicyPort) bool {
+	return portProtocol(a) == portProtocol(b) &&
+		equality.Semantic.DeepEqual(a.Port, b.Port) &&
+		equality.Semantic.DeepEqual(a.EndPort, b.EndPort)
+}
+
+func clonePeers(peers []networkingv1.NetworkPolicyPeer) []networkingv1.NetworkPolicyPeer {
+	var cloned []networkingv1.NetworkPolicyPeer
+	for i := range peers {
+		cloned = append(cloned, *peers[i].DeepCopy())
+	}
+	return cloned
+}
+
+func clonePorts(ports []networkingv1.NetworkPolicyPort) []networkingv1.NetworkPolicyPort {
+	var cloned []networkingv1.NetworkPolicyPort
+	for i := range ports {
+		cloned = append(cloned, *ports[i].DeepCopy())
+	}
+	return cloned
+}
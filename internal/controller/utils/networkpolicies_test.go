@@ -0,0 +1,245 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("NetworkPolicy Utilities", func() {
+	const namespace = "shop"
+
+	namespacePeer := func(name string) networkingv1.NetworkPolicyPeer {
+		return networkingv1.NetworkPolicyPeer{NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{corev1.LabelMetadataName: name},
+		}}
+	}
+	blockPeer := func(cidr string, except ...string) networkingv1.NetworkPolicyPeer {
+		return networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr, Except: except}}
+	}
+	port := func(protocol corev1.Protocol, number int32) networkingv1.NetworkPolicyPort {
+		return networkingv1.NetworkPolicyPort{Protocol: ptr.To(protocol), Port: ptr.To(intstr.FromInt32(number))}
+	}
+
+	Describe("IntersectNetworkPolicyPeers", func() {
+		It("should treat an empty list of peers as matching any traffic", func() {
+			peers, ok := IntersectNetworkPolicyPeers(nil, []networkingv1.NetworkPolicyPeer{namespacePeer("payments")}, namespace)
+			Expect(ok).To(BeTrue())
+			Expect(peers).To(Equal([]networkingv1.NetworkPolicyPeer{namespacePeer("payments")}))
+		})
+
+		It("should keep the narrower of nested IP blocks, with the exceptions within it", func() {
+			peers, ok := IntersectNetworkPolicyPeers(
+				[]networkingv1.NetworkPolicyPeer{blockPeer("10.0.0.0/8", "10.1.2.0/24", "10.2.0.0/16")},
+				[]networkingv1.NetworkPolicyPeer{blockPeer("10.1.0.0/16")},
+				namespace,
+			)
+			Expect(ok).To(BeTrue())
+			Expect(peers).To(Equal([]networkingv1.NetworkPolicyPeer{blockPeer("10.1.0.0/16", "10.1.2.0/24")}))
+		})
+
+		It("should not match disjoint or fully excluded IP blocks", func() {
+			_, ok := IntersectNetworkPolicyPeers(
+				[]networkingv1.NetworkPolicyPeer{blockPeer("10.0.0.0/16")},
+				[]networkingv1.NetworkPolicyPeer{blockPeer("10.1.0.0/16")},
+				namespace,
+			)
+			Expect(ok).To(BeFalse())
+
+			_, ok = IntersectNetworkPolicyPeers(
+				[]networkingv1.NetworkPolicyPeer{blockPeer("10.0.0.0/8", "10.1.0.0/16")},
+				[]networkingv1.NetworkPolicyPeer{blockPeer("10.1.1.0/24")},
+				namespace,
+			)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should combine the selectors, defaulting to the namespace of the NetworkPolicy", func() {
+			peers, ok := IntersectNetworkPolicyPeers(
+				[]networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{}}},
+				[]networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}}},
+				namespace,
+			)
+			Expect(ok).To(BeTrue())
+			Expect(peers).To(Equal([]networkingv1.NetworkPolicyPeer{{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{corev1.LabelMetadataName: namespace}},
+				PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			}}))
+		})
+
+		It("should not match selectors requiring different values or mixed with IP blocks", func() {
+			_, ok := IntersectNetworkPolicyPeers(
+				[]networkingv1.NetworkPolicyPeer{namespacePeer("payments"), blockPeer("10.0.0.0/8")},
+				[]networkingv1.NetworkPolicyPeer{namespacePeer("billing")},
+				namespace,
+			)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("SubtractNetworkPolicyPeers", func() {
+		var (
+			ctx context.Context
+			cl  client.Client
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			scheme := runtime.NewScheme()
+			Expect(corev1.AddToScheme(scheme)).To(Succeed())
+			cl = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+					Name: "payments", Labels: map[string]string{corev1.LabelMetadataName: "payments"},
+				}},
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "payments", Labels: map[string]string{"app": "api"}},
+					Status:     corev1.PodStatus{PodIP: "10.1.0.5"},
+				},
+			).Build()
+		})
+
+		It("should exclude the denied namespaces and the addresses of their pods from any traffic", func() {
+			peers, ok, err := SubtractNetworkPolicyPeers(ctx, cl, nil, []networkingv1.NetworkPolicyPeer{namespacePeer("payments")}, namespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(peers).To(Equal([]networkingv1.NetworkPolicyPeer{
+				{NamespaceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key: corev1.LabelMetadataName, Operator: metav1.LabelSelectorOpNotIn, Values: []string{"payments"},
+				}}}},
+				blockPeer("0.0.0.0/0", "10.1.0.5/32"),
+				blockPeer("::/0"),
+			}))
+		})
+
+		It("should except the denied IP blocks, still matching their exceptions", func() {
+			peers, ok, err := SubtractNetworkPolicyPeers(ctx, cl,
+				[]networkingv1.NetworkPolicyPeer{blockPeer("10.0.0.0/8")},
+				[]networkingv1.NetworkPolicyPeer{blockPeer("10.1.0.0/16", "10.1.2.0/24")},
+				namespace,
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(peers).To(Equal([]networkingv1.NetworkPolicyPeer{blockPeer("10.0.0.0/8", "10.1.0.0/16"), blockPeer("10.1.2.0/24")}))
+		})
+
+		It("should negate the pod requirements of the denied peers in their namespaces", func() {
+			peers, ok, err := SubtractNetworkPolicyPeers(ctx, cl,
+				[]networkingv1.NetworkPolicyPeer{namespacePeer("payments")},
+				[]networkingv1.NetworkPolicyPeer{{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{corev1.LabelMetadataName: "payments"}},
+					PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+				}},
+				namespace,
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(peers).To(Equal([]networkingv1.NetworkPolicyPeer{{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{corev1.LabelMetadataName: "payments"}},
+				PodSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key: "app", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"api"},
+				}}},
+			}}))
+		})
+
+		It("should keep the peers disjoint from the denied ones", func() {
+			allowed := []networkingv1.NetworkPolicyPeer{namespacePeer("billing"), blockPeer("192.168.0.0/16")}
+			peers, ok, err := SubtractNetworkPolicyPeers(ctx, cl, allowed,
+				[]networkingv1.NetworkPolicyPeer{namespacePeer("payments"), blockPeer("10.0.0.0/8")}, namespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(peers).To(Equal(allowed))
+		})
+
+		It("should not match any traffic once all of it is denied", func() {
+			_, ok, err := SubtractNetworkPolicyPeers(ctx, cl, []networkingv1.NetworkPolicyPeer{namespacePeer("payments")}, nil, namespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+
+			_, ok, err = SubtractNetworkPolicyPeers(ctx, cl,
+				[]networkingv1.NetworkPolicyPeer{namespacePeer("payments")},
+				[]networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{}}},
+				namespace,
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should fail if the addresses of the pods selected by label are denied", func() {
+			_, _, err := SubtractNetworkPolicyPeers(ctx, cl,
+				[]networkingv1.NetworkPolicyPeer{namespacePeer("payments")},
+				[]networkingv1.NetworkPolicyPeer{blockPeer("10.1.0.0/16")},
+				namespace,
+			)
+			Expect(err).To(MatchError(ErrNotExpressible))
+		})
+	})
+
+	Describe("SplitNetworkPolicyPorts", func() {
+		It("should split the ports matched by the denied ones from the others", func() {
+			covered, uncovered, ok := SplitNetworkPolicyPorts(
+				[]networkingv1.NetworkPolicyPort{port(corev1.ProtocolTCP, 80), port(corev1.ProtocolTCP, 443)},
+				[]networkingv1.NetworkPolicyPort{{Port: ptr.To(intstr.FromInt32(400)), EndPort: ptr.To[int32](500)}},
+			)
+			Expect(ok).To(BeTrue())
+			Expect(covered).To(Equal([]networkingv1.NetworkPolicyPort{port(corev1.ProtocolTCP, 443)}))
+			Expect(uncovered).To(Equal([]networkingv1.NetworkPolicyPort{port(corev1.ProtocolTCP, 80)}))
+		})
+
+		It("should not split the ports partially matched by the denied ones", func() {
+			_, _, ok := SplitNetworkPolicyPorts(nil, []networkingv1.NetworkPolicyPort{port(corev1.ProtocolTCP, 80)})
+			Expect(ok).To(BeFalse())
+
+			_, _, ok = SplitNetworkPolicyPorts(
+				[]networkingv1.NetworkPolicyPort{{Port: ptr.To(intstr.FromInt32(80)), EndPort: ptr.To[int32](90)}},
+				[]networkingv1.NetworkPolicyPort{port(corev1.ProtocolTCP, 85)},
+			)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("IntersectNetworkPolicyPorts", func() {
+		It("should treat an empty list of ports as matching any port", func() {
+			ports, ok := IntersectNetworkPolicyPorts([]networkingv1.NetworkPolicyPort{port(corev1.ProtocolTCP, 80)}, nil)
+			Expect(ok).To(BeTrue())
+			Expect(ports).To(Equal([]networkingv1.NetworkPolicyPort{port(corev1.ProtocolTCP, 80)}))
+		})
+
+		It("should keep the ports listed by both lists", func() {
+			ports, ok := IntersectNetworkPolicyPorts(
+				[]networkingv1.NetworkPolicyPort{port(corev1.ProtocolTCP, 80), port(corev1.ProtocolTCP, 443)},
+				[]networkingv1.NetworkPolicyPort{{Port: ptr.To(intstr.FromInt32(443))}, port(corev1.ProtocolUDP, 80)},
+			)
+			Expect(ok).To(BeTrue())
+			Expect(ports).To(Equal([]networkingv1.NetworkPolicyPort{port(corev1.ProtocolTCP, 443)}))
+
+			_, ok = IntersectNetworkPolicyPorts(
+				[]networkingv1.NetworkPolicyPort{port(corev1.ProtocolTCP, 80)},
+				[]networkingv1.NetworkPolicyPort{port(corev1.ProtocolUDP, 80)},
+			)
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
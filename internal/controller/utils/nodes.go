@@ -0,0 +1,61 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"slices"
+
+	"github.com/liqotech/liqo/pkg/consts"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetNodeAddresses returns the internal and external addresses of the physical nodes of the
+// local cluster, sorted and without duplicates. The virtual nodes representing the peered
+// clusters are excluded, since their addresses do not belong to the local cluster.
+func GetNodeAddresses(ctx context.Context, cl client.Client) ([]string, error) {
+	// Exclude the virtual nodes created by Liqo.
+	req, err := labels.NewRequirement(consts.TypeLabel, selection.NotEquals, []string{consts.TypeNode})
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes corev1.NodeList
+	if err := cl.List(ctx, &nodes, client.MatchingLabelsSelector{Selector: labels.NewSelector().Add(*req)}); err != nil {
+		return nil, err
+	}
+
+	addresses := make([]string, 0, len(nodes.Items))
+	for i := range nodes.Items {
+		addresses = append(addresses, NodeAddresses(&nodes.Items[i])...)
+	}
+
+	slices.Sort(addresses)
+	return slices.Compact(addresses), nil
+}
+
+// NodeAddresses returns the internal and external addresses of the given node.
+func NodeAddresses(node *corev1.Node) []string {
+	var addresses []string
+	for _, address := range node.Status.Addresses {
+		if address.Type == corev1.NodeInternalIP || address.Type == corev1.NodeExternalIP {
+			addresses = append(addresses, address.Address)
+		}
+	}
+	return addresses
+}
@@ -0,0 +1,77 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+
+	"github.com/liqotech/liqo/pkg/consts"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Nodes Utilities", func() {
+	var (
+		ctx    context.Context
+		scheme *runtime.Scheme
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		RegisterScheme(scheme)
+	})
+
+	newNode := func(name string, labels map[string]string, addresses ...corev1.NodeAddress) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Status:     corev1.NodeStatus{Addresses: addresses},
+		}
+	}
+
+	Describe("GetNodeAddresses", func() {
+		It("should return the internal and external addresses of the physical nodes", func() {
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newNode("worker-1", nil,
+					corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "192.168.0.2"},
+					corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "203.0.113.2"},
+					corev1.NodeAddress{Type: corev1.NodeHostName, Address: "worker-1"},
+				),
+				newNode("worker-2", map[string]string{"role": "worker"},
+					corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "192.168.0.1"},
+				),
+				newNode("liqo-provider", map[string]string{consts.TypeLabel: consts.TypeNode},
+					corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+				),
+			).Build()
+
+			addresses, err := GetNodeAddresses(ctx, cl)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(addresses).To(Equal([]string{"192.168.0.1", "192.168.0.2", "203.0.113.2"}))
+		})
+
+		It("should return no address when there are no nodes", func() {
+			cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+			addresses, err := GetNodeAddresses(ctx, cl)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(addresses).To(BeEmpty())
+		})
+	})
+})
@@ -16,6 +16,7 @@ package utils
 
 import (
 	"context"
+	"slices"
 
 	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
 	"github.com/liqotech/liqo/pkg/consts"
@@ -31,7 +32,7 @@ import (
 // This function is used on the consumer side.
 //
 // Shadow pods are identified by the liqo.io/local-pod label and are scheduled
-// on a virtual node representing the provider cluster.
+// on one of the virtual nodes targeting the provider cluster.
 //
 // For consumer only!
 func GetPodsOffloadedToProvider(ctx context.Context, cl client.Client, providerClusterID string) ([]corev1.Pod, error) {
@@ -45,11 +46,15 @@ func GetPodsOffloadedToProvider(ctx context.Context, cl client.Client, providerC
 		return nil, err
 	}
 
-	// Filter the pods that are scheduled on the specified provider cluster.
-	// The node name corresponds to the cluster ID of the provider.
+	// Filter the pods that are scheduled on the virtual nodes of the specified provider cluster.
+	nodeNames, err := GetVirtualNodeNames(ctx, cl, providerClusterID)
+	if err != nil {
+		return nil, err
+	}
+
 	pods := make([]corev1.Pod, 0)
 	for _, pod := range podList.Items {
-		if pod.Spec.NodeName == providerClusterID {
+		if slices.Contains(nodeNames, pod.Spec.NodeName) {
 			pods = append(pods, pod)
 		}
 	}
@@ -123,3 +128,14 @@ func GetPodsInNamespace(ctx context.Context, cl client.Client, namespace string)
 
 	return podList.Items, nil
 }
+
+// GetPodIPs returns the IP addresses of the given pods, skipping the pods without an IP address yet.
+func GetPodIPs(pods []corev1.Pod) []string {
+	ips := make([]string, 0, len(pods))
+	for i := range pods {
+		if pods[i].Status.PodIP != "" {
+			ips = append(ips, pods[i].Status.PodIP)
+		}
+	}
+	return ips
+}
@@ -17,6 +17,7 @@ package utils
 import (
 	"context"
 
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
 	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
 	"github.com/liqotech/liqo/pkg/consts"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
@@ -25,7 +26,6 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 var _ = Describe("Pods Utilities", func() {
@@ -40,6 +40,13 @@ var _ = Describe("Pods Utilities", func() {
 		RegisterScheme(scheme)
 	})
 
+	newVirtualNode := func(name, clusterID string) *offloadingv1beta1.VirtualNode {
+		return &offloadingv1beta1.VirtualNode{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "liqo-tenant-" + clusterID},
+			Spec:       offloadingv1beta1.VirtualNodeSpec{ClusterID: liqov1beta1.ClusterID(clusterID)},
+		}
+	}
+
 	Describe("GetPodsOffloadedToProvider", func() {
 		It("should return pods offloaded to a specific provider cluster", func() {
 			providerClusterID := "provider-cluster-123"
@@ -53,7 +60,7 @@ var _ = Describe("Pods Utilities", func() {
 					},
 				},
 				Spec: corev1.PodSpec{
-					NodeName: providerClusterID,
+					NodeName: "liqo-provider",
 				},
 			}
 
@@ -66,11 +73,12 @@ var _ = Describe("Pods Utilities", func() {
 					},
 				},
 				Spec: corev1.PodSpec{
-					NodeName: "other-cluster",
+					NodeName: "liqo-other",
 				},
 			}
 
-			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pod1, pod2).Build()
+			cl := newFakeClientBuilder(scheme).WithObjects(pod1, pod2,
+				newVirtualNode("liqo-provider", providerClusterID), newVirtualNode("liqo-other", "other-cluster")).Build()
 
 			pods, err := GetPodsOffloadedToProvider(ctx, cl, providerClusterID)
 			Expect(err).NotTo(HaveOccurred())
@@ -79,14 +87,14 @@ var _ = Describe("Pods Utilities", func() {
 		})
 
 		It("should return empty list when no pods are offloaded to the provider", func() {
-			cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+			cl := newFakeClientBuilder(scheme).Build()
 
 			pods, err := GetPodsOffloadedToProvider(ctx, cl, "nonexistent-cluster")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(pods).To(BeEmpty())
 		})
 
-		It("should filter pods by node name", func() {
+		It("should return the pods on all the virtual nodes of the provider cluster", func() {
 			providerClusterID := "provider-cluster-abc"
 
 			pod1 := &corev1.Pod{
@@ -98,7 +106,7 @@ var _ = Describe("Pods Utilities", func() {
 					},
 				},
 				Spec: corev1.PodSpec{
-					NodeName: providerClusterID,
+					NodeName: "liqo-provider-a",
 				},
 			}
 
@@ -111,11 +119,12 @@ var _ = Describe("Pods Utilities", func() {
 					},
 				},
 				Spec: corev1.PodSpec{
-					NodeName: providerClusterID,
+					NodeName: "liqo-provider-b",
 				},
 			}
 
-			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pod1, pod2).Build()
+			cl := newFakeClientBuilder(scheme).WithObjects(pod1, pod2,
+				newVirtualNode("liqo-provider-a", providerClusterID), newVirtualNode("liqo-provider-b", providerClusterID)).Build()
 
 			pods, err := GetPodsOffloadedToProvider(ctx, cl, providerClusterID)
 			Expect(err).NotTo(HaveOccurred())
@@ -147,7 +156,7 @@ var _ = Describe("Pods Utilities", func() {
 				},
 			}
 
-			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pod1, pod2).Build()
+			cl := newFakeClientBuilder(scheme).WithObjects(pod1, pod2).Build()
 
 			pods, err := GetPodsFromConsumer(ctx, cl, consumerClusterID)
 			Expect(err).NotTo(HaveOccurred())
@@ -156,7 +165,7 @@ var _ = Describe("Pods Utilities", func() {
 		})
 
 		It("should return empty list when no pods are from the consumer", func() {
-			cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+			cl := newFakeClientBuilder(scheme).Build()
 
 			pods, err := GetPodsFromConsumer(ctx, cl, "nonexistent-consumer")
 			Expect(err).NotTo(HaveOccurred())
@@ -187,7 +196,7 @@ var _ = Describe("Pods Utilities", func() {
 				},
 			}
 
-			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(nso, pod1, pod2).Build()
+			cl := newFakeClientBuilder(scheme).WithObjects(nso, pod1, pod2).Build()
 
 			pods, err := GetPodsInOffloadedNamespaces(ctx, cl)
 			Expect(err).NotTo(HaveOccurred())
@@ -220,7 +229,7 @@ var _ = Describe("Pods Utilities", func() {
 				},
 			}
 
-			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(nso, pod1, pod2).Build()
+			cl := newFakeClientBuilder(scheme).WithObjects(nso, pod1, pod2).Build()
 
 			pods, err := GetPodsInOffloadedNamespaces(ctx, cl)
 			Expect(err).NotTo(HaveOccurred())
@@ -229,7 +238,7 @@ var _ = Describe("Pods Utilities", func() {
 		})
 
 		It("should return empty list when no NamespaceOffloading resources exist", func() {
-			cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+			cl := newFakeClientBuilder(scheme).Build()
 
 			pods, err := GetPodsInOffloadedNamespaces(ctx, cl)
 			Expect(err).NotTo(HaveOccurred())
@@ -260,7 +269,7 @@ var _ = Describe("Pods Utilities", func() {
 				},
 			}
 
-			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pod1, pod2, pod3).Build()
+			cl := newFakeClientBuilder(scheme).WithObjects(pod1, pod2, pod3).Build()
 
 			pods, err := GetPodsInNamespace(ctx, cl, "test-ns")
 			Expect(err).NotTo(HaveOccurred())
@@ -268,7 +277,7 @@ var _ = Describe("Pods Utilities", func() {
 		})
 
 		It("should return empty list for non-existent namespace", func() {
-			cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+			cl := newFakeClientBuilder(scheme).Build()
 
 			pods, err := GetPodsInNamespace(ctx, cl, "nonexistent-ns")
 			Expect(err).NotTo(HaveOccurred())
@@ -276,7 +285,7 @@ var _ = Describe("Pods Utilities", func() {
 		})
 
 		It("should return empty list for empty namespace", func() {
-			cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+			cl := newFakeClientBuilder(scheme).Build()
 
 			pods, err := GetPodsInNamespace(ctx, cl, "empty-ns")
 			Expect(err).NotTo(HaveOccurred())
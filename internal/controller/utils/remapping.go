@@ -0,0 +1,178 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+
+	ipamv1alpha1 "github.com/liqotech/liqo/apis/ipam/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
+)
+
+// CIDRMapping describes how a network of a remote cluster is remapped by Liqo in the local cluster,
+// which happens when the network overlaps with one of the local cluster.
+type CIDRMapping struct {
+	// Original is the CIDR of the network in the remote cluster.
+	Original netip.Prefix
+	// Remapped is the CIDR the network is reachable at from the local cluster.
+	Remapped netip.Prefix
+}
+
+// Translate converts an address of the original network into the corresponding address of the
+// remapped network, preserving the host bits. It returns false if the address does not belong
+// to the original network.
+func (m CIDRMapping) Translate(addr netip.Addr) (netip.Addr, bool) {
+	if !m.Original.Contains(addr) || m.Original.Bits() != m.Remapped.Bits() {
+		return addr, false
+	}
+
+	original, remapped, bits := addr.AsSlice(), m.Remapped.Addr().AsSlice(), m.Remapped.Bits()
+	for i := range original {
+		// Keep the network bits of the remapped CIDR and the host bits of the address.
+		mask := byte(0xff)
+		if prefixBits := bits - i*8; prefixBits < 8 {
+			mask = ^byte(0xff >> max(prefixBits, 0))
+		}
+		original[i] = remapped[i]&mask | original[i]&^mask
+	}
+
+	translated, _ := netip.AddrFromSlice(original)
+	return translated, true
+}
+
+// AddressTranslator converts the addresses of the remote cluster into the addresses the packets
+// carry in the local cluster, according to the Liqo remapping of the overlapping networks.
+type AddressTranslator struct {
+	mappings []CIDRMapping
+}
+
+// NewAddressTranslator creates a new AddressTranslator applying the given CIDR mappings.
+// Mappings whose original and remapped CIDRs are equal are ignored.
+func NewAddressTranslator(mappings ...CIDRMapping) *AddressTranslator {
+	translator := AddressTranslator{}
+	for _, mapping := range mappings {
+		if mapping.Original != mapping.Remapped {
+			translator.mappings = append(translator.mappings, mapping)
+		}
+	}
+	return &translator
+}
+
+// Translate converts the given address of the remote cluster into the one seen by the local cluster.
+// Addresses outside of the remapped networks, or already translated, are returned unchanged.
+func (t *AddressTranslator) Translate(address string) string {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return address
+	}
+
+	for _, mapping := range t.mappings {
+		if mapping.Remapped.Contains(addr) {
+			// The address has already been translated (e.g., by the virtual kubelet).
+			return address
+		}
+		if translated, ok := mapping.Translate(addr); ok {
+			return translated.String()
+		}
+	}
+	return address
+}
+
+// TranslateAll converts the given addresses of the remote cluster into the ones seen by the local cluster.
+func (t *AddressTranslator) TranslateAll(addresses []string) []string {
+	translated := make([]string, len(addresses))
+	for i, address := range addresses {
+		translated[i] = t.Translate(address)
+	}
+	return translated
+}
+
+// GetRemoteClusterAddressTranslator returns the AddressTranslator for the pod and external networks
+// of the given remote cluster, based on the Liqo IPAM Network resources in its tenant namespace.
+// Missing networks are considered as not remapped.
+func GetRemoteClusterAddressTranslator(
+	ctx context.Context, cl client.Client, liqoOpts *options.LiqoOptions, clusterID string,
+) (*AddressTranslator, error) {
+	namespace, err := GetTenantNamespace(ctx, cl, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var mappings []CIDRMapping
+	for _, name := range []string{liqoOpts.RemotePodCIDRNetworkName(clusterID), liqoOpts.RemoteExternalCIDRNetworkName(clusterID)} {
+		var network ipamv1alpha1.Network
+		if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &network); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		mapping, err := networkCIDRMapping(&network)
+		if err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, mapping)
+	}
+
+	return NewAddressTranslator(mappings...), nil
+}
+
+// GetLocalRemappedIPs returns the addresses the given local addresses are exposed at to the remote
+// clusters, according to the Liqo IPAM IP resources. Such addresses belong to the external CIDR,
+// and are carried by the packets once Liqo translated them. Only the remapped addresses are returned.
+func GetLocalRemappedIPs(ctx context.Context, cl client.Client, addresses []string) ([]string, error) {
+	var ips ipamv1alpha1.IPList
+	if err := cl.List(ctx, &ips); err != nil {
+		return nil, err
+	}
+
+	remapped := make([]string, 0)
+	for i := range ips.Items {
+		ip := &ips.Items[i]
+		if ip.Status.IP != "" && ip.Status.IP != ip.Spec.IP && slices.Contains(addresses, string(ip.Spec.IP)) {
+			remapped = append(remapped, string(ip.Status.IP))
+		}
+	}
+
+	slices.Sort(remapped)
+	return slices.Compact(remapped), nil
+}
+
+// networkCIDRMapping returns the CIDR mapping described by the given Network resource.
+// It fails if the remapped CIDR has not been allocated yet.
+func networkCIDRMapping(network *ipamv1alpha1.Network) (CIDRMapping, error) {
+	original, err := netip.ParsePrefix(string(network.Spec.CIDR))
+	if err != nil {
+		return CIDRMapping{}, fmt.Errorf("invalid CIDR of Network %s/%s: %w", network.Namespace, network.Name, err)
+	}
+
+	remapped, err := getNetworkStatusCIDR(network)
+	if err != nil {
+		return CIDRMapping{}, err
+	}
+	remappedPrefix, err := netip.ParsePrefix(remapped)
+	if err != nil {
+		return CIDRMapping{}, fmt.Errorf("invalid remapped CIDR of Network %s/%s: %w", network.Namespace, network.Name, err)
+	}
+
+	return CIDRMapping{Original: original.Masked(), Remapped: remappedPrefix.Masked()}, nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"net/netip"
+
+	ipamv1alpha1 "github.com/liqotech/liqo/apis/ipam/v1alpha1"
+	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var _ = Describe("Remapping Utilities", func() {
+	var (
+		ctx    context.Context
+		scheme *runtime.Scheme
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		RegisterScheme(scheme)
+	})
+
+	newNetwork := func(name, original, remapped string) *ipamv1alpha1.Network {
+		return &ipamv1alpha1.Network{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "liqo-tenant-remote"},
+			Spec:       ipamv1alpha1.NetworkSpec{CIDR: networkingv1beta1.CIDR(original)},
+			Status:     ipamv1alpha1.NetworkStatus{CIDR: networkingv1beta1.CIDR(remapped)},
+		}
+	}
+
+	Describe("CIDRMapping", func() {
+		It("should preserve the host bits of the address", func() {
+			mapping := CIDRMapping{Original: netip.MustParsePrefix("10.0.0.0/16"), Remapped: netip.MustParsePrefix("10.70.0.0/16")}
+			translated, ok := mapping.Translate(netip.MustParseAddr("10.0.12.34"))
+			Expect(ok).To(BeTrue())
+			Expect(translated).To(Equal(netip.MustParseAddr("10.70.12.34")))
+		})
+
+		It("should handle prefixes not aligned to the byte boundary", func() {
+			mapping := CIDRMapping{Original: netip.MustParsePrefix("10.0.16.0/20"), Remapped: netip.MustParsePrefix("192.168.32.0/20")}
+			translated, ok := mapping.Translate(netip.MustParseAddr("10.0.31.7"))
+			Expect(ok).To(BeTrue())
+			Expect(translated).To(Equal(netip.MustParseAddr("192.168.47.7")))
+		})
+
+		It("should not translate the addresses outside of the original CIDR", func() {
+			mapping := CIDRMapping{Original: netip.MustParsePrefix("10.0.0.0/16"), Remapped: netip.MustParsePrefix("10.70.0.0/16")}
+			_, ok := mapping.Translate(netip.MustParseAddr("10.1.0.1"))
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("GetRemoteClusterAddressTranslator", func() {
+		It("should translate the addresses of an overlapping peering", func() {
+			cl := newFakeClientBuilder(scheme).WithObjects(
+				newNetwork("remote-pod", "10.0.0.0/16", "10.70.0.0/16"),
+				newNetwork("remote-external", "10.201.0.0/16", "10.81.0.0/16"),
+			).Build()
+
+			translator, err := GetRemoteClusterAddressTranslator(ctx, cl, liqoOpts, "remote")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(translator.TranslateAll([]string{"10.0.1.5", "10.201.3.4", "172.16.0.1", "invalid"})).To(
+				Equal([]string{"10.70.1.5", "10.81.3.4", "172.16.0.1", "invalid"}))
+		})
+
+		It("should not translate again the addresses already remapped", func() {
+			// The remapped CIDR overlaps with the original one of another network.
+			cl := newFakeClientBuilder(scheme).WithObjects(
+				newNetwork("remote-pod", "10.0.0.0/16", "10.1.0.0/16"),
+				newNetwork("remote-external", "10.1.0.0/16", "10.2.0.0/16"),
+			).Build()
+
+			translator, err := GetRemoteClusterAddressTranslator(ctx, cl, liqoOpts, "remote")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(translator.Translate("10.1.0.5")).To(Equal("10.1.0.5"))
+			Expect(translator.Translate("10.0.0.5")).To(Equal("10.1.0.5"))
+		})
+
+		It("should leave the addresses unchanged when the networks are not remapped", func() {
+			cl := newFakeClientBuilder(scheme).WithObjects(
+				newNetwork("remote-pod", "10.0.0.0/16", "10.0.0.0/16"),
+			).Build()
+
+			translator, err := GetRemoteClusterAddressTranslator(ctx, cl, liqoOpts, "remote")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(translator.Translate("10.0.1.5")).To(Equal("10.0.1.5"))
+		})
+
+		It("should return error when the remapped CIDR has not been allocated yet", func() {
+			cl := newFakeClientBuilder(scheme).WithObjects(
+				newNetwork("remote-pod", "10.0.0.0/16", ""),
+			).Build()
+
+			_, err := GetRemoteClusterAddressTranslator(ctx, cl, liqoOpts, "remote")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("GetLocalRemappedIPs", func() {
+		It("should return the external CIDR addresses of the given local addresses", func() {
+			cl := newFakeClientBuilder(scheme).WithObjects(
+				&ipamv1alpha1.IP{
+					ObjectMeta: metav1.ObjectMeta{Name: "node-1", Namespace: "liqo"},
+					Spec:       ipamv1alpha1.IPSpec{IP: "192.168.0.1"},
+					Status:     ipamv1alpha1.IPStatus{IP: "10.80.0.1"},
+				},
+				&ipamv1alpha1.IP{
+					ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "liqo"},
+					Spec:       ipamv1alpha1.IPSpec{IP: "192.168.0.2"},
+				},
+				&ipamv1alpha1.IP{
+					ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "liqo"},
+					Spec:       ipamv1alpha1.IPSpec{IP: "192.168.1.1"},
+					Status:     ipamv1alpha1.IPStatus{IP: "10.80.0.2"},
+				},
+			).Build()
+
+			remapped, err := GetLocalRemappedIPs(ctx, cl, []string{"192.168.0.1", "192.168.0.2"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(remapped).To(Equal([]string{"10.80.0.1"}))
+		})
+	})
+})
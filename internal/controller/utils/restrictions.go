@@ -0,0 +1,129 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+)
+
+// NamespaceRestriction is the restriction of the cross-cluster traffic of the pods of a namespace,
+// obtained by merging the NamespacePeeringPolicy resources of the namespace applying to a peering.
+// As for NetworkPolicies, the policies are additive: a direction is restricted if any policy
+// restricts it, and the traffic allowed by any of them is allowed.
+type NamespaceRestriction struct {
+	// Namespace is the namespace whose pods are restricted.
+	Namespace string
+	// Policies are the names of the merged NamespacePeeringPolicy resources.
+	Policies []string
+
+	// RestrictIngress is whether the traffic received by the pods is restricted to the Ingress parties.
+	RestrictIngress bool
+	// Ingress are the parties allowed to send traffic to the pods.
+	Ingress []connectivityv1.Party
+
+	// RestrictEgress is whether the traffic sent by the pods is restricted to the Egress parties.
+	RestrictEgress bool
+	// Egress are the parties the pods are allowed to send traffic to.
+	Egress []connectivityv1.Party
+}
+
+// NamespacePolicyRestricts checks whether the NamespacePeeringPolicy restricts the given direction.
+// If the policy types are omitted, the directions with at least one party are restricted.
+func NamespacePolicyRestricts(spec *connectivityv1.NamespacePeeringPolicySpec, policyType connectivityv1.NamespacePolicyType) bool {
+	if len(spec.PolicyTypes) > 0 {
+		return slices.Contains(spec.PolicyTypes, policyType)
+	}
+
+	switch policyType {
+	case connectivityv1.NamespacePolicyTypeIngress:
+		return len(spec.Ingress) > 0
+	case connectivityv1.NamespacePolicyTypeEgress:
+		return len(spec.Egress) > 0
+	default:
+		return false
+	}
+}
+
+// NamespacePolicyAppliesTo checks whether the NamespacePeeringPolicy applies to the peering with the given cluster.
+func NamespacePolicyAppliesTo(policy *connectivityv1.NamespacePeeringPolicy, clusterID string) bool {
+	return len(policy.Spec.ClusterIDs) == 0 || slices.Contains(policy.Spec.ClusterIDs, clusterID)
+}
+
+// MergeNamespacePolicies merges the given NamespacePeeringPolicy resources applying to the peering
+// with the given cluster into a restriction for each namespace, ignoring the ones being deleted and
+// the ones not restricting any direction. The restrictions are sorted by namespace, and the policies
+// of each namespace are merged by name.
+func MergeNamespacePolicies(policies []connectivityv1.NamespacePeeringPolicy, clusterID string) []NamespaceRestriction {
+	policies = slices.Clone(policies)
+	slices.SortFunc(policies, func(a, b connectivityv1.NamespacePeeringPolicy) int {
+		if c := strings.Compare(a.Namespace, b.Namespace); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	var restrictions []NamespaceRestriction
+	for i := range policies {
+		policy := &policies[i]
+		if !policy.DeletionTimestamp.IsZero() || !NamespacePolicyAppliesTo(policy, clusterID) {
+			continue
+		}
+
+		restrictIngress := NamespacePolicyRestricts(&policy.Spec, connectivityv1.NamespacePolicyTypeIngress)
+		restrictEgress := NamespacePolicyRestricts(&policy.Spec, connectivityv1.NamespacePolicyTypeEgress)
+		if !restrictIngress && !restrictEgress {
+			continue
+		}
+
+		if len(restrictions) == 0 || restrictions[len(restrictions)-1].Namespace != policy.Namespace {
+			restrictions = append(restrictions, NamespaceRestriction{Namespace: policy.Namespace})
+		}
+		restriction := &restrictions[len(restrictions)-1]
+		restriction.Policies = append(restriction.Policies, policy.Name)
+
+		// The parties of a direction are added only by the policies restricting it, since the
+		// ones of the other policies would allow traffic they do not intend to restrict.
+		if restrictIngress {
+			restriction.RestrictIngress = true
+			for j := range policy.Spec.Ingress {
+				restriction.Ingress = append(restriction.Ingress, *policy.Spec.Ingress[j].DeepCopy())
+			}
+		}
+		if restrictEgress {
+			restriction.RestrictEgress = true
+			for j := range policy.Spec.Egress {
+				restriction.Egress = append(restriction.Egress, *policy.Spec.Egress[j].DeepCopy())
+			}
+		}
+	}
+	return restrictions
+}
+
+// GetNamespaceRestrictions returns the restrictions of the application namespaces for the peering
+// with the given cluster, defined by the NamespacePeeringPolicy resources of all the namespaces.
+func GetNamespaceRestrictions(ctx context.Context, cl client.Client, clusterID string) ([]NamespaceRestriction, error) {
+	var policyList connectivityv1.NamespacePeeringPolicyList
+	if err := cl.List(ctx, &policyList); err != nil {
+		return nil, fmt.Errorf("unable to list the NamespacePeeringPolicy resources: %w", err)
+	}
+	return MergeNamespacePolicies(policyList.Items, clusterID), nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+)
+
+var _ = Describe("Namespace Restriction Utilities", func() {
+	group := func(group connectivityv1.ResourceGroup) connectivityv1.Party {
+		return connectivityv1.Party{Group: ptr.To(group)}
+	}
+
+	newPolicy := func(namespace, name string, spec connectivityv1.NamespacePeeringPolicySpec) connectivityv1.NamespacePeeringPolicy {
+		return connectivityv1.NamespacePeeringPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       spec,
+		}
+	}
+
+	Describe("NamespacePolicyRestricts", func() {
+		It("should restrict the directions with parties when the policy types are omitted", func() {
+			spec := &connectivityv1.NamespacePeeringPolicySpec{Ingress: []connectivityv1.Party{group(connectivityv1.ResourceGroupRemoteCluster)}}
+			Expect(NamespacePolicyRestricts(spec, connectivityv1.NamespacePolicyTypeIngress)).To(BeTrue())
+			Expect(NamespacePolicyRestricts(spec, connectivityv1.NamespacePolicyTypeEgress)).To(BeFalse())
+		})
+
+		It("should restrict the listed policy types, even without parties", func() {
+			spec := &connectivityv1.NamespacePeeringPolicySpec{
+				Ingress:     []connectivityv1.Party{group(connectivityv1.ResourceGroupRemoteCluster)},
+				PolicyTypes: []connectivityv1.NamespacePolicyType{connectivityv1.NamespacePolicyTypeEgress},
+			}
+			Expect(NamespacePolicyRestricts(spec, connectivityv1.NamespacePolicyTypeIngress)).To(BeFalse())
+			Expect(NamespacePolicyRestricts(spec, connectivityv1.NamespacePolicyTypeEgress)).To(BeTrue())
+		})
+	})
+
+	Describe("MergeNamespacePolicies", func() {
+		It("should merge the policies of each namespace by name", func() {
+			restrictions := MergeNamespacePolicies([]connectivityv1.NamespacePeeringPolicy{
+				newPolicy("shop", "b", connectivityv1.NamespacePeeringPolicySpec{
+					Ingress: []connectivityv1.Party{group(connectivityv1.ResourceGroupInternet)},
+				}),
+				newPolicy("billing", "a", connectivityv1.NamespacePeeringPolicySpec{
+					Egress: []connectivityv1.Party{group(connectivityv1.ResourceGroupNameserver)},
+				}),
+				newPolicy("shop", "a", connectivityv1.NamespacePeeringPolicySpec{
+					Ingress: []connectivityv1.Party{group(connectivityv1.ResourceGroupRemoteCluster)},
+				}),
+			}, "cluster-a")
+
+			Expect(restrictions).To(Equal([]NamespaceRestriction{{
+				Namespace:      "billing",
+				Policies:       []string{"a"},
+				RestrictEgress: true,
+				Egress:         []connectivityv1.Party{group(connectivityv1.ResourceGroupNameserver)},
+			}, {
+				Namespace:       "shop",
+				Policies:        []string{"a", "b"},
+				RestrictIngress: true,
+				Ingress: []connectivityv1.Party{
+					group(connectivityv1.ResourceGroupRemoteCluster),
+					group(connectivityv1.ResourceGroupInternet),
+				},
+			}}))
+		})
+
+		It("should ignore the parties of the directions not restricted by a policy", func() {
+			restrictions := MergeNamespacePolicies([]connectivityv1.NamespacePeeringPolicy{
+				newPolicy("shop", "deny-egress", connectivityv1.NamespacePeeringPolicySpec{
+					Ingress:     []connectivityv1.Party{group(connectivityv1.ResourceGroupInternet)},
+					PolicyTypes: []connectivityv1.NamespacePolicyType{connectivityv1.NamespacePolicyTypeEgress},
+				}),
+			}, "cluster-a")
+
+			Expect(restrictions).To(HaveLen(1))
+			Expect(restrictions[0].RestrictIngress).To(BeFalse())
+			Expect(restrictions[0].Ingress).To(BeEmpty())
+			Expect(restrictions[0].RestrictEgress).To(BeTrue())
+			Expect(restrictions[0].Egress).To(BeEmpty())
+		})
+
+		It("should skip the policies of other peerings, without restrictions or being deleted", func() {
+			deleting := newPolicy("shop", "deleting", connectivityv1.NamespacePeeringPolicySpec{
+				Ingress: []connectivityv1.Party{group(connectivityv1.ResourceGroupInternet)},
+			})
+			deleting.DeletionTimestamp = ptr.To(metav1.Now())
+
+			Expect(MergeNamespacePolicies([]connectivityv1.NamespacePeeringPolicy{
+				newPolicy("shop", "other", connectivityv1.NamespacePeeringPolicySpec{
+					ClusterIDs: []string{"cluster-b"},
+					Ingress:    []connectivityv1.Party{group(connectivityv1.ResourceGroupInternet)},
+				}),
+				newPolicy("shop", "empty", connectivityv1.NamespacePeeringPolicySpec{}),
+				deleting,
+			}, "cluster-a")).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,237 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+)
+
+const (
+	// RevisionOwnerLabelKey labels the ControllerRevisions with the name of the PeeringConnectivity whose spec they record.
+	RevisionOwnerLabelKey = "connectivity.liqo.io/peeringconnectivity"
+
+	// FirewallHashAnnotationKey annotates a ControllerRevision with the hash of the spec of the gateway
+	// FirewallConfiguration rendered when the revision was last applied.
+	FirewallHashAnnotationKey = "connectivity.liqo.io/firewall-hash"
+
+	// AppliedAtAnnotationKey annotates a ControllerRevision with the time the revision was last applied.
+	AppliedAtAnnotationKey = "connectivity.liqo.io/applied-at"
+
+	// RollbackAnnotationKey requests to roll the spec of a PeeringConnectivity back to the recorded
+	// revision with the given number. It is removed once the request is handled.
+	RollbackAnnotationKey = "connectivity.liqo.io/rollback-to"
+
+	// revisionHashLength is the number of hexadecimal characters of the hash of the spec in the revision names.
+	revisionHashLength = 10
+)
+
+// ForgeHash returns the hexadecimal SHA-256 hash of the JSON encoding of the given object.
+func ForgeHash(obj any) (string, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode the object to hash: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ForgeRevisionName returns the name of the ControllerRevision recording the spec with the given hash of the
+// PeeringConnectivity with the given name, truncating the latter to fit the maximum length of the name.
+func ForgeRevisionName(name, specHash string) string {
+	hash := specHash[:min(len(specHash), revisionHashLength)]
+	if available := validation.DNS1123SubdomainMaxLength - len(hash) - 1; len(name) > available {
+		name = strings.TrimRight(name[:available], "-.")
+	}
+	return fmt.Sprintf("%s-%s", name, hash)
+}
+
+// ListRevisions returns the ControllerRevisions recording the specs of the given PeeringConnectivity,
+// ordered by increasing revision number: the last one is the current revision.
+func ListRevisions(ctx context.Context, cl client.Client, cfg *connectivityv1.PeeringConnectivity) ([]appsv1.ControllerRevision, error) {
+	var revisionList appsv1.ControllerRevisionList
+	if err := cl.List(ctx, &revisionList, client.InNamespace(cfg.Namespace), client.MatchingLabels{RevisionOwnerLabelKey: cfg.Name}); err != nil {
+		return nil, fmt.Errorf("unable to list the revisions of the PeeringConnectivity %q: %w", cfg.Name, err)
+	}
+
+	// A PeeringConnectivity recreated with the same name does not inherit the revisions of the previous one.
+	revisions := slices.DeleteFunc(revisionList.Items, func(revision appsv1.ControllerRevision) bool {
+		return !metav1.IsControlledBy(&revision, cfg)
+	})
+	slices.SortFunc(revisions, func(a, b appsv1.ControllerRevision) int {
+		return cmp.Compare(a.Revision, b.Revision)
+	})
+	return revisions, nil
+}
+
+// RecordRevision records the spec of the given PeeringConnectivity as its current revision, along with the hash
+// of the spec of the gateway FirewallConfiguration rendered from it, and returns the recorded revisions ordered
+// by increasing revision number. If the spec matches a previous revision, e.g. after a rollback, that revision
+// becomes the current one instead of recording a duplicate. If the spec is unchanged but the rendered
+// FirewallConfiguration differs, e.g. after a change of the resolved addresses, the current revision records
+// the new hash and application time. Only the most recent limit revisions are kept.
+func RecordRevision(
+	ctx context.Context,
+	cl client.Client,
+	scheme *runtime.Scheme,
+	cfg *connectivityv1.PeeringConnectivity,
+	firewallHash string,
+	limit int,
+	now time.Time,
+) ([]appsv1.ControllerRevision, error) {
+	data, err := json.Marshal(cfg.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode the spec of the PeeringConnectivity %q: %w", cfg.Name, err)
+	}
+	specHash, err := ForgeHash(cfg.Spec)
+	if err != nil {
+		return nil, err
+	}
+	name := ForgeRevisionName(cfg.Name, specHash)
+
+	revisions, err := ListRevisions(ctx, cl, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest int64
+	if len(revisions) > 0 {
+		latest = revisions[len(revisions)-1].Revision
+	}
+	annotations := map[string]string{
+		FirewallHashAnnotationKey: firewallHash,
+		AppliedAtAnnotationKey:    now.UTC().Format(time.RFC3339),
+	}
+
+	switch i := slices.IndexFunc(revisions, func(revision appsv1.ControllerRevision) bool { return revision.Name == name }); {
+	case i < 0:
+		// A new spec has been applied.
+		revision := appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   cfg.Namespace,
+				Labels:      map[string]string{RevisionOwnerLabelKey: cfg.Name},
+				Annotations: annotations,
+			},
+			Data:     runtime.RawExtension{Raw: data},
+			Revision: latest + 1,
+		}
+		if err := controllerutil.SetControllerReference(cfg, &revision, scheme); err != nil {
+			return nil, err
+		}
+		// The revisions are listed from the cache, which may not have observed the one created by a previous
+		// reconciliation yet: its name is derived from the spec, hence the spec is recorded already.
+		if err := cl.Create(ctx, &revision); err != nil && !apierrors.IsAlreadyExists(err) {
+			return nil, fmt.Errorf("unable to create the revision %q: %w", name, err)
+		}
+		revisions = append(revisions, revision)
+
+	case i < len(revisions)-1:
+		// A previous spec has been applied again: its revision becomes the current one.
+		revision := revisions[i].DeepCopy()
+		revision.Revision = latest + 1
+		if revision.Annotations == nil {
+			revision.Annotations = make(map[string]string, len(annotations))
+		}
+		for key, value := range annotations {
+			revision.Annotations[key] = value
+		}
+		if err := cl.Update(ctx, revision); err != nil {
+			return nil, fmt.Errorf("unable to update the revision %q: %w", name, err)
+		}
+		revisions = append(slices.Delete(revisions, i, i+1), *revision)
+
+	case revisions[i].Annotations[FirewallHashAnnotationKey] != firewallHash:
+		// The current spec has been rendered to a different FirewallConfiguration.
+		revision := revisions[i].DeepCopy()
+		if revision.Annotations == nil {
+			revision.Annotations = make(map[string]string, len(annotations))
+		}
+		for key, value := range annotations {
+			revision.Annotations[key] = value
+		}
+		if err := cl.Update(ctx, revision); err != nil {
+			return nil, fmt.Errorf("unable to update the revision %q: %w", name, err)
+		}
+		revisions[i] = *revision
+	}
+
+	// Prune the oldest revisions exceeding the limit, always keeping the current one.
+	for len(revisions) > max(limit, 1) {
+		if err := cl.Delete(ctx, &revisions[0]); client.IgnoreNotFound(err) != nil {
+			return nil, fmt.Errorf("unable to delete the revision %q: %w", revisions[0].Name, err)
+		}
+		revisions = revisions[1:]
+	}
+	return revisions, nil
+}
+
+// GetRevisionSpec returns the spec recorded by the revision with the given number of the given PeeringConnectivity.
+func GetRevisionSpec(
+	ctx context.Context, cl client.Client, cfg *connectivityv1.PeeringConnectivity, number int64,
+) (*connectivityv1.PeeringConnectivitySpec, error) {
+	revisions, err := ListRevisions(ctx, cl, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	i := slices.IndexFunc(revisions, func(revision appsv1.ControllerRevision) bool { return revision.Revision == number })
+	if i < 0 {
+		return nil, fmt.Errorf("revision %d of the PeeringConnectivity %q not found", number, cfg.Name)
+	}
+
+	var spec connectivityv1.PeeringConnectivitySpec
+	if err := json.Unmarshal(revisions[i].Data.Raw, &spec); err != nil {
+		return nil, fmt.Errorf("unable to decode the spec of the revision %q: %w", revisions[i].Name, err)
+	}
+	return &spec, nil
+}
+
+// ForgeRevisionStatuses describes the given revisions, in the same order, for the status of their PeeringConnectivity.
+func ForgeRevisionStatuses(revisions []appsv1.ControllerRevision) []connectivityv1.RevisionStatus {
+	statuses := make([]connectivityv1.RevisionStatus, 0, len(revisions))
+	for i := range revisions {
+		appliedAt := revisions[i].CreationTimestamp
+		if value, err := time.Parse(time.RFC3339, revisions[i].Annotations[AppliedAtAnnotationKey]); err == nil {
+			appliedAt = metav1.NewTime(value)
+		}
+
+		statuses = append(statuses, connectivityv1.RevisionStatus{
+			Revision:     revisions[i].Revision,
+			Name:         revisions[i].Name,
+			FirewallHash: revisions[i].Annotations[FirewallHashAnnotationKey],
+			AppliedAt:    appliedAt,
+		})
+	}
+	return statuses
+}
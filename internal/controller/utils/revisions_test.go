@@ -0,0 +1,202 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+)
+
+var _ = Describe("Revision Utilities", func() {
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	var (
+		ctx    context.Context
+		scheme *runtime.Scheme
+		cl     client.Client
+		cfg    *connectivityv1.PeeringConnectivity
+	)
+
+	withNamespace := func(namespace string) connectivityv1.PeeringConnectivitySpec {
+		return connectivityv1.PeeringConnectivitySpec{
+			Rules: []connectivityv1.Rule{{
+				Action:      connectivityv1.ActionAllow,
+				Destination: &connectivityv1.Party{Namespace: ptr.To(namespace)},
+			}},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		Expect(connectivityv1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		cl = fake.NewClientBuilder().WithScheme(scheme).Build()
+
+		cfg = &connectivityv1.PeeringConnectivity{
+			ObjectMeta: metav1.ObjectMeta{Name: "peering", Namespace: "tenant", UID: types.UID("uid")},
+			Spec:       withNamespace("a"),
+		}
+	})
+
+	record := func(firewallHash string, limit int, at time.Time) []appsv1.ControllerRevision {
+		revisions, err := RecordRevision(ctx, cl, scheme, cfg, firewallHash, limit, at)
+		Expect(err).NotTo(HaveOccurred())
+		return revisions
+	}
+
+	Describe("ForgeRevisionName", func() {
+		It("should suffix the name with the truncated hash of the spec", func() {
+			Expect(ForgeRevisionName("peering", "0123456789abcdef")).To(Equal("peering-0123456789"))
+		})
+
+		It("should truncate long names to a valid length", func() {
+			name := ForgeRevisionName(strings.Repeat("a", 250), "0123456789abcdef")
+			Expect(name).To(HaveLen(253))
+			Expect(name).To(HaveSuffix("a-0123456789"))
+		})
+	})
+
+	Describe("RecordRevision", func() {
+		It("should record a new revision for each applied spec", func() {
+			revisions := record("fw1", 10, now)
+			Expect(revisions).To(HaveLen(1))
+			Expect(revisions[0].Revision).To(BeEquivalentTo(1))
+			Expect(revisions[0].Labels).To(HaveKeyWithValue(RevisionOwnerLabelKey, "peering"))
+			Expect(revisions[0].Annotations).To(HaveKeyWithValue(FirewallHashAnnotationKey, "fw1"))
+			Expect(metav1.IsControlledBy(&revisions[0], cfg)).To(BeTrue())
+
+			cfg.Spec = withNamespace("b")
+			revisions = record("fw2", 10, now.Add(time.Minute))
+			Expect(revisions).To(HaveLen(2))
+			Expect(revisions[1].Revision).To(BeEquivalentTo(2))
+		})
+
+		It("should tolerate a revision the cache has not observed yet", func() {
+			recorded := record("fw1", 10, now)
+
+			// A stale cache does not list the revision created by the previous reconciliation.
+			cl = interceptor.NewClient(cl.(client.WithWatch), interceptor.Funcs{
+				List: func(context.Context, client.WithWatch, client.ObjectList, ...client.ListOption) error { return nil },
+			})
+			revisions := record("fw1", 10, now.Add(time.Minute))
+			Expect(revisions).To(HaveLen(1))
+			Expect(revisions[0].Name).To(Equal(recorded[0].Name))
+			Expect(revisions[0].Revision).To(Equal(recorded[0].Revision))
+		})
+
+		It("should not record a duplicate when the spec is unchanged", func() {
+			record("fw1", 10, now)
+			revisions := record("fw1", 10, now.Add(time.Minute))
+			Expect(revisions).To(HaveLen(1))
+			Expect(revisions[0].Annotations).To(HaveKeyWithValue(AppliedAtAnnotationKey, now.Format(time.RFC3339)))
+		})
+
+		It("should refresh the current revision when the render changes without a spec change", func() {
+			record("fw1", 10, now)
+			revisions := record("fw2", 10, now.Add(time.Minute))
+			Expect(revisions).To(HaveLen(1))
+			Expect(revisions[0].Revision).To(BeEquivalentTo(1))
+			Expect(revisions[0].Annotations).To(HaveKeyWithValue(FirewallHashAnnotationKey, "fw2"))
+			Expect(revisions[0].Annotations).To(HaveKeyWithValue(AppliedAtAnnotationKey, now.Add(time.Minute).Format(time.RFC3339)))
+
+			listed, err := ListRevisions(ctx, cl, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(listed).To(HaveLen(1))
+			Expect(listed[0].Annotations).To(HaveKeyWithValue(FirewallHashAnnotationKey, "fw2"))
+		})
+
+		It("should promote a previous revision when its spec is applied again", func() {
+			first := record("fw1", 10, now)[0].Name
+			cfg.Spec = withNamespace("b")
+			record("fw2", 10, now.Add(time.Minute))
+
+			cfg.Spec = withNamespace("a")
+			revisions := record("fw3", 10, now.Add(2*time.Minute))
+			Expect(revisions).To(HaveLen(2))
+			Expect(revisions[1].Name).To(Equal(first))
+			Expect(revisions[1].Revision).To(BeEquivalentTo(3))
+			Expect(revisions[1].Annotations).To(HaveKeyWithValue(FirewallHashAnnotationKey, "fw3"))
+
+			listed, err := ListRevisions(ctx, cl, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(listed).To(HaveLen(2))
+			Expect(listed[1].Name).To(Equal(first))
+		})
+
+		It("should prune the oldest revisions beyond the limit", func() {
+			for i, namespace := range []string{"a", "b", "c"} {
+				cfg.Spec = withNamespace(namespace)
+				record("fw", 2, now.Add(time.Duration(i)*time.Minute))
+			}
+
+			revisions, err := ListRevisions(ctx, cl, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(HaveLen(2))
+			Expect(revisions[0].Revision).To(BeEquivalentTo(2))
+			Expect(revisions[1].Revision).To(BeEquivalentTo(3))
+		})
+
+		It("should ignore the revisions of a previous resource with the same name", func() {
+			record("fw", 10, now)
+			cfg.UID = types.UID("other")
+			revisions, err := ListRevisions(ctx, cl, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(BeEmpty())
+		})
+	})
+
+	Describe("GetRevisionSpec", func() {
+		It("should return the spec recorded by the revision", func() {
+			record("fw", 10, now)
+			cfg.Spec = withNamespace("b")
+			record("fw", 10, now)
+
+			spec, err := GetRevisionSpec(ctx, cl, cfg, 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*spec).To(Equal(withNamespace("a")))
+		})
+
+		It("should fail for a missing revision", func() {
+			record("fw", 10, now)
+			_, err := GetRevisionSpec(ctx, cl, cfg, 5)
+			Expect(err).To(MatchError(ContainSubstring("revision 5")))
+		})
+	})
+
+	Describe("ForgeRevisionStatuses", func() {
+		It("should describe the revisions with the time they were applied", func() {
+			statuses := ForgeRevisionStatuses(record("fw", 10, now))
+			Expect(statuses).To(HaveLen(1))
+			Expect(statuses[0].Revision).To(BeEquivalentTo(1))
+			Expect(statuses[0].FirewallHash).To(Equal("fw"))
+			Expect(statuses[0].AppliedAt.Time).To(BeTemporally("==", now))
+		})
+	})
+})
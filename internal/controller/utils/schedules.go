@@ -0,0 +1,79 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/schedule"
+)
+
+// EvaluateSchedules evaluates the schedules of the given rules at the given time, returning the status
+// of each scheduled rule and the next time any of them must be evaluated again, if any.
+// The rules whose schedule cannot be parsed are never active.
+func EvaluateSchedules(rules []connectivityv1.Rule, now time.Time) ([]connectivityv1.RuleScheduleStatus, time.Time) {
+	var (
+		statuses []connectivityv1.RuleScheduleStatus
+		next     time.Time
+	)
+	for i := range rules {
+		if rules[i].Schedule == nil {
+			continue
+		}
+
+		status := connectivityv1.RuleScheduleStatus{Rule: int32(i)}
+		sched, err := schedule.Parse(rules[i].Schedule)
+		if err != nil {
+			status.Message = err.Error()
+			statuses = append(statuses, status)
+			continue
+		}
+
+		active, transition := sched.Evaluate(now)
+		status.Active = active
+		if !transition.IsZero() {
+			status.NextTransition = &metav1.Time{Time: transition}
+			if next.IsZero() || transition.Before(next) {
+				next = transition
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, next
+}
+
+// ActiveRuleIndexes returns the indexes of the given rules without a schedule or whose schedule is active
+// at the given time, in order, along with the next time any of the scheduled rules must be evaluated again.
+func ActiveRuleIndexes(rules []connectivityv1.Rule, now time.Time) ([]int, time.Time) {
+	statuses, next := EvaluateSchedules(rules, now)
+
+	inactive := make(map[int32]struct{}, len(statuses))
+	for _, status := range statuses {
+		if !status.Active {
+			inactive[status.Rule] = struct{}{}
+		}
+	}
+
+	active := make([]int, 0, len(rules))
+	for i := range rules {
+		if _, found := inactive[int32(i)]; !found {
+			active = append(active, i)
+		}
+	}
+	return active, next
+}
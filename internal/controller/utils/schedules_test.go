@@ -0,0 +1,85 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+)
+
+var _ = Describe("Schedule Utilities", func() {
+	// 2026-01-05 is a Monday.
+	now := time.Date(2026, time.January, 5, 12, 0, 0, 0, time.UTC)
+
+	scheduled := func(start string, duration time.Duration) connectivityv1.Rule {
+		return connectivityv1.Rule{
+			Action: connectivityv1.ActionAllow,
+			Schedule: &connectivityv1.RuleSchedule{
+				Windows: []connectivityv1.ScheduleWindow{{Start: start, Duration: metav1.Duration{Duration: duration}}},
+			},
+		}
+	}
+
+	rules := []connectivityv1.Rule{
+		{Action: connectivityv1.ActionAllow},
+		scheduled("0 9 * * *", 8*time.Hour),
+		scheduled("0 18 * * *", time.Hour),
+		scheduled("0 9 * * *", -time.Hour),
+	}
+
+	Describe("EvaluateSchedules", func() {
+		It("should report the status of the scheduled rules only", func() {
+			statuses, next := EvaluateSchedules(rules, now)
+			Expect(statuses).To(HaveLen(3))
+
+			Expect(statuses[0].Rule).To(Equal(int32(1)))
+			Expect(statuses[0].Active).To(BeTrue())
+			Expect(statuses[0].NextTransition.Time).To(BeTemporally("==", time.Date(2026, time.January, 5, 17, 0, 0, 0, time.UTC)))
+
+			Expect(statuses[1].Rule).To(Equal(int32(2)))
+			Expect(statuses[1].Active).To(BeFalse())
+			Expect(statuses[1].NextTransition.Time).To(BeTemporally("==", time.Date(2026, time.January, 5, 18, 0, 0, 0, time.UTC)))
+
+			Expect(next).To(BeTemporally("==", time.Date(2026, time.January, 5, 17, 0, 0, 0, time.UTC)))
+		})
+
+		It("should deactivate the rules with an invalid schedule", func() {
+			statuses, _ := EvaluateSchedules(rules, now)
+			Expect(statuses[2].Rule).To(Equal(int32(3)))
+			Expect(statuses[2].Active).To(BeFalse())
+			Expect(statuses[2].NextTransition).To(BeNil())
+			Expect(statuses[2].Message).ToNot(BeEmpty())
+		})
+
+		It("should return nothing when no rule is scheduled", func() {
+			statuses, next := EvaluateSchedules([]connectivityv1.Rule{{Action: connectivityv1.ActionAllow}}, now)
+			Expect(statuses).To(BeEmpty())
+			Expect(next).To(BeZero())
+		})
+	})
+
+	Describe("ActiveRuleIndexes", func() {
+		It("should keep the unscheduled rules and the scheduled rules whose window is open, in order", func() {
+			indexes, next := ActiveRuleIndexes(rules, now)
+			Expect(indexes).To(Equal([]int{0, 1}))
+			Expect(next).To(BeTemporally("==", time.Date(2026, time.January, 5, 17, 0, 0, 0, time.UTC)))
+		})
+	})
+})
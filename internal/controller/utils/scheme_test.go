@@ -15,6 +15,8 @@
 package utils
 
 import (
+	authv1beta1 "github.com/liqotech/liqo/apis/authentication/v1beta1"
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
 	ipamv1alpha1 "github.com/liqotech/liqo/apis/ipam/v1alpha1"
 	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
 	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
@@ -44,6 +46,10 @@ var _ = Describe("Scheme Utilities", func() {
 			// Verify that Liqo offloading types are registered
 			Expect(scheme.IsGroupRegistered(offloadingv1beta1.SchemeGroupVersion.Group)).To(BeTrue())
 
+			// Verify that Liqo core and authentication types are registered
+			Expect(scheme.IsGroupRegistered(liqov1beta1.GroupVersion.Group)).To(BeTrue())
+			Expect(scheme.IsGroupRegistered(authv1beta1.GroupVersion.Group)).To(BeTrue())
+
 			// Verify that connectivity types are registered
 			Expect(scheme.IsGroupRegistered(connectivityv1.GroupVersion.Group)).To(BeTrue())
 		})
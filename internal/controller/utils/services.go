@@ -0,0 +1,310 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"cmp"
+	"context"
+	"net/netip"
+	"slices"
+	"sync"
+
+	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ServicePort is a transport port exposed by a Service.
+type ServicePort struct {
+	// Name is the name of the port, shared by the Service port and the corresponding endpoint port.
+	Name string
+	// Protocol is the transport protocol of the port.
+	Protocol corev1.Protocol
+	// Port is the port number.
+	Port int32
+}
+
+// ServiceEndpoints contains the addresses and ports through which a Service is reachable.
+type ServiceEndpoints struct {
+	// Namespace is the namespace of the Service.
+	Namespace string
+	// Addresses are the cluster IPs of the Service and the addresses of its ready endpoints, sorted.
+	Addresses []string
+	// Ports are the ports of the Service, sorted.
+	Ports []ServicePort
+	// TargetPorts are the ports of the endpoints of the Service, sorted.
+	TargetPorts []ServicePort
+	// Selector is the pod selector of the Service. It is empty if the endpoints are not managed by Kubernetes.
+	Selector map[string]string
+}
+
+// GetServiceEndpoints retrieves the addresses and ports of the given Service, including both
+// its virtual IPs and the endpoints listed in its EndpointSlices, since traffic may be observed
+// before or after the service translation. A missing Service has no endpoints, hence it matches
+// no traffic, so that the other rules keep being enforced: GetMissingServices reports it.
+func GetServiceEndpoints(ctx context.Context, cl client.Client, namespace, name string) (*ServiceEndpoints, error) {
+	var service corev1.Service
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &service); err != nil {
+		if apierrors.IsNotFound(err) {
+			return &ServiceEndpoints{Namespace: namespace}, nil
+		}
+		return nil, err
+	}
+
+	endpoints := ServiceEndpoints{Namespace: namespace, Selector: service.Spec.Selector}
+
+	for _, ip := range service.Spec.ClusterIPs {
+		if ip != "" && ip != corev1.ClusterIPNone {
+			endpoints.Addresses = append(endpoints.Addresses, ip)
+		}
+	}
+	for _, port := range service.Spec.Ports {
+		endpoints.Ports = append(endpoints.Ports, ServicePort{Name: port.Name, Protocol: port.Protocol, Port: port.Port})
+	}
+
+	var endpointSlices discoveryv1.EndpointSliceList
+	if err := cl.List(ctx, &endpointSlices, client.InNamespace(namespace),
+		client.MatchingLabels{discoveryv1.LabelServiceName: name}); err != nil {
+		return nil, err
+	}
+
+	for i := range endpointSlices.Items {
+		slice := &endpointSlices.Items[i]
+		for j := range slice.Endpoints {
+			endpoint := &slice.Endpoints[j]
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			endpoints.Addresses = append(endpoints.Addresses, endpoint.Addresses...)
+		}
+		for _, port := range slice.Ports {
+			if port.Port == nil {
+				continue
+			}
+			endpoints.TargetPorts = append(endpoints.TargetPorts, ServicePort{
+				Name:     ptr.Deref(port.Name, ""),
+				Protocol: ptr.Deref(port.Protocol, corev1.ProtocolTCP),
+				Port:     *port.Port,
+			})
+		}
+	}
+
+	slices.Sort(endpoints.Addresses)
+	endpoints.Addresses = slices.Compact(endpoints.Addresses)
+	endpoints.Ports = sortServicePorts(endpoints.Ports)
+	endpoints.TargetPorts = sortServicePorts(endpoints.TargetPorts)
+
+	return &endpoints, nil
+}
+
+// GetMissingServices returns the given Services that do not exist, sorted and without duplicates.
+func GetMissingServices(ctx context.Context, cl client.Client, services []types.NamespacedName) ([]types.NamespacedName, error) {
+	var missing []types.NamespacedName
+	for _, key := range services {
+		if err := cl.Get(ctx, key, &corev1.Service{}); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, err
+			}
+			missing = append(missing, key)
+		}
+	}
+
+	slices.SortFunc(missing, func(a, b types.NamespacedName) int {
+		return cmp.Compare(a.String(), b.String())
+	})
+	return slices.Compact(missing), nil
+}
+
+// GetServiceAddressesFromConsumer returns the addresses of the Services reflected on the provider cluster
+// by the given consumer cluster: their virtual IPs and the addresses of the endpoints listed in their
+// EndpointSlices, both sorted. Reflected resources are identified by the liqo.io/origin-cluster-id label.
+//
+// For provider only!
+func GetServiceAddressesFromConsumer(ctx context.Context, cl client.Client, consumerClusterID string) (virtualIPs, endpoints []string, err error) {
+	var services corev1.ServiceList
+	if err := cl.List(ctx, &services, client.MatchingLabels{forge.LiqoOriginClusterIDKey: consumerClusterID}); err != nil {
+		return nil, nil, err
+	}
+
+	virtualIPs = make([]string, 0, len(services.Items))
+	for i := range services.Items {
+		for _, ip := range services.Items[i].Spec.ClusterIPs {
+			if ip != "" && ip != corev1.ClusterIPNone {
+				virtualIPs = append(virtualIPs, ip)
+			}
+		}
+	}
+
+	var endpointSlices discoveryv1.EndpointSliceList
+	if err := cl.List(ctx, &endpointSlices, client.MatchingLabels{forge.LiqoOriginClusterIDKey: consumerClusterID}); err != nil {
+		return nil, nil, err
+	}
+
+	endpoints = make([]string, 0)
+	for i := range endpointSlices.Items {
+		for j := range endpointSlices.Items[i].Endpoints {
+			endpoint := &endpointSlices.Items[i].Endpoints[j]
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			endpoints = append(endpoints, endpoint.Addresses...)
+		}
+	}
+
+	slices.Sort(virtualIPs)
+	slices.Sort(endpoints)
+	return slices.Compact(virtualIPs), slices.Compact(endpoints), nil
+}
+
+// AllPorts returns both the Service ports and the target ports, sorted and without duplicates.
+func (e *ServiceEndpoints) AllPorts() []ServicePort {
+	return sortServicePorts(slices.Concat(e.Ports, e.TargetPorts))
+}
+
+// FilterPorts returns a copy of the endpoints whose ports and target ports are restricted to the given names.
+// If no name is given, the endpoints are returned unchanged.
+func (e *ServiceEndpoints) FilterPorts(names []string) *ServiceEndpoints {
+	if len(names) == 0 {
+		return e
+	}
+
+	drop := func(port ServicePort) bool { return !slices.Contains(names, port.Name) }
+	filtered := *e
+	filtered.Ports = slices.DeleteFunc(slices.Clone(e.Ports), drop)
+	filtered.TargetPorts = slices.DeleteFunc(slices.Clone(e.TargetPorts), drop)
+	return &filtered
+}
+
+// ForgeServiceNetworkPolicyPeers creates the NetworkPolicy peers matching the endpoints of a Service.
+// NetworkPolicies are enforced after the service translation, hence the backing pods are selected
+// through the Service selector, falling back to the endpoint addresses when the Service has no selector.
+func ForgeServiceNetworkPolicyPeers(endpoints *ServiceEndpoints) []networkingv1.NetworkPolicyPeer {
+	if len(endpoints.Selector) > 0 {
+		return []networkingv1.NetworkPolicyPeer{{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					corev1.LabelMetadataName: endpoints.Namespace,
+				},
+			},
+			PodSelector: &metav1.LabelSelector{
+				MatchLabels: endpoints.Selector,
+			},
+		}}
+	}
+
+	return ForgeAddressNetworkPolicyPeers(endpoints.Addresses)
+}
+
+// ForgeAddressNetworkPolicyPeers creates a NetworkPolicy peer matching each of the given addresses.
+// Invalid addresses are skipped.
+func ForgeAddressNetworkPolicyPeers(addresses []string) []networkingv1.NetworkPolicyPeer {
+	peers := make([]networkingv1.NetworkPolicyPeer, 0, len(addresses))
+	for _, address := range addresses {
+		addr, err := netip.ParseAddr(address)
+		if err != nil {
+			continue
+		}
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			IPBlock: &networkingv1.IPBlock{
+				CIDR: netip.PrefixFrom(addr, addr.BitLen()).String(),
+			},
+		})
+	}
+	return peers
+}
+
+// ForgeNetworkPolicyPorts creates the NetworkPolicy ports corresponding to the given Service ports.
+func ForgeNetworkPolicyPorts(ports []ServicePort) []networkingv1.NetworkPolicyPort {
+	policyPorts := make([]networkingv1.NetworkPolicyPort, 0, len(ports))
+	for _, port := range ports {
+		policyPorts = append(policyPorts, networkingv1.NetworkPolicyPort{
+			Port:     ptr.To(intstr.FromInt32(port.Port)),
+			Protocol: ptr.To(port.Protocol),
+		})
+	}
+	return policyPorts
+}
+
+// ForgeL4Proto converts a Kubernetes protocol to the corresponding firewall protocol.
+// It returns false for the protocols the firewall cannot match (e.g., SCTP).
+func ForgeL4Proto(protocol corev1.Protocol) (networkingv1beta1firewall.L4Proto, bool) {
+	switch protocol {
+	case corev1.ProtocolTCP:
+		return networkingv1beta1firewall.L4ProtoTCP, true
+	case corev1.ProtocolUDP:
+		return networkingv1beta1firewall.L4ProtoUDP, true
+	default:
+		return "", false
+	}
+}
+
+// sortServicePorts sorts the given ports by protocol, number and name, removing the duplicates.
+func sortServicePorts(ports []ServicePort) []ServicePort {
+	slices.SortFunc(ports, func(a, b ServicePort) int {
+		return cmp.Or(cmp.Compare(a.Protocol, b.Protocol), cmp.Compare(a.Port, b.Port), cmp.Compare(a.Name, b.Name))
+	})
+	return slices.Compact(ports)
+}
+
+// ServiceReferences tracks the Services referenced by each PeeringConnectivity once the rules of its chain
+// are merged with the AccessGrants and the namespace restrictions, which its spec alone does not reveal.
+type ServiceReferences struct {
+	mutex    sync.Mutex
+	services map[types.NamespacedName][]types.NamespacedName
+}
+
+// NewServiceReferences creates a new empty ServiceReferences.
+func NewServiceReferences() *ServiceReferences {
+	return &ServiceReferences{services: make(map[types.NamespacedName][]types.NamespacedName)}
+}
+
+// Set replaces the Services referenced by the given PeeringConnectivity.
+func (r *ServiceReferences) Set(pc types.NamespacedName, services []types.NamespacedName) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(services) == 0 {
+		delete(r.services, pc)
+		return
+	}
+	r.services[pc] = slices.Clone(services)
+}
+
+// Delete forgets the Services referenced by the given PeeringConnectivity.
+func (r *ServiceReferences) Delete(pc types.NamespacedName) {
+	r.Set(pc, nil)
+}
+
+// References returns whether the given PeeringConnectivity references the given Service.
+func (r *ServiceReferences) References(pc, service types.NamespacedName) bool {
+	if r == nil {
+		return false
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return slices.Contains(r.services[pc], service)
+}
@@ -0,0 +1,280 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+
+	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Services Utilities", func() {
+	var (
+		ctx    context.Context
+		scheme *runtime.Scheme
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		RegisterScheme(scheme)
+	})
+
+	Describe("GetServiceEndpoints", func() {
+		var service *corev1.Service
+
+		BeforeEach(func() {
+			service = &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "kube-dns", Namespace: "kube-system"},
+				Spec: corev1.ServiceSpec{
+					ClusterIPs: []string{"10.96.0.10"},
+					Selector:   map[string]string{"k8s-app": "kube-dns"},
+					Ports: []corev1.ServicePort{
+						{Name: "dns", Protocol: corev1.ProtocolUDP, Port: 53},
+						{Name: "dns-tcp", Protocol: corev1.ProtocolTCP, Port: 53},
+					},
+				},
+			}
+		})
+
+		It("should return the virtual IPs, the ready endpoints and the ports of the Service", func() {
+			slice := &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kube-dns-abcde",
+					Namespace: "kube-system",
+					Labels:    map[string]string{discoveryv1.LabelServiceName: "kube-dns"},
+				},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints: []discoveryv1.Endpoint{
+					{Addresses: []string{"10.0.0.5"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)}},
+					{Addresses: []string{"10.0.0.4"}},
+					{Addresses: []string{"10.0.0.6"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(false)}},
+				},
+				Ports: []discoveryv1.EndpointPort{
+					{Name: ptr.To("dns"), Protocol: ptr.To(corev1.ProtocolUDP), Port: ptr.To[int32](5353)},
+					{Name: ptr.To("dns-tcp"), Protocol: ptr.To(corev1.ProtocolTCP), Port: ptr.To[int32](53)},
+				},
+			}
+			other := &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "other-abcde",
+					Namespace: "kube-system",
+					Labels:    map[string]string{discoveryv1.LabelServiceName: "other"},
+				},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{"10.0.0.100"}}},
+			}
+
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(service, slice, other).Build()
+
+			endpoints, err := GetServiceEndpoints(ctx, cl, "kube-system", "kube-dns")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(endpoints.Addresses).To(Equal([]string{"10.0.0.4", "10.0.0.5", "10.96.0.10"}))
+			Expect(endpoints.Ports).To(Equal([]ServicePort{
+				{Name: "dns-tcp", Protocol: corev1.ProtocolTCP, Port: 53},
+				{Name: "dns", Protocol: corev1.ProtocolUDP, Port: 53},
+			}))
+			Expect(endpoints.TargetPorts).To(Equal([]ServicePort{
+				{Name: "dns-tcp", Protocol: corev1.ProtocolTCP, Port: 53},
+				{Name: "dns", Protocol: corev1.ProtocolUDP, Port: 5353},
+			}))
+			Expect(endpoints.Selector).To(Equal(map[string]string{"k8s-app": "kube-dns"}))
+		})
+
+		It("should ignore the virtual IP of headless Services", func() {
+			service.Spec.ClusterIPs = []string{corev1.ClusterIPNone}
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(service).Build()
+
+			endpoints, err := GetServiceEndpoints(ctx, cl, "kube-system", "kube-dns")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(endpoints.Addresses).To(BeEmpty())
+		})
+
+		It("should return no endpoints when the Service does not exist", func() {
+			cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+			endpoints, err := GetServiceEndpoints(ctx, cl, "kube-system", "kube-dns")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(endpoints.Addresses).To(BeEmpty())
+			Expect(endpoints.AllPorts()).To(BeEmpty())
+			Expect(endpoints.Selector).To(BeEmpty())
+		})
+	})
+
+	Describe("GetMissingServices", func() {
+		It("should return the Services that do not exist, sorted and without duplicates", func() {
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			}).Build()
+
+			web := types.NamespacedName{Namespace: "default", Name: "web"}
+			db := types.NamespacedName{Namespace: "default", Name: "db"}
+			cache := types.NamespacedName{Namespace: "default", Name: "cache"}
+			missing, err := GetMissingServices(ctx, cl, []types.NamespacedName{db, web, cache, db})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missing).To(Equal([]types.NamespacedName{cache, db}))
+		})
+	})
+
+	Describe("GetServiceAddressesFromConsumer", func() {
+		It("should return the virtual IPs and the ready endpoints of the reflected Services", func() {
+			reflected := map[string]string{forge.LiqoOriginClusterIDKey: "consumer"}
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "offloaded", Labels: reflected},
+					Spec:       corev1.ServiceSpec{ClusterIPs: []string{"10.96.0.20"}},
+				},
+				&corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{Name: "headless", Namespace: "offloaded", Labels: reflected},
+					Spec:       corev1.ServiceSpec{ClusterIPs: []string{corev1.ClusterIPNone}},
+				},
+				&corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{Name: "local", Namespace: "offloaded"},
+					Spec:       corev1.ServiceSpec{ClusterIPs: []string{"10.96.0.30"}},
+				},
+				&discoveryv1.EndpointSlice{
+					ObjectMeta:  metav1.ObjectMeta{Name: "web-abcde", Namespace: "offloaded", Labels: reflected},
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints: []discoveryv1.Endpoint{
+						{Addresses: []string{"10.71.0.5"}},
+						{Addresses: []string{"10.71.0.6"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(false)}},
+					},
+				},
+			).Build()
+
+			virtualIPs, endpoints, err := GetServiceAddressesFromConsumer(ctx, cl, "consumer")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(virtualIPs).To(Equal([]string{"10.96.0.20"}))
+			Expect(endpoints).To(Equal([]string{"10.71.0.5"}))
+		})
+	})
+
+	Describe("ServiceEndpoints ports", func() {
+		var endpoints *ServiceEndpoints
+
+		BeforeEach(func() {
+			endpoints = &ServiceEndpoints{
+				Ports: []ServicePort{
+					{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+					{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090},
+				},
+				TargetPorts: []ServicePort{
+					{Name: "http", Protocol: corev1.ProtocolTCP, Port: 8080},
+					{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090},
+				},
+			}
+		})
+
+		It("should merge the Service and target ports without duplicates", func() {
+			Expect(endpoints.AllPorts()).To(Equal([]ServicePort{
+				{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+				{Name: "http", Protocol: corev1.ProtocolTCP, Port: 8080},
+				{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090},
+			}))
+		})
+
+		It("should keep only the ports with the given names", func() {
+			filtered := endpoints.FilterPorts([]string{"http"})
+			Expect(filtered.Ports).To(Equal([]ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80}}))
+			Expect(filtered.TargetPorts).To(Equal([]ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 8080}}))
+			Expect(endpoints.Ports).To(HaveLen(2))
+		})
+
+		It("should keep all the ports when no name is given", func() {
+			Expect(endpoints.FilterPorts(nil)).To(Equal(endpoints))
+		})
+	})
+
+	Describe("ForgeServiceNetworkPolicyPeers", func() {
+		It("should select the backing pods through the Service selector", func() {
+			peers := ForgeServiceNetworkPolicyPeers(&ServiceEndpoints{
+				Namespace: "default",
+				Addresses: []string{"10.0.0.5"},
+				Selector:  map[string]string{"app": "web"},
+			})
+			Expect(peers).To(HaveLen(1))
+			Expect(peers[0].NamespaceSelector.MatchLabels).To(Equal(map[string]string{corev1.LabelMetadataName: "default"}))
+			Expect(peers[0].PodSelector.MatchLabels).To(Equal(map[string]string{"app": "web"}))
+		})
+
+		It("should fall back to the endpoint addresses when the Service has no selector", func() {
+			peers := ForgeServiceNetworkPolicyPeers(&ServiceEndpoints{
+				Namespace: "default",
+				Addresses: []string{"10.0.0.5", "fd00::5"},
+			})
+			Expect(peers).To(HaveLen(2))
+			Expect(peers[0].IPBlock.CIDR).To(Equal("10.0.0.5/32"))
+			Expect(peers[1].IPBlock.CIDR).To(Equal("fd00::5/128"))
+		})
+	})
+
+	Describe("ForgeL4Proto", func() {
+		It("should convert the protocols supported by the firewall", func() {
+			proto, ok := ForgeL4Proto(corev1.ProtocolUDP)
+			Expect(ok).To(BeTrue())
+			Expect(proto).To(Equal(networkingv1beta1firewall.L4ProtoUDP))
+		})
+
+		It("should reject the protocols not supported by the firewall", func() {
+			_, ok := ForgeL4Proto(corev1.ProtocolSCTP)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("ServiceReferences", func() {
+		pc := types.NamespacedName{Namespace: "liqo-tenant-remote", Name: "pc"}
+		web := types.NamespacedName{Namespace: "default", Name: "web"}
+		db := types.NamespacedName{Namespace: "default", Name: "db"}
+
+		It("should report the Services set for the PeeringConnectivity", func() {
+			references := NewServiceReferences()
+			references.Set(pc, []types.NamespacedName{web})
+			Expect(references.References(pc, web)).To(BeTrue())
+			Expect(references.References(pc, db)).To(BeFalse())
+			Expect(references.References(types.NamespacedName{Namespace: "other", Name: "pc"}, web)).To(BeFalse())
+		})
+
+		It("should replace the Services previously set", func() {
+			references := NewServiceReferences()
+			references.Set(pc, []types.NamespacedName{web})
+			references.Set(pc, []types.NamespacedName{db})
+			Expect(references.References(pc, web)).To(BeFalse())
+			Expect(references.References(pc, db)).To(BeTrue())
+		})
+
+		It("should forget the Services of a deleted PeeringConnectivity", func() {
+			references := NewServiceReferences()
+			references.Set(pc, []types.NamespacedName{web})
+			references.Delete(pc)
+			Expect(references.References(pc, web)).To(BeFalse())
+		})
+
+		It("should do nothing if not initialized", func() {
+			var references *ServiceReferences
+			references.Set(pc, []types.NamespacedName{web})
+			Expect(references.References(pc, web)).To(BeFalse())
+		})
+	})
+})
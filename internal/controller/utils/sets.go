@@ -15,8 +15,18 @@
 package utils
 
 import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
 	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
 )
 
 // ForgePodIpsSet creates a firewall Set containing the IP addresses of the given pods.
@@ -27,23 +37,147 @@ import (
 //   - setName: The name to assign to the firewall set (used for referencing in rules)
 //   - pods: The list of pods whose IPs should be included in the set
 //
-// Returns a networkingv1beta1firewall.Set containing the pod IPs.
+// Returns a networkingv1beta1firewall.Set containing the pod IPs, sorted and deduplicated.
 func ForgePodIpsSet(setName string, pods []corev1.Pod) networkingv1beta1firewall.Set {
-	setElements := make([]networkingv1beta1firewall.SetElement, 0)
+	podIps := make([]string, 0, len(pods))
 	for _, pod := range pods {
 		podIp := pod.Status.PodIP
 		if podIp == "" {
 			// Skip pods that don't have an IP address yet.
 			continue
 		}
-		setElements = append(setElements, networkingv1beta1firewall.SetElement{
-			Key: podIp,
-		})
+		podIps = append(podIps, podIp)
+	}
+
+	return networkingv1beta1firewall.Set{
+		Name:     setName,
+		KeyType:  networkingv1beta1firewall.SetDataTypeIPAddr,
+		Elements: forgeSetElements(podIps),
+	}
+}
+
+// ForgeIPsSet creates a firewall Set containing the given IP addresses, sorted and deduplicated.
+// Only IPv4 addresses are included, as the firewall tables created by the connectivity engine are IPv4-only.
+func ForgeIPsSet(setName string, ips []string) networkingv1beta1firewall.Set {
+	ipv4 := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		if addr, err := netip.ParseAddr(ip); err == nil && addr.Is4() {
+			ipv4 = append(ipv4, ip)
+		}
 	}
 
 	return networkingv1beta1firewall.Set{
 		Name:     setName,
 		KeyType:  networkingv1beta1firewall.SetDataTypeIPAddr,
-		Elements: setElements,
+		Elements: forgeSetElements(ipv4),
+	}
+}
+
+// forgeSetElements returns the set elements with the given keys, sorted by address and deduplicated,
+// so that the rendered sets do not change with the order the addresses are listed in.
+// The given slice is sorted in place.
+func forgeSetElements(keys []string) []networkingv1beta1firewall.SetElement {
+	slices.SortFunc(keys, compareAddresses)
+	keys = slices.CompactFunc(keys, func(a, b string) bool { return compareAddresses(a, b) == 0 })
+
+	elements := make([]networkingv1beta1firewall.SetElement, 0, len(keys))
+	for _, key := range keys {
+		elements = append(elements, networkingv1beta1firewall.SetElement{Key: key})
+	}
+	return elements
+}
+
+// compareAddresses orders the valid IP addresses numerically, followed by the other values in lexical order.
+func compareAddresses(a, b string) int {
+	addrA, errA := netip.ParseAddr(a)
+	addrB, errB := netip.ParseAddr(b)
+	switch {
+	case errA == nil && errB == nil:
+		return addrA.Compare(addrB)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// SortSets sorts the given firewall sets by name, so that the rendered table does not depend
+// on the order the sets are forged in.
+func SortSets(sets []networkingv1beta1firewall.Set) {
+	slices.SortFunc(sets, func(a, b networkingv1beta1firewall.Set) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+}
+
+// namespaceSetPrefix is the prefix of the names of the firewall sets containing the pods of a namespace.
+const namespaceSetPrefix = "ns"
+
+// ForgeNamespaceSetName returns the name of the firewall set containing the IPs of the pods of the given namespace.
+func ForgeNamespaceSetName(namespace string) string {
+	return ForgeSetName(namespaceSetPrefix, namespace)
+}
+
+// serviceSetPrefix is the prefix of the names of the firewall sets containing the addresses of a Service.
+const serviceSetPrefix = "svc"
+
+// ForgeServiceSetName returns the name of the firewall set containing the addresses of the given Service.
+func ForgeServiceSetName(namespace, name string) string {
+	return ForgeSetName(serviceSetPrefix, namespace+"/"+name)
+}
+
+// fqdnSetPrefix is the prefix of the names of the firewall sets containing the addresses of a domain name.
+const fqdnSetPrefix = "fqdn"
+
+// ForgeFQDNSetName returns the name of the firewall set containing the addresses the given domain name resolves to.
+func ForgeFQDNSetName(name string) string {
+	return ForgeSetName(fqdnSetPrefix, name)
+}
+
+// SetNamesAnnotationKey is the annotation of the FirewallConfiguration resources mapping
+// the names of their sets to the policy constructs they represent.
+const SetNamesAnnotationKey = "connectivity.liqo.io/set-names"
+
+// SetOrigins maps the names of the firewall sets to the policy construct they represent
+// (e.g., "group/offloaded" or "namespace/default"), since the set names are hashed and
+// cannot be traced back to their origin.
+type SetOrigins map[string]string
+
+// ForgeGroupSetOrigin returns the description of a set representing the given resource group.
+func ForgeGroupSetOrigin(group connectivityv1.ResourceGroup) string {
+	return fmt.Sprintf("group/%s", group)
+}
+
+// ForgeServiceSetOrigin returns the description of a set representing the given Service.
+func ForgeServiceSetOrigin(namespace, name string) string {
+	return fmt.Sprintf("service/%s/%s", namespace, name)
+}
+
+// ForgeFQDNSetOrigin returns the description of a set representing the given domain name.
+func ForgeFQDNSetOrigin(name string) string {
+	return fmt.Sprintf("fqdn/%s", name)
+}
+
+// ForgeNamespaceSetOrigin returns the description of a set representing the given namespace.
+func ForgeNamespaceSetOrigin(namespace string) string {
+	return fmt.Sprintf("namespace/%s", namespace)
+}
+
+// Annotate stores the set origins in the SetNamesAnnotationKey annotation of the given object,
+// preserving its other annotations.
+func (o SetOrigins) Annotate(obj metav1.Object) error {
+	// Map keys are marshaled in sorted order, hence the annotation is deterministic.
+	value, err := json.Marshal(o)
+	if err != nil {
+		return fmt.Errorf("unable to marshal the set origins: %w", err)
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
 	}
+	annotations[SetNamesAnnotationKey] = string(value)
+	obj.SetAnnotations(annotations)
+	return nil
 }
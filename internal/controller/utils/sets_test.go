@@ -15,11 +15,15 @@
 package utils
 
 import (
+	"strings"
+
 	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
 )
 
 var _ = Describe("Sets Utilities", func() {
@@ -126,8 +130,34 @@ var _ = Describe("Sets Utilities", func() {
 
 				Expect(result.Name).To(Equal(setName))
 				Expect(result.Elements).To(HaveLen(2))
-				Expect(result.Elements[0].Key).To(Equal("2001:db8::1"))
-				Expect(result.Elements[1].Key).To(Equal("10.0.0.1"))
+				Expect(result.Elements[0].Key).To(Equal("10.0.0.1"))
+				Expect(result.Elements[1].Key).To(Equal("2001:db8::1"))
+			})
+		})
+
+		Context("when pods are listed in any order", func() {
+			It("should sort and deduplicate the pod IPs", func() {
+				pods := []corev1.Pod{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "pod1"},
+						Status:     corev1.PodStatus{PodIP: "10.0.0.10"},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "pod2"},
+						Status:     corev1.PodStatus{PodIP: "10.0.0.2"},
+					},
+					{
+						// A terminated pod whose IP has been reassigned.
+						ObjectMeta: metav1.ObjectMeta{Name: "pod3"},
+						Status:     corev1.PodStatus{PodIP: "10.0.0.10"},
+					},
+				}
+
+				result := ForgePodIpsSet("sorted-set", pods)
+
+				Expect(result.Elements).To(Equal([]networkingv1beta1firewall.SetElement{
+					{Key: "10.0.0.2"}, {Key: "10.0.0.10"},
+				}))
 			})
 		})
 
@@ -147,4 +177,74 @@ var _ = Describe("Sets Utilities", func() {
 			})
 		})
 	})
+
+	Describe("ForgeNamespaceSetName", func() {
+		It("should be bounded for the longest namespace names", func() {
+			name := ForgeNamespaceSetName(strings.Repeat("n", 63))
+			Expect(len(name)).To(BeNumerically("<=", maxSetNameLength))
+			Expect(name).To(HavePrefix("ns-nnn"))
+		})
+	})
+
+	Describe("ForgeIPsSet", func() {
+		It("should contain only the IPv4 addresses", func() {
+			set := ForgeIPsSet("svc-test", []string{"10.0.0.1", "fd00::1", "invalid", "10.96.0.10"})
+			Expect(set.Name).To(Equal("svc-test"))
+			Expect(set.KeyType).To(Equal(networkingv1beta1firewall.SetDataTypeIPAddr))
+			Expect(set.Elements).To(Equal([]networkingv1beta1firewall.SetElement{{Key: "10.0.0.1"}, {Key: "10.96.0.10"}}))
+		})
+
+		It("should sort the addresses numerically and deduplicate them", func() {
+			set := ForgeIPsSet("svc-test", []string{"10.0.0.10", "10.0.0.9", "10.0.0.10", "9.0.0.1"})
+			Expect(set.Elements).To(Equal([]networkingv1beta1firewall.SetElement{
+				{Key: "9.0.0.1"}, {Key: "10.0.0.9"}, {Key: "10.0.0.10"},
+			}))
+		})
+	})
+
+	Describe("SortSets", func() {
+		It("should sort the sets by name", func() {
+			sets := []networkingv1beta1firewall.Set{{Name: "ns-b"}, {Name: "grp-a"}, {Name: "ns-a"}}
+			SortSets(sets)
+			Expect(sets).To(Equal([]networkingv1beta1firewall.Set{{Name: "grp-a"}, {Name: "ns-a"}, {Name: "ns-b"}}))
+		})
+	})
+
+	Describe("ForgeServiceSetName", func() {
+		It("should distinguish Services with the same name in different namespaces", func() {
+			name := ForgeServiceSetName("default", "web")
+			Expect(len(name)).To(BeNumerically("<=", maxSetNameLength))
+			Expect(name).To(HavePrefix("svc-"))
+			Expect(name).NotTo(Equal(ForgeServiceSetName("other", "web")))
+		})
+	})
+
+	Describe("ForgeFQDNSetName", func() {
+		It("should be bounded and valid for wildcard domain names", func() {
+			name := ForgeFQDNSetName("*.storage." + strings.Repeat("a", 60) + ".example.com")
+			Expect(len(name)).To(BeNumerically("<=", maxSetNameLength))
+			Expect(name).To(MatchRegexp(`^fqdn-[A-Za-z0-9_-]+$`))
+		})
+	})
+
+	Describe("SetOrigins", func() {
+		It("should annotate the object preserving the existing annotations", func() {
+			obj := &metav1.ObjectMeta{Annotations: map[string]string{"foo": "bar"}}
+			origins := SetOrigins{
+				"ns-default-12345678":  ForgeNamespaceSetOrigin("default"),
+				"rg-offloaded-1234567": ForgeGroupSetOrigin(connectivityv1.ResourceGroupOffloaded),
+			}
+
+			Expect(origins.Annotate(obj)).To(Succeed())
+			Expect(obj.Annotations).To(HaveKeyWithValue("foo", "bar"))
+			Expect(obj.Annotations).To(HaveKeyWithValue(SetNamesAnnotationKey,
+				`{"ns-default-12345678":"namespace/default","rg-offloaded-1234567":"group/offloaded"}`))
+		})
+
+		It("should annotate an object without annotations", func() {
+			obj := &metav1.ObjectMeta{}
+			Expect(SetOrigins{}.Annotate(obj)).To(Succeed())
+			Expect(obj.Annotations).To(HaveKeyWithValue(SetNamesAnnotationKey, "{}"))
+		})
+	})
 })
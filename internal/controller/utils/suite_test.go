@@ -19,9 +19,26 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 )
 
+// liqoOpts are the default options of the Liqo installation used by the tests.
+var liqoOpts = &options.NewDefaultOptions().Liqo
+
 func TestUtils(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Utils Suite")
 }
+
+// newFakeClientBuilder returns a fake client builder with the given scheme,
+// registering the field indexes used to resolve the identity of the peerings.
+func newFakeClientBuilder(scheme *runtime.Scheme) *fake.ClientBuilder {
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	for _, index := range identityIndexes {
+		builder = builder.WithIndex(index.obj, index.field, index.extract)
+	}
+	return builder
+}
@@ -0,0 +1,18 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package counters reads back the packet and byte counters of the rules of the gateway firewall.
+// It defines a pluggable Reader, an HTTPReader querying a reporter running next to the gateway,
+// which serves the output of "nft --json list table", and a Prometheus collector exposing them.
+package counters
@@ -0,0 +1,82 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package counters
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	packetsDesc = prometheus.NewDesc("liqo_connectivity_rule_packets_total",
+		"Packets matched by a rule of the gateway firewall of a peering.", []string{"cluster_id", "chain", "rule"}, nil)
+	bytesDesc = prometheus.NewDesc("liqo_connectivity_rule_bytes_total",
+		"Bytes matched by a rule of the gateway firewall of a peering.", []string{"cluster_id", "chain", "rule"}, nil)
+)
+
+// Metrics is a Prometheus collector exposing the last counters read for each peering.
+type Metrics struct {
+	mutex     sync.Mutex
+	snapshots map[string]Snapshot
+}
+
+var _ prometheus.Collector = &Metrics{}
+
+// NewMetrics creates a new Metrics collector.
+func NewMetrics() *Metrics {
+	return &Metrics{snapshots: make(map[string]Snapshot)}
+}
+
+// Set replaces the counters of the peering with the given cluster.
+func (m *Metrics) Set(clusterID string, snapshot Snapshot) {
+	if m == nil {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.snapshots[clusterID] = snapshot
+}
+
+// Delete removes the counters of the peering with the given cluster.
+func (m *Metrics) Delete(clusterID string) {
+	if m == nil {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.snapshots, clusterID)
+}
+
+// Describe sends the descriptors of the metrics to the given channel.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- packetsDesc
+	ch <- bytesDesc
+}
+
+// Collect sends the counters of the rules of all the peerings to the given channel.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for clusterID, snapshot := range m.snapshots {
+		for key, counter := range snapshot {
+			ch <- prometheus.MustNewConstMetric(packetsDesc, prometheus.CounterValue, float64(counter.Packets), clusterID, key.Chain, key.Rule)
+			ch <- prometheus.MustNewConstMetric(bytesDesc, prometheus.CounterValue, float64(counter.Bytes), clusterID, key.Chain, key.Rule)
+		}
+	}
+}
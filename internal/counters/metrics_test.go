@@ -0,0 +1,78 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package counters
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metrics", func() {
+	var (
+		metrics  *Metrics
+		registry *prometheus.Registry
+	)
+
+	gather := func() map[string][]*dto.Metric {
+		families, err := registry.Gather()
+		Expect(err).NotTo(HaveOccurred())
+
+		result := make(map[string][]*dto.Metric)
+		for _, family := range families {
+			result[family.GetName()] = family.GetMetric()
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		metrics = NewMetrics()
+		registry = prometheus.NewRegistry()
+		Expect(registry.Register(metrics)).To(Succeed())
+	})
+
+	It("should expose the counters of each rule", func() {
+		metrics.Set("cluster-a", Snapshot{{Chain: "cluster-a-gw", Rule: "allowed-traffic-0"}: {Packets: 10, Bytes: 1500}})
+
+		families := gather()
+		Expect(families["liqo_connectivity_rule_packets_total"]).To(HaveLen(1))
+		Expect(families["liqo_connectivity_rule_packets_total"][0].GetCounter().GetValue()).To(Equal(10.0))
+		Expect(families["liqo_connectivity_rule_bytes_total"][0].GetCounter().GetValue()).To(Equal(1500.0))
+
+		labels := make(map[string]string)
+		for _, label := range families["liqo_connectivity_rule_packets_total"][0].GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		Expect(labels).To(Equal(map[string]string{
+			"cluster_id": "cluster-a", "chain": "cluster-a-gw", "rule": "allowed-traffic-0",
+		}))
+	})
+
+	It("should stop exposing the counters of a deleted peering", func() {
+		metrics.Set("cluster-a", Snapshot{{Chain: "cluster-a-gw", Rule: "allowed-traffic-0"}: {Packets: 10, Bytes: 1500}})
+		metrics.Delete("cluster-a")
+		Expect(gather()).To(BeEmpty())
+	})
+
+	It("should ignore a nil collector", func() {
+		var disabled *Metrics
+		Expect(func() {
+			disabled.Set("cluster-a", Snapshot{})
+			disabled.Delete("cluster-a")
+		}).NotTo(Panic())
+	})
+})
@@ -0,0 +1,137 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package counters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RuleKey identifies a rule of a firewall table by its chain and its name.
+type RuleKey struct {
+	Chain string
+	Rule  string
+}
+
+// Counter is the traffic matched by a rule since it was applied.
+type Counter struct {
+	Packets uint64
+	Bytes   uint64
+}
+
+// Add returns the sum of the two counters.
+func (c Counter) Add(other Counter) Counter {
+	return Counter{Packets: c.Packets + other.Packets, Bytes: c.Bytes + other.Bytes}
+}
+
+// Snapshot contains the counters of the named rules of a firewall table.
+type Snapshot map[RuleKey]Counter
+
+// Reader reads the counters of the rules of the gateway firewall table of a peering.
+type Reader interface {
+	// Read returns the counters of the rules of the given table, configured by the
+	// FirewallConfiguration in the given tenant namespace for the peering with the given cluster.
+	Read(ctx context.Context, clusterID, namespace, table string) (Snapshot, error)
+}
+
+// HTTPReader reads the counters from a reporter running next to the gateway of each peering,
+// which serves the output of "nft --json list table" for the requested table.
+type HTTPReader struct {
+	endpoint string
+	client   *http.Client
+}
+
+var _ Reader = &HTTPReader{}
+
+// NewHTTPReader creates a new HTTPReader querying the given endpoint. The {clusterID}, {namespace}
+// and {table} placeholders of the endpoint are replaced with the ones of the requested table.
+func NewHTTPReader(endpoint string, timeout time.Duration) *HTTPReader {
+	return &HTTPReader{endpoint: endpoint, client: &http.Client{Timeout: timeout}}
+}
+
+// Read queries the reporter of the given peering and parses the returned counters.
+func (r *HTTPReader) Read(ctx context.Context, clusterID, namespace, table string) (Snapshot, error) {
+	endpoint := strings.NewReplacer(
+		"{clusterID}", url.PathEscape(clusterID),
+		"{namespace}", url.PathEscape(namespace),
+		"{table}", url.PathEscape(table),
+	).Replace(r.endpoint)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("invalid counters endpoint %q: %w", endpoint, err)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query the counters of table %q: %w", table, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to query the counters of table %q: unexpected status %s", table, resp.Status)
+	}
+	return ParseNftables(resp.Body, table)
+}
+
+// nftablesOutput is the subset of the JSON output of nft describing the counters of the rules.
+type nftablesOutput struct {
+	Nftables []struct {
+		Rule *struct {
+			Table   string `json:"table"`
+			Chain   string `json:"chain"`
+			Comment string `json:"comment"`
+			Expr    []struct {
+				Counter *struct {
+					Packets uint64 `json:"packets"`
+					Bytes   uint64 `json:"bytes"`
+				} `json:"counter"`
+			} `json:"expr"`
+		} `json:"rule"`
+	} `json:"nftables"`
+}
+
+// ParseNftables parses the JSON output of nft, returning the counters of the rules of the given table.
+// The rules are identified by their comment, which holds the name they are configured with, and the
+// rules without a name or a counter are skipped.
+func ParseNftables(r io.Reader, table string) (Snapshot, error) {
+	var output nftablesOutput
+	if err := json.NewDecoder(r).Decode(&output); err != nil {
+		return nil, fmt.Errorf("unable to parse the nftables output: %w", err)
+	}
+
+	snapshot := Snapshot{}
+	for _, object := range output.Nftables {
+		rule := object.Rule
+		if rule == nil || rule.Table != table || rule.Comment == "" {
+			continue
+		}
+
+		for _, expr := range rule.Expr {
+			if expr.Counter != nil {
+				key := RuleKey{Chain: rule.Chain, Rule: rule.Comment}
+				snapshot[key] = snapshot[key].Add(Counter{Packets: expr.Counter.Packets, Bytes: expr.Counter.Bytes})
+				break
+			}
+		}
+	}
+	return snapshot, nil
+}
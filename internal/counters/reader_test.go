@@ -0,0 +1,102 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package counters
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nftablesSample = `{"nftables": [
+  {"metainfo": {"version": "1.0.9", "json_schema_version": 1}},
+  {"table": {"family": "inet", "name": "cluster-a-gw", "handle": 1}},
+  {"chain": {"family": "inet", "table": "cluster-a-gw", "name": "cluster-a-gw", "handle": 1}},
+  {"rule": {"family": "inet", "table": "cluster-a-gw", "chain": "cluster-a-gw", "handle": 2,
+    "comment": "allowed-traffic-0",
+    "expr": [{"match": {"op": "==", "left": {"ct": {"key": "state"}}, "right": "established"}},
+      {"counter": {"packets": 10, "bytes": 1500}}, {"accept": null}]}},
+  {"rule": {"family": "inet", "table": "cluster-a-gw", "chain": "cluster-a-gw", "handle": 3,
+    "comment": "allowed-traffic-1",
+    "expr": [{"counter": {"packets": 3, "bytes": 180}}, {"drop": null}]}},
+  {"rule": {"family": "inet", "table": "cluster-a-gw", "chain": "cluster-a-gw", "handle": 4,
+    "expr": [{"counter": {"packets": 7, "bytes": 700}}, {"drop": null}]}},
+  {"rule": {"family": "inet", "table": "cluster-a-gw", "chain": "cluster-a-gw", "handle": 5,
+    "comment": "uncounted", "expr": [{"accept": null}]}},
+  {"rule": {"family": "inet", "table": "other", "chain": "other", "handle": 6,
+    "comment": "allowed-traffic-0", "expr": [{"counter": {"packets": 1, "bytes": 1}}]}}
+]}`
+
+var _ = Describe("Reader", func() {
+	Describe("ParseNftables", func() {
+		It("should return the counters of the named rules of the table", func() {
+			snapshot, err := ParseNftables(strings.NewReader(nftablesSample), "cluster-a-gw")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshot).To(Equal(Snapshot{
+				{Chain: "cluster-a-gw", Rule: "allowed-traffic-0"}: {Packets: 10, Bytes: 1500},
+				{Chain: "cluster-a-gw", Rule: "allowed-traffic-1"}: {Packets: 3, Bytes: 180},
+			}))
+		})
+
+		It("should return an empty snapshot for a missing table", func() {
+			snapshot, err := ParseNftables(strings.NewReader(nftablesSample), "missing")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshot).To(BeEmpty())
+		})
+
+		It("should fail on invalid JSON", func() {
+			_, err := ParseNftables(strings.NewReader("not json"), "cluster-a-gw")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("HTTPReader", func() {
+		var (
+			server *httptest.Server
+			path   string
+			status int
+		)
+
+		BeforeEach(func() {
+			status = http.StatusOK
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				path = req.URL.Path
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(nftablesSample))
+			}))
+			DeferCleanup(server.Close)
+		})
+
+		It("should query the endpoint of the table and parse the counters", func() {
+			reader := NewHTTPReader(server.URL+"/{namespace}/{clusterID}/{table}", time.Second)
+			snapshot, err := reader.Read(context.Background(), "cluster-a", "liqo-tenant-a", "cluster-a-gw")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal("/liqo-tenant-a/cluster-a/cluster-a-gw"))
+			Expect(snapshot).To(HaveLen(2))
+		})
+
+		It("should fail when the reporter does not answer with success", func() {
+			status = http.StatusServiceUnavailable
+			reader := NewHTTPReader(server.URL+"/{table}", time.Second)
+			_, err := reader.Read(context.Background(), "cluster-a", "liqo-tenant-a", "cluster-a-gw")
+			Expect(err).To(MatchError(ContainSubstring("503")))
+		})
+	})
+})
@@ -0,0 +1,134 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fqdn
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
+)
+
+// Cache stores the addresses of the resolved FQDNs until their TTL expires,
+// so that each FQDN is resolved at most once per TTL regardless of the number of reconciliations.
+type Cache struct {
+	resolver Resolver
+	minTTL   time.Duration
+	maxTTL   time.Duration
+	now      func() time.Time
+
+	mutex   sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// cacheEntry contains the addresses of an FQDN and the time they must be refreshed at.
+type cacheEntry struct {
+	addresses  []string
+	expiration time.Time
+}
+
+// NewCache creates a new Cache resolving the FQDNs with the given resolver.
+// The TTL of the answers is clamped between minTTL and maxTTL.
+func NewCache(resolver Resolver, minTTL, maxTTL time.Duration) *Cache {
+	return &Cache{
+		resolver: resolver,
+		minTTL:   minTTL,
+		maxTTL:   maxTTL,
+		now:      time.Now,
+		entries:  make(map[string]*cacheEntry),
+	}
+}
+
+// NewCacheFromOptions creates a new Cache backed by a DNSResolver, configured with the given options.
+func NewCacheFromOptions(opts *options.FQDNOptions) *Cache {
+	return NewCache(NewDNSResolver(opts.DNSServer, opts.Timeout), opts.MinTTL, opts.MaxTTL)
+}
+
+// Lookup returns the sorted addresses of the given FQDN, resolving it again if its TTL expired.
+// If the resolution fails, the previously resolved addresses are returned and the resolution is
+// retried after the minimum TTL, so that transient DNS failures do not disrupt the traffic.
+func (c *Cache) Lookup(ctx context.Context, name string) ([]string, error) {
+	if c == nil {
+		return nil, fmt.Errorf("unable to resolve %q: the FQDN resolution is not configured", name)
+	}
+	name = Canonicalize(name)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, found := c.entries[name]
+	now := c.now()
+	if found && now.Before(entry.expiration) {
+		return entry.addresses, nil
+	}
+
+	answer, err := c.resolver.Resolve(ctx, name)
+	if err != nil {
+		if !found {
+			return nil, err
+		}
+		entry.expiration = now.Add(c.minTTL)
+		return entry.addresses, nil
+	}
+
+	c.entries[name] = &cacheEntry{
+		addresses:  formatAddresses(answer.Addresses),
+		expiration: now.Add(min(max(answer.TTL, c.minTTL), c.maxTTL)),
+	}
+	return c.entries[name].addresses, nil
+}
+
+// NextExpiration returns the earliest time the addresses of one of the given FQDNs must be refreshed at.
+// It returns false if none of the FQDNs has been resolved yet.
+func (c *Cache) NextExpiration(names []string) (time.Time, bool) {
+	if c == nil {
+		return time.Time{}, false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var (
+		next  time.Time
+		found bool
+	)
+	for _, name := range names {
+		entry, ok := c.entries[Canonicalize(name)]
+		if !ok {
+			continue
+		}
+		if !found || entry.expiration.Before(next) {
+			next, found = entry.expiration, true
+		}
+	}
+	return next, found
+}
+
+// formatAddresses converts the given addresses to strings, sorted and without duplicates.
+func formatAddresses(addresses []netip.Addr) []string {
+	sorted := slices.Clone(addresses)
+	slices.SortFunc(sorted, netip.Addr.Compare)
+	sorted = slices.Compact(sorted)
+
+	formatted := make([]string, len(sorted))
+	for i, addr := range sorted {
+		formatted[i] = addr.Unmap().String()
+	}
+	return formatted
+}
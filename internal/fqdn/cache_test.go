@@ -0,0 +1,129 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fqdn
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeResolver is a Resolver returning a configurable answer and counting the resolutions.
+type fakeResolver struct {
+	answer *Answer
+	err    error
+	calls  int
+}
+
+func (r *fakeResolver) Resolve(_ context.Context, _ string) (*Answer, error) {
+	r.calls++
+	return r.answer, r.err
+}
+
+var _ = Describe("Cache", func() {
+	var (
+		ctx      context.Context
+		resolver *fakeResolver
+		cache    *Cache
+		now      time.Time
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		resolver = &fakeResolver{answer: &Answer{
+			Addresses: []netip.Addr{netip.MustParseAddr("192.0.2.2"), netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.2")},
+			TTL:       time.Minute,
+		}}
+		cache = NewCache(resolver, 30*time.Second, 10*time.Minute)
+		now = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		cache.now = func() time.Time { return now }
+	})
+
+	It("should return the sorted addresses without duplicates", func() {
+		addresses, err := cache.Lookup(ctx, "Example.COM.")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(addresses).To(Equal([]string{"192.0.2.1", "192.0.2.2"}))
+	})
+
+	It("should not resolve the FQDN again before the TTL expires", func() {
+		_, err := cache.Lookup(ctx, "example.com")
+		Expect(err).NotTo(HaveOccurred())
+		now = now.Add(59 * time.Second)
+		_, err = cache.Lookup(ctx, "example.com.")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolver.calls).To(Equal(1))
+
+		now = now.Add(time.Second)
+		_, err = cache.Lookup(ctx, "example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolver.calls).To(Equal(2))
+	})
+
+	It("should clamp the TTL between the minimum and the maximum", func() {
+		resolver.answer.TTL = time.Second
+		_, err := cache.Lookup(ctx, "short.example.com")
+		Expect(err).NotTo(HaveOccurred())
+
+		resolver.answer.TTL = 24 * time.Hour
+		_, err = cache.Lookup(ctx, "long.example.com")
+		Expect(err).NotTo(HaveOccurred())
+
+		next, found := cache.NextExpiration([]string{"short.example.com"})
+		Expect(found).To(BeTrue())
+		Expect(next).To(Equal(now.Add(30 * time.Second)))
+		next, found = cache.NextExpiration([]string{"long.example.com"})
+		Expect(found).To(BeTrue())
+		Expect(next).To(Equal(now.Add(10 * time.Minute)))
+	})
+
+	It("should return the earliest expiration of the resolved FQDNs", func() {
+		_, err := cache.Lookup(ctx, "a.example.com")
+		Expect(err).NotTo(HaveOccurred())
+		now = now.Add(10 * time.Second)
+		_, err = cache.Lookup(ctx, "b.example.com")
+		Expect(err).NotTo(HaveOccurred())
+
+		next, found := cache.NextExpiration([]string{"b.example.com", "a.example.com", "unknown.example.com"})
+		Expect(found).To(BeTrue())
+		Expect(next).To(Equal(now.Add(50 * time.Second)))
+
+		_, found = cache.NextExpiration([]string{"unknown.example.com"})
+		Expect(found).To(BeFalse())
+	})
+
+	It("should keep the previous addresses when the resolution fails", func() {
+		_, err := cache.Lookup(ctx, "example.com")
+		Expect(err).NotTo(HaveOccurred())
+
+		now = now.Add(time.Hour)
+		resolver.err = errors.New("timeout")
+		addresses, err := cache.Lookup(ctx, "example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(addresses).To(Equal([]string{"192.0.2.1", "192.0.2.2"}))
+
+		next, _ := cache.NextExpiration([]string{"example.com"})
+		Expect(next).To(Equal(now.Add(30 * time.Second)))
+	})
+
+	It("should return the error when the FQDN was never resolved", func() {
+		resolver.err = errors.New("timeout")
+		_, err := cache.Lookup(ctx, "example.com")
+		Expect(err).To(HaveOccurred())
+	})
+})
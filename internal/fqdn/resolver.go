@@ -0,0 +1,269 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fqdn
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// DefaultResolvConfPath is the path of the resolver configuration of the host.
+	DefaultResolvConfPath = "/etc/resolv.conf"
+
+	// dnsPort is the port DNS servers listen on when not specified.
+	dnsPort = "53"
+
+	// maxUDPMessageSize is the size of the buffer used to receive the UDP responses.
+	maxUDPMessageSize = 4096
+)
+
+// Answer is the result of the resolution of an FQDN.
+type Answer struct {
+	// Addresses are the IPv4 and IPv6 addresses the FQDN resolves to.
+	Addresses []netip.Addr
+	// TTL is the lowest TTL of the records of the answer, zero if no record was returned.
+	TTL time.Duration
+}
+
+// Resolver resolves FQDNs to the corresponding addresses.
+type Resolver interface {
+	// Resolve returns the addresses of the given FQDN. A name without records is not an error,
+	// and results in an empty answer.
+	Resolve(ctx context.Context, name string) (*Answer, error)
+}
+
+// DNSResolver is a Resolver sending A and AAAA queries to a DNS server.
+// Unlike the resolver of the standard library, it exposes the TTL of the records.
+type DNSResolver struct {
+	// Server is the address (host:port) of the DNS server.
+	// If empty, the first nameserver of ResolvConfPath is used.
+	Server string
+	// ResolvConfPath is the path of the resolver configuration used when Server is empty.
+	ResolvConfPath string
+	// Timeout is the timeout of each query, applied in addition to the deadline of the context.
+	Timeout time.Duration
+}
+
+// NewDNSResolver creates a new DNSResolver querying the given server, or the nameserver
+// of the host if the server is empty.
+func NewDNSResolver(server string, timeout time.Duration) *DNSResolver {
+	return &DNSResolver{
+		Server:         server,
+		ResolvConfPath: DefaultResolvConfPath,
+		Timeout:        timeout,
+	}
+}
+
+// Resolve returns the IPv4 and IPv6 addresses of the given FQDN.
+func (r *DNSResolver) Resolve(ctx context.Context, name string) (*Answer, error) {
+	server, err := r.server()
+	if err != nil {
+		return nil, err
+	}
+
+	qname, err := dnsmessage.NewName(Canonicalize(name) + ".")
+	if err != nil {
+		return nil, fmt.Errorf("invalid FQDN %q: %w", name, err)
+	}
+
+	var answer Answer
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		addresses, ttl, err := r.query(ctx, server, qname, qtype)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve %q (%s): %w", name, qtype, err)
+		}
+		answer.Addresses = append(answer.Addresses, addresses...)
+		if len(addresses) > 0 && (answer.TTL == 0 || ttl < answer.TTL) {
+			answer.TTL = ttl
+		}
+	}
+
+	return &answer, nil
+}
+
+// server returns the address of the DNS server to query.
+func (r *DNSResolver) server() (string, error) {
+	if r.Server != "" {
+		return r.Server, nil
+	}
+
+	server, err := ReadResolvConfNameserver(r.ResolvConfPath)
+	if err != nil {
+		return "", fmt.Errorf("unable to determine the DNS server: %w", err)
+	}
+	return server, nil
+}
+
+// query sends a single query to the server, retrying over TCP if the UDP response is truncated.
+// It returns the addresses of the requested type and the lowest TTL of the answer records.
+func (r *DNSResolver) query(
+	ctx context.Context, server string, qname dnsmessage.Name, qtype dnsmessage.Type,
+) ([]netip.Addr, time.Duration, error) {
+	id := uint16(rand.N(1 << 16))
+	query, err := (&dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}},
+	}).Pack()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+
+	response, err := exchange(ctx, "udp", server, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(response); err != nil {
+		return nil, 0, fmt.Errorf("malformed response: %w", err)
+	}
+	if msg.Truncated {
+		if response, err = exchange(ctx, "tcp", server, query); err != nil {
+			return nil, 0, err
+		}
+		if err := msg.Unpack(response); err != nil {
+			return nil, 0, fmt.Errorf("malformed response: %w", err)
+		}
+	}
+
+	return parseResponse(&msg, id)
+}
+
+// exchange sends the query to the server with the given network protocol and returns the raw response.
+func exchange(ctx context.Context, network, server string, query []byte) ([]byte, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	if network == "udp" {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		buffer := make([]byte, maxUDPMessageSize)
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return nil, err
+		}
+		return buffer[:n], nil
+	}
+
+	// Over TCP, messages are prefixed by their length.
+	framed := binary.BigEndian.AppendUint16(nil, uint16(len(query)))
+	if _, err := conn.Write(append(framed, query...)); err != nil {
+		return nil, err
+	}
+	var length uint16
+	if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	response := make([]byte, length)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// parseResponse extracts the addresses and the lowest TTL of the records of the response.
+// CNAME records are followed by the recursive server, hence all the address records are considered.
+func parseResponse(msg *dnsmessage.Message, id uint16) ([]netip.Addr, time.Duration, error) {
+	if !msg.Response || msg.ID != id {
+		return nil, 0, fmt.Errorf("unexpected response")
+	}
+
+	switch msg.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		// The name does not exist, hence it has no addresses.
+		return nil, 0, nil
+	default:
+		return nil, 0, fmt.Errorf("the server returned %s", msg.RCode)
+	}
+
+	var (
+		addresses []netip.Addr
+		ttl       uint32
+	)
+	for _, answer := range msg.Answers {
+		var addr netip.Addr
+		switch body := answer.Body.(type) {
+		case *dnsmessage.AResource:
+			addr = netip.AddrFrom4(body.A)
+		case *dnsmessage.AAAAResource:
+			addr = netip.AddrFrom16(body.AAAA)
+		default:
+			continue
+		}
+		addresses = append(addresses, addr)
+		if len(addresses) == 1 || answer.Header.TTL < ttl {
+			ttl = answer.Header.TTL
+		}
+	}
+
+	return addresses, time.Duration(ttl) * time.Second, nil
+}
+
+// ReadResolvConfNameserver returns the address (host:port) of the first nameserver of the
+// given resolver configuration file.
+func ReadResolvConfNameserver(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return net.JoinHostPort(fields[1], dnsPort), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no nameserver found in %s", path)
+}
+
+// Canonicalize returns the canonical form of the given FQDN, lowercase and without the trailing dot.
+func Canonicalize(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
@@ -0,0 +1,229 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fqdn
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// stubRecord is a record served by the stub DNS server.
+type stubRecord struct {
+	addr netip.Addr
+	ttl  uint32
+}
+
+// stubServer is a minimal DNS server answering the A and AAAA queries with the configured records.
+type stubServer struct {
+	records map[string][]stubRecord
+	// rcode is the response code returned for the names without records.
+	rcode dnsmessage.RCode
+	// truncate sets the truncated flag on the UDP responses, forcing the clients to retry over TCP.
+	truncate bool
+
+	udp net.PacketConn
+	tcp net.Listener
+}
+
+// startStubServer starts a stub DNS server on a random local port, listening on both UDP and TCP.
+func startStubServer(records map[string][]stubRecord) *stubServer {
+	server := &stubServer{records: records, rcode: dnsmessage.RCodeNameError}
+
+	var err error
+	server.udp, err = net.ListenPacket("udp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	server.tcp, err = net.Listen("tcp", server.udp.LocalAddr().String())
+	Expect(err).NotTo(HaveOccurred())
+
+	go server.serveUDP()
+	go server.serveTCP()
+	DeferCleanup(func() {
+		server.udp.Close()
+		server.tcp.Close()
+	})
+	return server
+}
+
+// Addr returns the address of the stub server.
+func (s *stubServer) Addr() string {
+	return s.udp.LocalAddr().String()
+}
+
+func (s *stubServer) serveUDP() {
+	buffer := make([]byte, maxUDPMessageSize)
+	for {
+		n, addr, err := s.udp.ReadFrom(buffer)
+		if err != nil {
+			return
+		}
+		if response := s.answer(buffer[:n], s.truncate); response != nil {
+			_, _ = s.udp.WriteTo(response, addr)
+		}
+	}
+}
+
+func (s *stubServer) serveTCP() {
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			var length uint16
+			if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+				return
+			}
+			query := make([]byte, length)
+			if _, err := io.ReadFull(conn, query); err != nil {
+				return
+			}
+			if response := s.answer(query, false); response != nil {
+				_, _ = conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(response))), response...))
+			}
+		}()
+	}
+}
+
+// answer builds the response to the given query.
+func (s *stubServer) answer(query []byte, truncate bool) []byte {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil || len(msg.Questions) != 1 {
+		return nil
+	}
+
+	question := msg.Questions[0]
+	response := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: msg.ID, Response: true, Truncated: truncate, RCode: s.rcode},
+		Questions: msg.Questions,
+	}
+
+	records, found := s.records[question.Name.String()]
+	if found {
+		response.RCode = dnsmessage.RCodeSuccess
+	}
+	if truncate {
+		records = nil
+	}
+	for _, record := range records {
+		header := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: record.ttl}
+		switch {
+		case question.Type == dnsmessage.TypeA && record.addr.Is4():
+			header.Type = dnsmessage.TypeA
+			response.Answers = append(response.Answers, dnsmessage.Resource{
+				Header: header, Body: &dnsmessage.AResource{A: record.addr.As4()},
+			})
+		case question.Type == dnsmessage.TypeAAAA && record.addr.Is6():
+			header.Type = dnsmessage.TypeAAAA
+			response.Answers = append(response.Answers, dnsmessage.Resource{
+				Header: header, Body: &dnsmessage.AAAAResource{AAAA: record.addr.As16()},
+			})
+		}
+	}
+
+	packed, err := response.Pack()
+	if err != nil {
+		return nil
+	}
+	return packed
+}
+
+var _ = Describe("DNSResolver", func() {
+	var (
+		ctx    context.Context
+		server *stubServer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		server = startStubServer(map[string][]stubRecord{
+			"example.com.": {
+				{addr: netip.MustParseAddr("192.0.2.1"), ttl: 300},
+				{addr: netip.MustParseAddr("192.0.2.2"), ttl: 60},
+				{addr: netip.MustParseAddr("2001:db8::1"), ttl: 120},
+			},
+			"*.example.org.": {
+				{addr: netip.MustParseAddr("198.51.100.1"), ttl: 30},
+			},
+		})
+	})
+
+	It("should return the IPv4 and IPv6 addresses with the lowest TTL", func() {
+		answer, err := NewDNSResolver(server.Addr(), time.Second).Resolve(ctx, "Example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(answer.Addresses).To(ConsistOf(
+			netip.MustParseAddr("192.0.2.1"),
+			netip.MustParseAddr("192.0.2.2"),
+			netip.MustParseAddr("2001:db8::1"),
+		))
+		Expect(answer.TTL).To(Equal(time.Minute))
+	})
+
+	It("should resolve the wildcard records", func() {
+		answer, err := NewDNSResolver(server.Addr(), time.Second).Resolve(ctx, "*.example.org")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(answer.Addresses).To(ConsistOf(netip.MustParseAddr("198.51.100.1")))
+		Expect(answer.TTL).To(Equal(30 * time.Second))
+	})
+
+	It("should return an empty answer for non-existent names", func() {
+		answer, err := NewDNSResolver(server.Addr(), time.Second).Resolve(ctx, "missing.example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(answer.Addresses).To(BeEmpty())
+		Expect(answer.TTL).To(BeZero())
+	})
+
+	It("should return an error when the server fails", func() {
+		server.rcode = dnsmessage.RCodeServerFailure
+		_, err := NewDNSResolver(server.Addr(), time.Second).Resolve(ctx, "missing.example.com")
+		Expect(err).To(MatchError(ContainSubstring("RCodeServerFailure")))
+	})
+
+	It("should retry over TCP when the response is truncated", func() {
+		server.truncate = true
+		answer, err := NewDNSResolver(server.Addr(), time.Second).Resolve(ctx, "example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(answer.Addresses).To(HaveLen(3))
+	})
+
+	It("should use the nameserver of the resolver configuration when no server is set", func() {
+		host, port, err := net.SplitHostPort(server.Addr())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(port).NotTo(Equal(dnsPort))
+
+		// The stub server does not listen on the default port, hence only the configuration parsing is verified.
+		path := filepath.Join(GinkgoT().TempDir(), "resolv.conf")
+		Expect(os.WriteFile(path, []byte("# comment\nsearch svc.cluster.local\nnameserver "+host+"\n"), 0o600)).To(Succeed())
+		Expect(ReadResolvConfNameserver(path)).To(Equal(net.JoinHostPort(host, dnsPort)))
+	})
+
+	It("should fail when the resolver configuration has no nameserver", func() {
+		resolver := NewDNSResolver("", time.Second)
+		resolver.ResolvConfPath = filepath.Join(GinkgoT().TempDir(), "resolv.conf")
+		Expect(os.WriteFile(resolver.ResolvConfPath, []byte("search svc.cluster.local\n"), 0o600)).To(Succeed())
+		_, err := resolver.Resolve(ctx, "example.com")
+		Expect(err).To(MatchError(ContainSubstring("no nameserver")))
+	})
+})
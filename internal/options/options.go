@@ -0,0 +1,578 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"net"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/liqotech/liqo/pkg/gateway/tunnel"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// DefaultUplinkInterface is the default name of the gateway interface towards the local cluster.
+	DefaultUplinkInterface = "eth0"
+
+	// DefaultDNSServiceNamespace is the default namespace of the Service of the cluster DNS.
+	DefaultDNSServiceNamespace = "kube-system"
+
+	// DefaultDNSServiceName is the default name of the Service of the cluster DNS.
+	DefaultDNSServiceName = "kube-dns"
+
+	// DefaultLiqoNamespace is the default namespace Liqo is installed in.
+	DefaultLiqoNamespace = "liqo"
+
+	// DefaultPodCIDRNetworkName is the default name of the Network resource of the local pod CIDR.
+	DefaultPodCIDRNetworkName = "pod-cidr"
+
+	// DefaultServiceCIDRNetworkName is the default name of the Network resource of the local service CIDR.
+	DefaultServiceCIDRNetworkName = "service-cidr"
+
+	// DefaultRemotePodCIDRNetworkSuffix is the default suffix appended to the cluster ID
+	// to name the Network resource of the pod CIDR of a remote cluster.
+	DefaultRemotePodCIDRNetworkSuffix = "-pod"
+
+	// DefaultRemoteExternalCIDRNetworkSuffix is the default suffix appended to the cluster ID
+	// to name the Network resource of the external CIDR of a remote cluster.
+	DefaultRemoteExternalCIDRNetworkSuffix = "-external"
+
+	// DefaultFQDNMinTTL is the default minimum interval between two resolutions of the same FQDN.
+	DefaultFQDNMinTTL = 30 * time.Second
+
+	// DefaultFQDNMaxTTL is the default maximum interval between two resolutions of the same FQDN.
+	DefaultFQDNMaxTTL = 10 * time.Minute
+
+	// DefaultFQDNTimeout is the default timeout of the resolution of an FQDN.
+	DefaultFQDNTimeout = 5 * time.Second
+
+	// DefaultCountersInterval is the default minimum interval between two readings of the counters of the rules.
+	DefaultCountersInterval = time.Minute
+
+	// DefaultCountersTimeout is the default timeout of a reading of the counters of the rules.
+	DefaultCountersTimeout = 5 * time.Second
+
+	// DefaultRevisionHistoryLimit is the default number of revisions kept for each PeeringConnectivity.
+	DefaultRevisionHistoryLimit = 10
+
+	// DefaultChainHook is the default netfilter hook the gateway chains, and the hook the fabric chains, are attached to.
+	DefaultChainHook = "postrouting"
+
+	// DefaultChainPriority is the default priority of the gateway chains, and the priority of the fabric chains.
+	DefaultChainPriority = 200
+
+	// maxInterfaceNameLength is the maximum length of a network interface name (IFNAMSIZ - 1).
+	maxInterfaceNameLength = 15
+)
+
+// SupportedChainHooks are the netfilter hooks of the ip family the gateway chains can be attached to.
+var SupportedChainHooks = []string{"prerouting", "input", "forward", "output", "postrouting"}
+
+// Options contains the operator-wide configuration of the connectivity engine.
+type Options struct {
+	// Liqo contains the configuration of the Liqo installation the engine integrates with.
+	Liqo LiqoOptions
+
+	// Gateway contains the configuration of the gateway FirewallConfiguration.
+	Gateway GatewayOptions
+
+	// Internet contains the configuration of the internet resource group.
+	Internet InternetOptions
+
+	// DNS contains the configuration of the nameserver resource group.
+	DNS DNSOptions
+
+	// FQDN contains the configuration of the resolution of the FQDN parties.
+	FQDN FQDNOptions
+
+	// Peering contains the configuration of the PeeringConnectivity lifecycle driven by the peerings.
+	Peering PeeringOptions
+
+	// Counters contains the configuration of the reading of the counters of the rules.
+	Counters CountersOptions
+
+	// History contains the configuration of the revision history of the PeeringConnectivity resources.
+	History HistoryOptions
+}
+
+// LiqoOptions contains the namespace Liqo is installed in and the naming conventions of its
+// IPAM Network resources, which describe the CIDRs of the local and of the peered clusters.
+type LiqoOptions struct {
+	// Namespace is the namespace Liqo is installed in, containing the Network resources of the local cluster.
+	Namespace string
+
+	// PodCIDRNetworkName is the name of the Network resource of the local pod CIDR.
+	PodCIDRNetworkName string
+
+	// ServiceCIDRNetworkName is the name of the Network resource of the local service CIDR.
+	ServiceCIDRNetworkName string
+
+	// RemotePodCIDRNetworkSuffix is appended to the cluster ID to name the Network resource of the
+	// pod CIDR of a remote cluster, in its tenant namespace.
+	RemotePodCIDRNetworkSuffix string
+
+	// RemoteExternalCIDRNetworkSuffix is appended to the cluster ID to name the Network resource of the
+	// external CIDR of a remote cluster, in its tenant namespace.
+	RemoteExternalCIDRNetworkSuffix string
+}
+
+// GatewayOptions contains the configuration of the preamble rules of the gateway
+// FirewallConfiguration, which are evaluated before the user-defined rules.
+type GatewayOptions struct {
+	// TunnelInterface is the name of the interface the traffic from the peered cluster is received from.
+	TunnelInterface string
+
+	// UplinkInterfaces are the names of the interfaces connecting the gateway to the local cluster.
+	UplinkInterfaces []string
+
+	// BypassNonTunnelTraffic accepts all the traffic not received from the tunnel interface.
+	BypassNonTunnelTraffic bool
+
+	// BypassUplinkTraffic accepts all the traffic leaving through one of the uplink interfaces.
+	BypassUplinkTraffic bool
+
+	// Stateless disables the acceptance of the traffic of the established connections, which is
+	// filtered by the connectivity rules as any other packet.
+	Stateless bool
+
+	// AllowRelated accepts the traffic related to the established connections, unless stateless.
+	AllowRelated bool
+
+	// DropInvalid drops the traffic not belonging to any valid connection before any other rule.
+	DropInvalid bool
+
+	// ReevaluateEstablished accepts the traffic of the established connections only after the
+	// connectivity rules, so that the deny rules cut the connections established before them.
+	ReevaluateEstablished bool
+
+	// ChainHooks are the netfilter hooks the gateway chains are attached to. The chains are rendered
+	// once per hook, with the same connectivity rules.
+	ChainHooks []string
+
+	// ChainPriority is the priority of the gateway chains at their hooks. The chains restricting the
+	// traffic of the pods of a namespace are attached with the following priority.
+	ChainPriority int
+}
+
+// InternetOptions contains the configuration of the internet resource group.
+type InternetOptions struct {
+	// ExcludedCIDRs are site-specific ranges that are not part of the internet, in addition to
+	// the special-purpose ranges and the cluster networks.
+	ExcludedCIDRs []string
+}
+
+// DNSOptions contains the configuration of the nameserver resource group.
+type DNSOptions struct {
+	// ServiceNamespace is the namespace of the Service of the cluster DNS.
+	ServiceNamespace string
+
+	// ServiceName is the name of the Service of the cluster DNS.
+	ServiceName string
+}
+
+// FQDNOptions contains the configuration of the resolution of the FQDN parties.
+type FQDNOptions struct {
+	// DNSServer is the address (host:port) of the DNS server used to resolve the FQDNs.
+	// If empty, the first nameserver of /etc/resolv.conf is used.
+	DNSServer string
+
+	// MinTTL is the lower bound of the TTL of the resolved addresses, to limit the resolution rate.
+	MinTTL time.Duration
+
+	// MaxTTL is the upper bound of the TTL of the resolved addresses, to bound the staleness of the rules.
+	MaxTTL time.Duration
+
+	// Timeout is the timeout of a single resolution.
+	Timeout time.Duration
+}
+
+// PeeringOptions contains the configuration of the PeeringConnectivity lifecycle driven by the
+// Liqo ForeignCluster resources, which describe the peerings with the remote clusters.
+type PeeringOptions struct {
+	// AutoCreate creates a PeeringConnectivity in the tenant namespace when a peering is established.
+	AutoCreate bool
+
+	// DefaultSpecFile is the path of the YAML file containing the spec of the automatically created
+	// PeeringConnectivity resources. If empty, they are created without rules.
+	DefaultSpecFile string
+
+	// Cleanup deletes the PeeringConnectivity resources of a peering, and the resources generated
+	// from them, when the peering is torn down.
+	Cleanup bool
+}
+
+// HistoryOptions contains the configuration of the revisions recorded for each applied spec of the
+// PeeringConnectivity resources, which they can be rolled back to.
+type HistoryOptions struct {
+	// RevisionLimit is the number of revisions kept for each PeeringConnectivity, including the current one.
+	RevisionLimit int
+}
+
+// CountersOptions contains the configuration of the reading of the packet and byte counters of the
+// rules of the gateway, which are reported in the status of the PeeringConnectivity resources.
+type CountersOptions struct {
+	// ReporterEndpoint is the URL of the reporter serving the counters of the gateway of a peering.
+	// The {clusterID}, {namespace} and {table} placeholders are replaced with the ones of the peering.
+	// If empty, the counters are not read.
+	ReporterEndpoint string
+
+	// Interval is the minimum interval between two readings of the counters of the same peering.
+	Interval time.Duration
+
+	// Timeout is the timeout of a single reading.
+	Timeout time.Duration
+}
+
+// NewDefaultOptions returns the Options matching the default behavior of the connectivity engine.
+func NewDefaultOptions() *Options {
+	return &Options{
+		Liqo: LiqoOptions{
+			Namespace:                       DefaultLiqoNamespace,
+			PodCIDRNetworkName:              DefaultPodCIDRNetworkName,
+			ServiceCIDRNetworkName:          DefaultServiceCIDRNetworkName,
+			RemotePodCIDRNetworkSuffix:      DefaultRemotePodCIDRNetworkSuffix,
+			RemoteExternalCIDRNetworkSuffix: DefaultRemoteExternalCIDRNetworkSuffix,
+		},
+		Gateway: GatewayOptions{
+			TunnelInterface:        tunnel.TunnelInterfaceName,
+			UplinkInterfaces:       []string{DefaultUplinkInterface},
+			BypassNonTunnelTraffic: true,
+			BypassUplinkTraffic:    true,
+			AllowRelated:           true,
+			ChainHooks:             []string{DefaultChainHook},
+			ChainPriority:          DefaultChainPriority,
+		},
+		DNS: DNSOptions{
+			ServiceNamespace: DefaultDNSServiceNamespace,
+			ServiceName:      DefaultDNSServiceName,
+		},
+		FQDN: FQDNOptions{
+			MinTTL:  DefaultFQDNMinTTL,
+			MaxTTL:  DefaultFQDNMaxTTL,
+			Timeout: DefaultFQDNTimeout,
+		},
+		Peering: PeeringOptions{
+			Cleanup: true,
+		},
+		Counters: CountersOptions{
+			Interval: DefaultCountersInterval,
+			Timeout:  DefaultCountersTimeout,
+		},
+		History: HistoryOptions{
+			RevisionLimit: DefaultRevisionHistoryLimit,
+		},
+	}
+}
+
+// BindFlags registers the command-line flags to customize the Options on the given FlagSet.
+// The current values of the Options are used as defaults.
+func (o *Options) BindFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.Liqo.Namespace, "liqo-namespace", o.Liqo.Namespace,
+		"The namespace Liqo is installed in.")
+	fs.StringVar(&o.Liqo.PodCIDRNetworkName, "liqo-pod-cidr-network-name", o.Liqo.PodCIDRNetworkName,
+		"The name of the Liqo Network resource of the local pod CIDR, in the Liqo namespace.")
+	fs.StringVar(&o.Liqo.ServiceCIDRNetworkName, "liqo-service-cidr-network-name", o.Liqo.ServiceCIDRNetworkName,
+		"The name of the Liqo Network resource of the local service CIDR, in the Liqo namespace.")
+	fs.StringVar(&o.Liqo.RemotePodCIDRNetworkSuffix, "liqo-remote-pod-cidr-network-suffix", o.Liqo.RemotePodCIDRNetworkSuffix,
+		"The suffix appended to the cluster ID to name the Liqo Network resource of the pod CIDR of a remote cluster.")
+	fs.StringVar(&o.Liqo.RemoteExternalCIDRNetworkSuffix, "liqo-remote-external-cidr-network-suffix", o.Liqo.RemoteExternalCIDRNetworkSuffix,
+		"The suffix appended to the cluster ID to name the Liqo Network resource of the external CIDR of a remote cluster.")
+	fs.StringVar(&o.Gateway.TunnelInterface, "gateway-tunnel-interface", o.Gateway.TunnelInterface,
+		"The name of the gateway interface the traffic from the peered cluster is received from.")
+	fs.Var(newStringSliceValue(&o.Gateway.UplinkInterfaces), "gateway-uplink-interfaces",
+		"Comma-separated list of the gateway interfaces connecting it to the local cluster.")
+	fs.BoolVar(&o.Gateway.BypassNonTunnelTraffic, "gateway-bypass-non-tunnel-traffic", o.Gateway.BypassNonTunnelTraffic,
+		"If set, the traffic not received from the tunnel interface bypasses the connectivity rules.")
+	fs.BoolVar(&o.Gateway.BypassUplinkTraffic, "gateway-bypass-uplink-traffic", o.Gateway.BypassUplinkTraffic,
+		"If set, the traffic leaving through the uplink interfaces bypasses the connectivity rules.")
+	fs.BoolVar(&o.Gateway.Stateless, "gateway-stateless", o.Gateway.Stateless,
+		"If set, the traffic of the established connections is filtered by the connectivity rules, so that each direction must be allowed explicitly.")
+	fs.BoolVar(&o.Gateway.AllowRelated, "gateway-allow-related", o.Gateway.AllowRelated,
+		"If set, the traffic related to the established connections (e.g., ICMP errors) is accepted as well.")
+	fs.BoolVar(&o.Gateway.DropInvalid, "gateway-drop-invalid", o.Gateway.DropInvalid,
+		"If set, the traffic not belonging to any valid connection is dropped before evaluating the connectivity rules.")
+	fs.BoolVar(&o.Gateway.ReevaluateEstablished, "gateway-reevaluate-established", o.Gateway.ReevaluateEstablished,
+		"If set, the deny rules apply to the traffic of the established connections too, cutting the ones established before them.")
+	fs.Var(newStringSliceValue(&o.Gateway.ChainHooks), "gateway-chain-hooks",
+		"Comma-separated list of the netfilter hooks the gateway chains are attached to (prerouting, input, forward, output, postrouting).")
+	fs.IntVar(&o.Gateway.ChainPriority, "gateway-chain-priority", o.Gateway.ChainPriority,
+		"The priority of the gateway chains at their hooks.")
+	fs.Var(newStringSliceValue(&o.Internet.ExcludedCIDRs), "internet-excluded-cidrs",
+		"Comma-separated list of additional IPv4 and IPv6 CIDRs excluded from the internet resource group.")
+	fs.StringVar(&o.DNS.ServiceNamespace, "dns-service-namespace", o.DNS.ServiceNamespace,
+		"The namespace of the Service of the cluster DNS, matched by the nameserver resource group.")
+	fs.StringVar(&o.DNS.ServiceName, "dns-service-name", o.DNS.ServiceName,
+		"The name of the Service of the cluster DNS, matched by the nameserver resource group.")
+	fs.StringVar(&o.FQDN.DNSServer, "fqdn-dns-server", o.FQDN.DNSServer,
+		"The address (host:port) of the DNS server resolving the FQDN parties. Defaults to the first nameserver of /etc/resolv.conf.")
+	fs.DurationVar(&o.FQDN.MinTTL, "fqdn-min-ttl", o.FQDN.MinTTL,
+		"The minimum interval between two resolutions of the same FQDN, regardless of the TTL of its records.")
+	fs.DurationVar(&o.FQDN.MaxTTL, "fqdn-max-ttl", o.FQDN.MaxTTL,
+		"The maximum interval between two resolutions of the same FQDN, regardless of the TTL of its records.")
+	fs.DurationVar(&o.FQDN.Timeout, "fqdn-timeout", o.FQDN.Timeout,
+		"The timeout of the resolution of an FQDN.")
+	fs.BoolVar(&o.Peering.AutoCreate, "peering-auto-create", o.Peering.AutoCreate,
+		"If set, a PeeringConnectivity is created in the tenant namespace when a peering is established.")
+	fs.StringVar(&o.Peering.DefaultSpecFile, "peering-default-spec-file", o.Peering.DefaultSpecFile,
+		"The path of the YAML file with the spec of the automatically created PeeringConnectivity resources.")
+	fs.BoolVar(&o.Peering.Cleanup, "peering-cleanup", o.Peering.Cleanup,
+		"If set, the PeeringConnectivity resources of a peering and the generated resources are deleted when the peering is torn down.")
+	fs.StringVar(&o.Counters.ReporterEndpoint, "counters-reporter-endpoint", o.Counters.ReporterEndpoint,
+		"The URL of the reporter serving the rule counters of the gateway of a peering, with the {clusterID}, {namespace} and {table} placeholders. If empty, the counters are not read.")
+	fs.DurationVar(&o.Counters.Interval, "counters-interval", o.Counters.Interval,
+		"The minimum interval between two readings of the rule counters of the same peering.")
+	fs.DurationVar(&o.Counters.Timeout, "counters-timeout", o.Counters.Timeout,
+		"The timeout of a reading of the rule counters.")
+	fs.IntVar(&o.History.RevisionLimit, "revision-history-limit", o.History.RevisionLimit,
+		"The number of revisions of the spec kept for each PeeringConnectivity, including the current one.")
+}
+
+// Validate checks that the Options are consistent.
+func (o *Options) Validate() error {
+	if err := o.Liqo.Validate(); err != nil {
+		return err
+	}
+	if err := o.Gateway.Validate(); err != nil {
+		return err
+	}
+	if err := o.Internet.Validate(); err != nil {
+		return err
+	}
+	if err := o.DNS.Validate(); err != nil {
+		return err
+	}
+	if err := o.FQDN.Validate(); err != nil {
+		return err
+	}
+	if err := o.Peering.Validate(); err != nil {
+		return err
+	}
+	if err := o.Counters.Validate(); err != nil {
+		return err
+	}
+	return o.History.Validate()
+}
+
+// Validate checks that the LiqoOptions are consistent.
+func (o *LiqoOptions) Validate() error {
+	if errs := validation.IsDNS1123Label(o.Namespace); len(errs) > 0 {
+		return fmt.Errorf("invalid Liqo namespace %q: %s", o.Namespace, strings.Join(errs, ", "))
+	}
+
+	for _, name := range []string{o.PodCIDRNetworkName, o.ServiceCIDRNetworkName} {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("invalid Liqo Network name %q: %s", name, strings.Join(errs, ", "))
+		}
+	}
+
+	if o.RemotePodCIDRNetworkSuffix == o.RemoteExternalCIDRNetworkSuffix {
+		return fmt.Errorf("the suffixes of the remote pod and external CIDR Networks must be different")
+	}
+	for _, suffix := range []string{o.RemotePodCIDRNetworkSuffix, o.RemoteExternalCIDRNetworkSuffix} {
+		// The suffix is appended to a cluster ID, which is a valid DNS label itself.
+		if errs := validation.IsDNS1123Subdomain("cluster" + suffix); len(errs) > 0 {
+			return fmt.Errorf("invalid Liqo remote Network suffix %q: %s", suffix, strings.Join(errs, ", "))
+		}
+	}
+
+	return nil
+}
+
+// RemotePodCIDRNetworkName returns the name of the Network resource of the pod CIDR of the given remote cluster.
+func (o *LiqoOptions) RemotePodCIDRNetworkName(clusterID string) string {
+	return clusterID + o.RemotePodCIDRNetworkSuffix
+}
+
+// RemoteExternalCIDRNetworkName returns the name of the Network resource of the external CIDR of the given remote cluster.
+func (o *LiqoOptions) RemoteExternalCIDRNetworkName(clusterID string) string {
+	return clusterID + o.RemoteExternalCIDRNetworkSuffix
+}
+
+// Validate checks that the GatewayOptions are consistent.
+func (o *GatewayOptions) Validate() error {
+	if err := ValidateInterfaceName(o.TunnelInterface); err != nil {
+		return fmt.Errorf("invalid gateway tunnel interface: %w", err)
+	}
+
+	if o.BypassUplinkTraffic && len(o.UplinkInterfaces) == 0 {
+		return fmt.Errorf("at least one gateway uplink interface is required when the uplink traffic bypass is enabled")
+	}
+
+	for _, iface := range o.UplinkInterfaces {
+		if err := ValidateInterfaceName(iface); err != nil {
+			return fmt.Errorf("invalid gateway uplink interface: %w", err)
+		}
+		if iface == o.TunnelInterface {
+			return fmt.Errorf("the gateway uplink interface %q cannot be the tunnel interface", iface)
+		}
+	}
+
+	if err := validateChainHooks("gateway", o.ChainHooks); err != nil {
+		return err
+	}
+
+	// The restriction chains are attached with the following priority, which must be representable as well.
+	if o.ChainPriority < math.MinInt32 || o.ChainPriority >= math.MaxInt32 {
+		return fmt.Errorf("invalid gateway chain priority %d: must be between %d and %d", o.ChainPriority, math.MinInt32, math.MaxInt32-1)
+	}
+
+	return nil
+}
+
+// validateChainHooks checks that the hooks the chains of the given enforcement point are attached to
+// are supported, not repeated and not empty.
+func validateChainHooks(component string, hooks []string) error {
+	if len(hooks) == 0 {
+		return fmt.Errorf("at least one %s chain hook is required", component)
+	}
+	for i, hook := range hooks {
+		if !slices.Contains(SupportedChainHooks, hook) {
+			return fmt.Errorf("invalid %s chain hook %q: must be one of %s", component, hook, strings.Join(SupportedChainHooks, ", "))
+		}
+		if slices.Contains(hooks[:i], hook) {
+			return fmt.Errorf("duplicate %s chain hook %q", component, hook)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the InternetOptions are consistent.
+func (o *InternetOptions) Validate() error {
+	for _, cidr := range o.ExcludedCIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid internet excluded CIDR: %w", err)
+		}
+		if prefix.Masked() != prefix {
+			return fmt.Errorf("invalid internet excluded CIDR %q: host bits must be zero", cidr)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the DNSOptions are consistent.
+func (o *DNSOptions) Validate() error {
+	if o.ServiceNamespace == "" || o.ServiceName == "" {
+		return fmt.Errorf("the namespace and the name of the DNS service are required")
+	}
+	return nil
+}
+
+// Validate checks that the FQDNOptions are consistent.
+func (o *FQDNOptions) Validate() error {
+	if o.DNSServer != "" {
+		if _, _, err := net.SplitHostPort(o.DNSServer); err != nil {
+			return fmt.Errorf("invalid FQDN DNS server: %w", err)
+		}
+	}
+	if o.MinTTL <= 0 || o.Timeout <= 0 {
+		return fmt.Errorf("the FQDN minimum TTL and timeout must be positive")
+	}
+	if o.MaxTTL < o.MinTTL {
+		return fmt.Errorf("the FQDN maximum TTL cannot be lower than the minimum TTL")
+	}
+	return nil
+}
+
+// Validate checks that the PeeringOptions are consistent.
+func (o *PeeringOptions) Validate() error {
+	if o.DefaultSpecFile != "" && !o.AutoCreate {
+		return fmt.Errorf("the default PeeringConnectivity spec file requires the automatic creation to be enabled")
+	}
+	return nil
+}
+
+// Validate checks that the CountersOptions are consistent.
+func (o *CountersOptions) Validate() error {
+	if o.ReporterEndpoint == "" {
+		return nil
+	}
+
+	// The placeholders are replaced with valid values, as they may be part of the host name.
+	endpoint, err := url.Parse(strings.NewReplacer(
+		"{clusterID}", "cluster", "{namespace}", "namespace", "{table}", "table",
+	).Replace(o.ReporterEndpoint))
+	if err != nil {
+		return fmt.Errorf("invalid counters reporter endpoint: %w", err)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return fmt.Errorf("invalid counters reporter endpoint %q: the scheme must be http or https", o.ReporterEndpoint)
+	}
+	if o.Interval <= 0 || o.Timeout <= 0 {
+		return fmt.Errorf("the counters interval and timeout must be positive")
+	}
+	return nil
+}
+
+// Validate checks that the HistoryOptions are consistent.
+func (o *HistoryOptions) Validate() error {
+	if o.RevisionLimit < 1 {
+		return fmt.Errorf("the revision history limit must be at least 1, to keep the current revision")
+	}
+	return nil
+}
+
+// ValidateInterfaceName checks that the given string is a valid Linux network interface name.
+func ValidateInterfaceName(name string) error {
+	if name == "" {
+		return fmt.Errorf("the interface name cannot be empty")
+	}
+	if len(name) > maxInterfaceNameLength {
+		return fmt.Errorf("the interface name %q exceeds %d characters", name, maxInterfaceNameLength)
+	}
+	if name == "." || name == ".." {
+		return fmt.Errorf("the interface name %q is reserved", name)
+	}
+	if strings.ContainsAny(name, "/: \t\n") {
+		return fmt.Errorf("the interface name %q contains invalid characters", name)
+	}
+	return nil
+}
+
+// stringSliceValue is a flag.Value for comma-separated lists of strings.
+type stringSliceValue struct {
+	values *[]string
+}
+
+func newStringSliceValue(values *[]string) *stringSliceValue {
+	return &stringSliceValue{values: values}
+}
+
+// String returns the comma-separated representation of the list.
+func (s *stringSliceValue) String() string {
+	if s.values == nil {
+		return ""
+	}
+	return strings.Join(*s.values, ",")
+}
+
+// Set replaces the list with the comma-separated values of the given string.
+func (s *stringSliceValue) Set(value string) error {
+	values := make([]string, 0)
+	for v := range strings.SplitSeq(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	*s.values = values
+	return nil
+}
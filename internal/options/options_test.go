@@ -0,0 +1,291 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"flag"
+	"math"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Options", func() {
+	Describe("NewDefaultOptions", func() {
+		It("should return valid options", func() {
+			Expect(NewDefaultOptions().Validate()).To(Succeed())
+		})
+
+		It("should bypass the non-tunnel and uplink traffic", func() {
+			opts := NewDefaultOptions()
+			Expect(opts.Gateway.BypassNonTunnelTraffic).To(BeTrue())
+			Expect(opts.Gateway.BypassUplinkTraffic).To(BeTrue())
+			Expect(opts.Gateway.UplinkInterfaces).To(Equal([]string{DefaultUplinkInterface}))
+		})
+
+		It("should accept the established and related connections first", func() {
+			opts := NewDefaultOptions()
+			Expect(opts.Gateway.Stateless).To(BeFalse())
+			Expect(opts.Gateway.AllowRelated).To(BeTrue())
+			Expect(opts.Gateway.DropInvalid).To(BeFalse())
+			Expect(opts.Gateway.ReevaluateEstablished).To(BeFalse())
+		})
+	})
+
+	Describe("BindFlags", func() {
+		var (
+			opts *Options
+			fs   *flag.FlagSet
+		)
+
+		BeforeEach(func() {
+			opts = NewDefaultOptions()
+			fs = flag.NewFlagSet("test", flag.ContinueOnError)
+			opts.BindFlags(fs)
+		})
+
+		It("should keep the defaults when no flag is set", func() {
+			Expect(fs.Parse(nil)).To(Succeed())
+			Expect(opts).To(Equal(NewDefaultOptions()))
+		})
+
+		It("should parse the gateway flags", func() {
+			Expect(fs.Parse([]string{
+				"--gateway-tunnel-interface=wg0",
+				"--gateway-uplink-interfaces=ens3, ens4",
+				"--gateway-bypass-non-tunnel-traffic=false",
+				"--gateway-bypass-uplink-traffic=false",
+				"--gateway-stateless",
+				"--gateway-allow-related=false",
+				"--gateway-drop-invalid",
+				"--gateway-reevaluate-established",
+				"--gateway-chain-hooks=forward,input",
+				"--gateway-chain-priority=-10",
+				"--internet-excluded-cidrs=198.51.100.0/24,2001:db8::/32",
+				"--dns-service-namespace=dns-system",
+				"--dns-service-name=coredns",
+				"--fqdn-dns-server=10.0.0.53:53",
+				"--fqdn-min-ttl=1m",
+				"--fqdn-max-ttl=1h",
+				"--fqdn-timeout=2s",
+			})).To(Succeed())
+			Expect(opts.Gateway).To(Equal(GatewayOptions{
+				TunnelInterface:        "wg0",
+				UplinkInterfaces:       []string{"ens3", "ens4"},
+				BypassNonTunnelTraffic: false,
+				BypassUplinkTraffic:    false,
+				Stateless:              true,
+				AllowRelated:           false,
+				DropInvalid:            true,
+				ReevaluateEstablished:  true,
+				ChainHooks:             []string{"forward", "input"},
+				ChainPriority:          -10,
+			}))
+			Expect(opts.Internet.ExcludedCIDRs).To(Equal([]string{"198.51.100.0/24", "2001:db8::/32"}))
+			Expect(opts.DNS).To(Equal(DNSOptions{ServiceNamespace: "dns-system", ServiceName: "coredns"}))
+			Expect(opts.FQDN).To(Equal(FQDNOptions{
+				DNSServer: "10.0.0.53:53",
+				MinTTL:    time.Minute,
+				MaxTTL:    time.Hour,
+				Timeout:   2 * time.Second,
+			}))
+		})
+
+		It("should parse the peering flags", func() {
+			Expect(fs.Parse([]string{
+				"--peering-auto-create",
+				"--peering-default-spec-file=/etc/engine/default.yaml",
+				"--peering-cleanup=false",
+			})).To(Succeed())
+			Expect(opts.Peering).To(Equal(PeeringOptions{
+				AutoCreate:      true,
+				DefaultSpecFile: "/etc/engine/default.yaml",
+				Cleanup:         false,
+			}))
+		})
+
+		It("should parse the counters flags", func() {
+			Expect(fs.Parse([]string{
+				"--counters-reporter-endpoint=http://{clusterID}-reporter.{namespace}:9090/tables/{table}",
+				"--counters-interval=30s",
+				"--counters-timeout=1s",
+			})).To(Succeed())
+			Expect(opts.Counters).To(Equal(CountersOptions{
+				ReporterEndpoint: "http://{clusterID}-reporter.{namespace}:9090/tables/{table}",
+				Interval:         30 * time.Second,
+				Timeout:          time.Second,
+			}))
+		})
+
+		It("should parse the history flags", func() {
+			Expect(fs.Parse([]string{"--revision-history-limit=3"})).To(Succeed())
+			Expect(opts.History).To(Equal(HistoryOptions{RevisionLimit: 3}))
+		})
+
+		It("should parse the Liqo flags", func() {
+			Expect(fs.Parse([]string{
+				"--liqo-namespace=liqo-system",
+				"--liqo-pod-cidr-network-name=local-pods",
+				"--liqo-service-cidr-network-name=local-services",
+				"--liqo-remote-pod-cidr-network-suffix=-pods",
+				"--liqo-remote-external-cidr-network-suffix=-ext",
+			})).To(Succeed())
+			Expect(opts.Liqo).To(Equal(LiqoOptions{
+				Namespace:                       "liqo-system",
+				PodCIDRNetworkName:              "local-pods",
+				ServiceCIDRNetworkName:          "local-services",
+				RemotePodCIDRNetworkSuffix:      "-pods",
+				RemoteExternalCIDRNetworkSuffix: "-ext",
+			}))
+			Expect(opts.Liqo.RemotePodCIDRNetworkName("cluster-a")).To(Equal("cluster-a-pods"))
+			Expect(opts.Liqo.RemoteExternalCIDRNetworkName("cluster-a")).To(Equal("cluster-a-ext"))
+		})
+	})
+
+	Describe("Validate", func() {
+		var opts *Options
+
+		BeforeEach(func() {
+			opts = NewDefaultOptions()
+		})
+
+		It("should reject an invalid Liqo namespace", func() {
+			opts.Liqo.Namespace = "Liqo_System"
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("invalid Liqo namespace")))
+		})
+
+		It("should reject an empty Liqo Network name", func() {
+			opts.Liqo.ServiceCIDRNetworkName = ""
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("invalid Liqo Network name")))
+		})
+
+		It("should reject equal remote Network suffixes", func() {
+			opts.Liqo.RemoteExternalCIDRNetworkSuffix = opts.Liqo.RemotePodCIDRNetworkSuffix
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("must be different")))
+		})
+
+		It("should reject a remote Network suffix with invalid characters", func() {
+			opts.Liqo.RemotePodCIDRNetworkSuffix = "_pod"
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("invalid Liqo remote Network suffix")))
+		})
+
+		It("should reject an empty tunnel interface", func() {
+			opts.Gateway.TunnelInterface = ""
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("tunnel interface")))
+		})
+
+		It("should reject an interface name longer than 15 characters", func() {
+			opts.Gateway.UplinkInterfaces = []string{"this-is-too-long"}
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("exceeds")))
+		})
+
+		It("should reject an interface name with invalid characters", func() {
+			opts.Gateway.UplinkInterfaces = []string{"eth/0"}
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("invalid characters")))
+		})
+
+		It("should reject the tunnel interface as uplink", func() {
+			opts.Gateway.UplinkInterfaces = []string{opts.Gateway.TunnelInterface}
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("cannot be the tunnel interface")))
+		})
+
+		It("should require a gateway chain hook", func() {
+			opts.Gateway.ChainHooks = nil
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("at least one gateway chain hook")))
+		})
+
+		It("should reject an unsupported gateway chain hook", func() {
+			opts.Gateway.ChainHooks = []string{"ingress"}
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("invalid gateway chain hook")))
+		})
+
+		It("should reject a duplicate gateway chain hook", func() {
+			opts.Gateway.ChainHooks = []string{"forward", "input", "forward"}
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("duplicate gateway chain hook")))
+		})
+
+		It("should reject a gateway chain priority leaving no room for the restriction chains", func() {
+			opts.Gateway.ChainPriority = math.MaxInt32
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("invalid gateway chain priority")))
+		})
+
+		It("should require an uplink interface when the uplink bypass is enabled", func() {
+			opts.Gateway.UplinkInterfaces = nil
+			Expect(opts.Validate()).To(HaveOccurred())
+		})
+
+		It("should accept no uplink interface when the uplink bypass is disabled", func() {
+			opts.Gateway.UplinkInterfaces = nil
+			opts.Gateway.BypassUplinkTraffic = false
+			Expect(opts.Validate()).To(Succeed())
+		})
+
+		It("should accept IPv4 and IPv6 internet excluded CIDRs", func() {
+			opts.Internet.ExcludedCIDRs = []string{"198.51.100.0/24", "2001:db8::/32"}
+			Expect(opts.Validate()).To(Succeed())
+		})
+
+		It("should reject a malformed internet excluded CIDR", func() {
+			opts.Internet.ExcludedCIDRs = []string{"198.51.100.0"}
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("invalid internet excluded CIDR")))
+		})
+
+		It("should reject an internet excluded CIDR with host bits set", func() {
+			opts.Internet.ExcludedCIDRs = []string{"198.51.100.1/24"}
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("host bits")))
+		})
+
+		It("should require the DNS service name", func() {
+			opts.DNS.ServiceName = ""
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("DNS service")))
+		})
+
+		It("should reject an FQDN DNS server without port", func() {
+			opts.FQDN.DNSServer = "10.0.0.53"
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("invalid FQDN DNS server")))
+		})
+
+		It("should require the automatic creation for the default PeeringConnectivity spec file", func() {
+			opts.Peering.DefaultSpecFile = "/etc/engine/default.yaml"
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("automatic creation")))
+			opts.Peering.AutoCreate = true
+			Expect(opts.Validate()).To(Succeed())
+		})
+
+		It("should reject a counters reporter endpoint that is not an HTTP URL", func() {
+			opts.Counters.ReporterEndpoint = "tcp://reporter:9090"
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("invalid counters reporter endpoint")))
+			opts.Counters.ReporterEndpoint = "https://{clusterID}-reporter.{namespace}:9090/{table}"
+			Expect(opts.Validate()).To(Succeed())
+		})
+
+		It("should reject a non-positive counters interval", func() {
+			opts.Counters.ReporterEndpoint = "http://reporter:9090/{table}"
+			opts.Counters.Interval = 0
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("counters interval")))
+		})
+
+		It("should reject a revision history limit not keeping the current revision", func() {
+			opts.History.RevisionLimit = 0
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("revision history limit")))
+		})
+
+		It("should reject an FQDN maximum TTL lower than the minimum one", func() {
+			opts.FQDN.MaxTTL = opts.FQDN.MinTTL - time.Second
+			Expect(opts.Validate()).To(MatchError(ContainSubstring("maximum TTL")))
+		})
+	})
+})
@@ -19,6 +19,7 @@ import (
 
 	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 	networkingv1 "k8s.io/api/networking/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -26,23 +27,23 @@ import (
 // local-cluster: Matches pods in the local cluster's pod CIDR.
 // This doesn't need a set because it uses a simple CIDR match.
 var ResourceGroupLocalCluster = groupFuncts{
-	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, clusterID string, position networkingv1beta1firewall.MatchPosition) ([]networkingv1beta1firewall.Match, error) {
+	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string, position networkingv1beta1firewall.MatchPosition) ([][]networkingv1beta1firewall.Match, error) {
 		// Get the local cluster's pod CIDR and create a match rule for it.
-		cidr, err := utils.GetCurrentClusterPodCIDR(ctx, cl)
+		cidr, err := utils.GetCurrentClusterPodCIDR(ctx, cl, &opts.Liqo)
 		if err != nil {
 			return nil, err
 		}
 
-		return []networkingv1beta1firewall.Match{{
+		return [][]networkingv1beta1firewall.Match{{{
 			IP: &networkingv1beta1firewall.MatchIP{
 				Value:    cidr,
 				Position: position,
 			},
 			Op: networkingv1beta1firewall.MatchOperationEq,
-		}}, nil
+		}}}, nil
 	},
-	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
-		cidr, err := utils.GetCurrentClusterPodCIDR(ctx, cl)
+	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
+		cidr, err := utils.GetCurrentClusterPodCIDR(ctx, cl, &opts.Liqo)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -58,23 +59,23 @@ var ResourceGroupLocalCluster = groupFuncts{
 // remote-cluster: Matches pods in the remote cluster's pod CIDR.
 // This doesn't need a set because it uses a simple CIDR match.
 var ResourceGroupRemoteCluster = groupFuncts{
-	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, clusterID string, position networkingv1beta1firewall.MatchPosition) ([]networkingv1beta1firewall.Match, error) {
+	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string, position networkingv1beta1firewall.MatchPosition) ([][]networkingv1beta1firewall.Match, error) {
 		// Get the remote cluster's pod CIDR and create a match rule for it.
-		cidr, err := utils.GetRemoteClusterPodCIDR(ctx, cl, clusterID)
+		cidr, err := utils.GetRemoteClusterPodCIDR(ctx, cl, &opts.Liqo, clusterID)
 		if err != nil {
 			return nil, err
 		}
 
-		return []networkingv1beta1firewall.Match{{
+		return [][]networkingv1beta1firewall.Match{{{
 			IP: &networkingv1beta1firewall.MatchIP{
 				Value:    cidr,
 				Position: position,
 			},
 			Op: networkingv1beta1firewall.MatchOperationEq,
-		}}, nil
+		}}}, nil
 	},
-	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
-		cidr, err := utils.GetRemoteClusterPodCIDR(ctx, cl, clusterID)
+	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
+		cidr, err := utils.GetRemoteClusterPodCIDR(ctx, cl, &opts.Liqo, clusterID)
 		if err != nil {
 			return nil, nil, err
 		}
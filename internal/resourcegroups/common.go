@@ -16,27 +16,44 @@ package resourcegroups
 
 import (
 	"context"
+	"errors"
 
 	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
 	networkingv1 "k8s.io/api/networking/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 )
 
+// groupSetPrefix is the prefix of the names of the firewall sets created by the resource groups.
+const groupSetPrefix = "rg"
+
+// forgeGroupSetName returns the name of the firewall set created by the given resource group.
+func forgeGroupSetName(group connectivityv1.ResourceGroup) string {
+	return utils.ForgeSetName(groupSetPrefix, string(group))
+}
+
 // groupFuncts defines the functions needed to implement a resource group.
 // Each resource group needs to provide:
 //   - MakeFirewallConfigurationSets: creates firewall sets (collections of IP addresses) for the group.
 //     May be nil if the resource group uses CIDR-based matching instead of sets.
-//   - MakeFirewallConfigurationRule: creates firewall match rules for the group.
+//   - MakeFirewallConfigurationRule: creates firewall match rules for the group, as a list of alternatives.
+//     Traffic belongs to the group if it satisfies all the matches of at least one alternative.
 //     Required for all resource groups.
 //   - MakeNetworkPolicyRule: creates NetworkPolicyPeer objects for the group (used in NetworkPolicies).
+//     No peers match any peer on the returned ports: a group currently matching no peer returns ErrNoMatchingPeers.
 type groupFuncts struct {
-	MakeFirewallConfigurationSets func(ctx context.Context, cl client.Client, clusterID string) ([]networkingv1beta1firewall.Set, error)
-	MakeFirewallConfigurationRule func(ctx context.Context, cl client.Client, clusterID string, position networkingv1beta1firewall.MatchPosition) ([]networkingv1beta1firewall.Match, error)
-	MakeNetworkPolicyRule         func(ctx context.Context, cl client.Client, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error)
+	MakeFirewallConfigurationSets func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1beta1firewall.Set, error)
+	MakeFirewallConfigurationRule func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string, position networkingv1beta1firewall.MatchPosition) ([][]networkingv1beta1firewall.Match, error)
+	MakeNetworkPolicyRule         func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error)
 }
 
+// ErrNoMatchingPeers is returned by MakeNetworkPolicyRule when the group currently does not match any peer,
+// since an empty list of NetworkPolicy peers would match all of them instead.
+var ErrNoMatchingPeers = errors.New("the resource group does not match any peer")
+
 // ResourceGroupFuncts maps each ResourceGroup to its implementation functions.
 // This allows the controller to dynamically create firewall rules based on the
 // resource groups specified in the PeeringConnectivity spec.
@@ -53,4 +70,9 @@ var ResourceGroupFuncts = map[connectivityv1.ResourceGroup]groupFuncts{
 
 	connectivityv1.ResourceGroupInternet:   ResourceGroupInternet,
 	connectivityv1.ResourceGroupNameserver: ResourceGroupNameserver,
+	connectivityv1.ResourceGroupAnyDNS:     ResourceGroupAnyDNS,
+
+	connectivityv1.ResourceGroupLocalServices:  ResourceGroupLocalServices,
+	connectivityv1.ResourceGroupRemoteServices: ResourceGroupRemoteServices,
+	connectivityv1.ResourceGroupLocalNodes:     ResourceGroupLocalNodes,
 }
@@ -19,7 +19,9 @@ import (
 
 	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
 	"github.com/liqotech/liqo/pkg/consts"
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -29,7 +31,7 @@ import (
 // These are the actual pods running locally that could be offloaded.
 // Uses a set because pod IPs are dynamically allocated.
 var ResourceGroupSliceLocal = groupFuncts{
-	MakeFirewallConfigurationSets: func(ctx context.Context, cl client.Client, clusterID string) ([]networkingv1beta1firewall.Set, error) {
+	MakeFirewallConfigurationSets: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1beta1firewall.Set, error) {
 		// Get all pods in namespaces that are configured for offloading.
 		pods, err := utils.GetPodsInOffloadedNamespaces(ctx, cl)
 		if err != nil {
@@ -37,17 +39,17 @@ var ResourceGroupSliceLocal = groupFuncts{
 		}
 
 		// Create a firewall set containing the IPs of these pods.
-		podIpsSet := utils.ForgePodIpsSet("vclocal", pods)
+		podIpsSet := utils.ForgePodIpsSet(forgeGroupSetName(connectivityv1.ResourceGroupSliceLocal), pods)
 		return []networkingv1beta1firewall.Set{podIpsSet}, nil
 	},
-	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, clusterID string, position networkingv1beta1firewall.MatchPosition) ([]networkingv1beta1firewall.Match, error) {
-		return []networkingv1beta1firewall.Match{{
+	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string, position networkingv1beta1firewall.MatchPosition) ([][]networkingv1beta1firewall.Match, error) {
+		return [][]networkingv1beta1firewall.Match{{{
 			IP: &networkingv1beta1firewall.MatchIP{
-				Value:    "@vclocal",
+				Value:    utils.ForgeSetReference(forgeGroupSetName(connectivityv1.ResourceGroupSliceLocal)),
 				Position: position,
 			},
 			Op: networkingv1beta1firewall.MatchOperationEq,
-		}}, nil
+		}}}, nil
 	},
 	// TODO: make networkpolicy
 }
@@ -56,27 +58,34 @@ var ResourceGroupSliceLocal = groupFuncts{
 // pods offloaded to a provider cluster.
 // Uses a set because pod IPs are dynamically allocated.
 var ResourceGroupSliceRemote = groupFuncts{
-	MakeFirewallConfigurationSets: func(ctx context.Context, cl client.Client, clusterID string) ([]networkingv1beta1firewall.Set, error) {
+	MakeFirewallConfigurationSets: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1beta1firewall.Set, error) {
 		// Get all shadow pods that represent offloaded pods on the specified provider cluster.
 		pods, err := utils.GetPodsOffloadedToProvider(ctx, cl, clusterID)
 		if err != nil {
 			return nil, err
 		}
 
+		// The shadow pods may report the IPs of the pods in the provider cluster, which differ from the ones
+		// carried by the packets when Liqo remaps the pod CIDR of the provider, hence they are translated.
+		translator, err := utils.GetRemoteClusterAddressTranslator(ctx, cl, &opts.Liqo, clusterID)
+		if err != nil {
+			return nil, err
+		}
+
 		// Create a firewall set containing the IPs of these shadow pods.
-		podIpsSet := utils.ForgePodIpsSet("vcremote", pods)
+		podIpsSet := utils.ForgeIPsSet(forgeGroupSetName(connectivityv1.ResourceGroupSliceRemote), translator.TranslateAll(utils.GetPodIPs(pods)))
 		return []networkingv1beta1firewall.Set{podIpsSet}, nil
 	},
-	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, clusterID string, position networkingv1beta1firewall.MatchPosition) ([]networkingv1beta1firewall.Match, error) {
-		return []networkingv1beta1firewall.Match{{
+	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string, position networkingv1beta1firewall.MatchPosition) ([][]networkingv1beta1firewall.Match, error) {
+		return [][]networkingv1beta1firewall.Match{{{
 			IP: &networkingv1beta1firewall.MatchIP{
-				Value:    "@vcremote",
+				Value:    utils.ForgeSetReference(forgeGroupSetName(connectivityv1.ResourceGroupSliceRemote)),
 				Position: position,
 			},
 			Op: networkingv1beta1firewall.MatchOperationEq,
-		}}, nil
+		}}}, nil
 	},
-	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
+	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
 		return []networkingv1.NetworkPolicyPeer{{
 			PodSelector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{
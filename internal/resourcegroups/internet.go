@@ -16,70 +16,125 @@ package resourcegroups
 
 import (
 	"context"
+	"fmt"
+	"slices"
 
 	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
-	corev1 "k8s.io/api/core/v1"
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 	networkingv1 "k8s.io/api/networking/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
-	"k8s.io/utils/ptr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// internet: Matches traffic destined to all public IP ranges except those described in RFC1918.
+var (
+	// specialPurposeIPv4CIDRs are the ranges of the IANA IPv4 Special-Purpose Address Registry (RFC 6890),
+	// plus multicast and the reserved space, which are not part of the internet.
+	specialPurposeIPv4CIDRs = []string{
+		"0.0.0.0/8",       // "This network" (RFC 791)
+		"10.0.0.0/8",      // Private-Use (RFC 1918)
+		"100.64.0.0/10",   // Shared Address Space, CGNAT (RFC 6598)
+		"127.0.0.0/8",     // Loopback (RFC 1122)
+		"169.254.0.0/16",  // Link Local, including cloud metadata endpoints (RFC 3927)
+		"172.16.0.0/12",   // Private-Use (RFC 1918)
+		"192.0.0.0/24",    // IETF Protocol Assignments (RFC 6890)
+		"192.0.2.0/24",    // Documentation, TEST-NET-1 (RFC 5737)
+		"192.88.99.0/24",  // Deprecated 6to4 Relay Anycast (RFC 7526)
+		"192.168.0.0/16",  // Private-Use (RFC 1918)
+		"198.18.0.0/15",   // Benchmarking (RFC 2544)
+		"198.51.100.0/24", // Documentation, TEST-NET-2 (RFC 5737)
+		"203.0.113.0/24",  // Documentation, TEST-NET-3 (RFC 5737)
+		"224.0.0.0/4",     // Multicast (RFC 5771)
+		"240.0.0.0/4",     // Reserved, including the limited broadcast address (RFC 1112)
+	}
+
+	// specialPurposeIPv6CIDRs are the ranges of the IANA IPv6 Special-Purpose Address Registry (RFC 6890),
+	// plus multicast, which are not part of the internet.
+	specialPurposeIPv6CIDRs = []string{
+		"::/128",         // Unspecified Address (RFC 4291)
+		"::1/128",        // Loopback Address (RFC 4291)
+		"::ffff:0:0/96",  // IPv4-mapped Address (RFC 4291)
+		"64:ff9b:1::/48", // IPv4-IPv6 Translation, local-use (RFC 8215)
+		"100::/64",       // Discard-Only Address Block (RFC 6666)
+		"2001::/23",      // IETF Protocol Assignments (RFC 2928)
+		"2001:db8::/32",  // Documentation (RFC 3849)
+		"2002::/16",      // 6to4 (RFC 3056)
+		"3fff::/20",      // Documentation (RFC 9637)
+		"5f00::/16",      // Segment Routing SIDs (RFC 9602)
+		"fc00::/7",       // Unique-Local (RFC 4193)
+		"fe80::/10",      // Link-Local Unicast (RFC 4291)
+		"ff00::/8",       // Multicast (RFC 4291)
+	}
+)
+
+// GetNonInternetCIDRs returns the ranges excluded from the internet resource group, split by address family:
+// the special-purpose ranges, the local service CIDR and the additional ranges configured by the operator.
+// Nested ranges are collapsed, so that the result can be used both as the elements of an nftables interval
+// set and as the exceptions of a NetworkPolicy IPBlock.
+func GetNonInternetCIDRs(ctx context.Context, cl client.Client, opts *options.Options) (ipv4, ipv6 []string, err error) {
+	cidrs := slices.Concat(specialPurposeIPv4CIDRs, specialPurposeIPv6CIDRs, opts.Internet.ExcludedCIDRs)
+
+	// The service CIDR may be outside the private ranges, hence it is excluded explicitly.
+	serviceCIDR, err := utils.GetCurrentClusterServiceCIDR(ctx, cl, &opts.Liqo)
+	switch {
+	case apierrors.IsNotFound(err):
+		// The service CIDR is not known, rely on the other ranges only.
+	case err != nil:
+		return nil, nil, fmt.Errorf("unable to get the local service CIDR: %w", err)
+	case serviceCIDR != "":
+		cidrs = append(cidrs, serviceCIDR)
+	}
+
+	return utils.CollapseCIDRs(cidrs)
+}
+
+// internet: Matches traffic destined to all the public IP ranges, i.e., everything except
+// the special-purpose ranges, the local service CIDR and the ranges configured by the operator.
+// The firewall configuration table is IPv4-only, hence only the IPv4 ranges are used in the set.
 var ResourceGroupInternet = groupFuncts{
-	MakeFirewallConfigurationSets: func(ctx context.Context, cl client.Client, clusterID string) ([]networkingv1beta1firewall.Set, error) {
+	MakeFirewallConfigurationSets: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1beta1firewall.Set, error) {
+		ipv4, _, err := GetNonInternetCIDRs(ctx, cl, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		elements := make([]networkingv1beta1firewall.SetElement, 0, len(ipv4))
+		for _, cidr := range ipv4 {
+			elements = append(elements, networkingv1beta1firewall.SetElement{Key: cidr})
+		}
+
 		return []networkingv1beta1firewall.Set{{
-			Name:    "privatesubnets",
-			KeyType: networkingv1beta1firewall.SetDataTypeIPCIDR,
-			Elements: []networkingv1beta1firewall.SetElement{
-				{Key: "10.0.0.0/8"},
-				{Key: "172.16.0.0/12"},
-				{Key: "192.168.0.0/16"},
-			},
+			Name:     forgeGroupSetName(connectivityv1.ResourceGroupInternet),
+			KeyType:  networkingv1beta1firewall.SetDataTypeIPCIDR,
+			Elements: elements,
 		}}, nil
 	},
-	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, clusterID string, position networkingv1beta1firewall.MatchPosition) ([]networkingv1beta1firewall.Match, error) {
-		return []networkingv1beta1firewall.Match{{
+	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string, position networkingv1beta1firewall.MatchPosition) ([][]networkingv1beta1firewall.Match, error) {
+		return [][]networkingv1beta1firewall.Match{{{
 			IP: &networkingv1beta1firewall.MatchIP{
-				Value:    "@privatesubnets",
+				Value:    utils.ForgeSetReference(forgeGroupSetName(connectivityv1.ResourceGroupInternet)),
 				Position: position,
 			},
 			Op: networkingv1beta1firewall.MatchOperationNeq,
-		}}, nil
+		}}}, nil
 	},
-	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
+	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
+		ipv4, ipv6, err := GetNonInternetCIDRs(ctx, cl, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		return []networkingv1.NetworkPolicyPeer{{
 			IPBlock: &networkingv1.IPBlock{
-				CIDR: "0.0.0.0/0",
-				Except: []string{
-					"10.0.0.0/8",
-					"172.16.0.0/12",
-					"192.168.0.0/16",
-				},
+				CIDR:   "0.0.0.0/0",
+				Except: ipv4,
 			},
-		}}, nil, nil
-	},
-}
-
-// nameserver: Matches traffic destined to any nameserver (port 53).
-var ResourceGroupNameserver = groupFuncts{
-	MakeFirewallConfigurationSets: nil, // No sets needed for this group
-	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, clusterID string, position networkingv1beta1firewall.MatchPosition) ([]networkingv1beta1firewall.Match, error) {
-		return []networkingv1beta1firewall.Match{{
-			Port: &networkingv1beta1firewall.MatchPort{
-				Value:    "53",
-				Position: position,
-			},
-			Op: networkingv1beta1firewall.MatchOperationEq,
-		}}, nil
-	},
-	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
-		return nil, []networkingv1.NetworkPolicyPort{{
-			Port:     ptr.To(intstr.FromInt(53)),
-			Protocol: ptr.To(corev1.ProtocolTCP),
 		}, {
-			Port:     ptr.To(intstr.FromInt(53)),
-			Protocol: ptr.To(corev1.ProtocolUDP),
-		}}, nil
+			IPBlock: &networkingv1.IPBlock{
+				CIDR:   "::/0",
+				Except: ipv6,
+			},
+		}}, nil, nil
 	},
 }
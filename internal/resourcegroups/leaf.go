@@ -19,29 +19,30 @@ import (
 
 	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 	networkingv1 "k8s.io/api/networking/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // leaf: matches the external CIDR
 var ResourceGroupLeaf = groupFuncts{
-	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, clusterID string, position networkingv1beta1firewall.MatchPosition) ([]networkingv1beta1firewall.Match, error) {
+	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string, position networkingv1beta1firewall.MatchPosition) ([][]networkingv1beta1firewall.Match, error) {
 		// Get the remote cluster's external CIDR and create a match rule for it.
-		cidr, err := utils.GetRemoteClusterExternalCIDR(ctx, cl, clusterID)
+		cidr, err := utils.GetRemoteClusterExternalCIDR(ctx, cl, &opts.Liqo, clusterID)
 		if err != nil {
 			return nil, err
 		}
 
-		return []networkingv1beta1firewall.Match{{
+		return [][]networkingv1beta1firewall.Match{{{
 			IP: &networkingv1beta1firewall.MatchIP{
 				Value:    cidr,
 				Position: position,
 			},
 			Op: networkingv1beta1firewall.MatchOperationEq,
-		}}, nil
+		}}}, nil
 	},
-	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
-		cidr, err := utils.GetRemoteClusterExternalCIDR(ctx, cl, clusterID)
+	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
+		cidr, err := utils.GetRemoteClusterExternalCIDR(ctx, cl, &opts.Liqo, clusterID)
 		if err != nil {
 			return nil, nil, err
 		}
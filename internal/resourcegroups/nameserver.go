@@ -0,0 +1,130 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroups
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// dnsPort is the well-known port of the DNS protocol.
+const dnsPort = 53
+
+// defaultDNSPorts are the ports used when the DNS service does not declare any port.
+var defaultDNSPorts = []utils.ServicePort{
+	{Protocol: corev1.ProtocolTCP, Port: dnsPort},
+	{Protocol: corev1.ProtocolUDP, Port: dnsPort},
+}
+
+// getDNSEndpoints retrieves the addresses and ports of the cluster DNS service configured in the options.
+// If the DNS service does not exist, e.g. since it has a different name, it has no addresses, hence the
+// nameserver group matches no traffic instead of failing the whole chain: the PeeringConnectivity
+// controller reports the missing Service in the status.
+func getDNSEndpoints(ctx context.Context, cl client.Client, opts *options.Options) (*utils.ServiceEndpoints, error) {
+	endpoints, err := utils.GetServiceEndpoints(ctx, cl, opts.DNS.ServiceNamespace, opts.DNS.ServiceName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the DNS service %s/%s: %w", opts.DNS.ServiceNamespace, opts.DNS.ServiceName, err)
+	}
+
+	if len(endpoints.Ports) == 0 && len(endpoints.TargetPorts) == 0 {
+		endpoints.Ports = defaultDNSPorts
+	}
+	return endpoints, nil
+}
+
+// nameserver: Matches traffic to the cluster DNS service, i.e., to its virtual IPs and endpoints, on the DNS ports.
+// Uses a set because the endpoint IPs are dynamically allocated.
+// The firewall configuration table is IPv4-only, hence only the IPv4 addresses are used in the set.
+var ResourceGroupNameserver = groupFuncts{
+	MakeFirewallConfigurationSets: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1beta1firewall.Set, error) {
+		endpoints, err := getDNSEndpoints(ctx, cl, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return []networkingv1beta1firewall.Set{
+			utils.ForgeIPsSet(forgeGroupSetName(connectivityv1.ResourceGroupNameserver), endpoints.Addresses),
+		}, nil
+	},
+	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string, position networkingv1beta1firewall.MatchPosition) ([][]networkingv1beta1firewall.Match, error) {
+		endpoints, err := getDNSEndpoints(ctx, cl, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		// Match the DNS addresses on each of the DNS ports, with the corresponding protocol.
+		// Both the service and the target ports are used, since the traffic may be observed
+		// before or after the service translation.
+		return utils.ForgePortMatchAlternatives([]networkingv1beta1firewall.Match{{
+			IP: &networkingv1beta1firewall.MatchIP{
+				Value:    utils.ForgeSetReference(forgeGroupSetName(connectivityv1.ResourceGroupNameserver)),
+				Position: position,
+			},
+			Op: networkingv1beta1firewall.MatchOperationEq,
+		}}, endpoints.AllPorts(), position), nil
+	},
+	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
+		endpoints, err := getDNSEndpoints(ctx, cl, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// NetworkPolicies are enforced after the service translation, hence the target ports are used if known.
+		ports := endpoints.TargetPorts
+		if len(ports) == 0 {
+			ports = endpoints.Ports
+		}
+
+		peers := utils.ForgeServiceNetworkPolicyPeers(endpoints)
+		if len(peers) == 0 {
+			return nil, nil, ErrNoMatchingPeers
+		}
+		return peers, utils.ForgeNetworkPolicyPorts(ports), nil
+	},
+}
+
+// any-dns: Matches traffic destined to any nameserver (port 53), regardless of its address.
+var ResourceGroupAnyDNS = groupFuncts{
+	MakeFirewallConfigurationSets: nil, // No sets needed for this group
+	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string, position networkingv1beta1firewall.MatchPosition) ([][]networkingv1beta1firewall.Match, error) {
+		return [][]networkingv1beta1firewall.Match{{{
+			Port: &networkingv1beta1firewall.MatchPort{
+				Value:    strconv.Itoa(dnsPort),
+				Position: position,
+			},
+			Op: networkingv1beta1firewall.MatchOperationEq,
+		}}}, nil
+	},
+	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
+		return nil, []networkingv1.NetworkPolicyPort{{
+			Port:     ptr.To(intstr.FromInt(dnsPort)),
+			Protocol: ptr.To(corev1.ProtocolTCP),
+		}, {
+			Port:     ptr.To(intstr.FromInt(dnsPort)),
+			Protocol: ptr.To(corev1.ProtocolUDP),
+		}}, nil
+	},
+}
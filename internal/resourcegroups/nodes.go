@@ -0,0 +1,69 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroups
+
+import (
+	"context"
+
+	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// local-nodes: Matches the addresses of the nodes of the local cluster, and hence the host-network pods.
+// Uses a set because node addresses are not necessarily contiguous.
+var ResourceGroupLocalNodes = groupFuncts{
+	MakeFirewallConfigurationSets: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1beta1firewall.Set, error) {
+		addresses, err := utils.GetNodeAddresses(ctx, cl)
+		if err != nil {
+			return nil, err
+		}
+
+		// Node addresses exposed to the remote clusters through the external CIDR are translated by Liqo,
+		// hence the set contains both the original and the remapped addresses.
+		remapped, err := utils.GetLocalRemappedIPs(ctx, cl, addresses)
+		if err != nil {
+			return nil, err
+		}
+
+		set := utils.ForgeIPsSet(forgeGroupSetName(connectivityv1.ResourceGroupLocalNodes), append(addresses, remapped...))
+		return []networkingv1beta1firewall.Set{set}, nil
+	},
+	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string, position networkingv1beta1firewall.MatchPosition) ([][]networkingv1beta1firewall.Match, error) {
+		return [][]networkingv1beta1firewall.Match{{{
+			IP: &networkingv1beta1firewall.MatchIP{
+				Value:    utils.ForgeSetReference(forgeGroupSetName(connectivityv1.ResourceGroupLocalNodes)),
+				Position: position,
+			},
+			Op: networkingv1beta1firewall.MatchOperationEq,
+		}}}, nil
+	},
+	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
+		// Host-network pods cannot be selected by label, hence the node addresses are used.
+		addresses, err := utils.GetNodeAddresses(ctx, cl)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		peers := utils.ForgeAddressNetworkPolicyPeers(addresses)
+		if len(peers) == 0 {
+			return nil, nil, ErrNoMatchingPeers
+		}
+		return peers, nil, nil
+	},
+}
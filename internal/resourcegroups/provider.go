@@ -19,7 +19,9 @@ import (
 
 	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
 	"github.com/liqotech/liqo/pkg/consts"
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
 	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -29,7 +31,7 @@ import (
 // and are running on this provider cluster.
 // Uses a set because pod IPs are dynamically allocated and may not be contiguous.
 var ResourceGroupOffloaded = groupFuncts{
-	MakeFirewallConfigurationSets: func(ctx context.Context, cl client.Client, clusterID string) ([]networkingv1beta1firewall.Set, error) {
+	MakeFirewallConfigurationSets: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1beta1firewall.Set, error) {
 		// Get all pods offloaded from the consumer cluster.
 		pods, err := utils.GetPodsFromConsumer(ctx, cl, clusterID)
 		if err != nil {
@@ -37,19 +39,19 @@ var ResourceGroupOffloaded = groupFuncts{
 		}
 
 		// Create a firewall set containing the IPs of these pods.
-		podIpsSet := utils.ForgePodIpsSet("offloaded", pods)
+		podIpsSet := utils.ForgePodIpsSet(forgeGroupSetName(connectivityv1.ResourceGroupOffloaded), pods)
 		return []networkingv1beta1firewall.Set{podIpsSet}, nil
 	},
-	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, clusterID string, position networkingv1beta1firewall.MatchPosition) ([]networkingv1beta1firewall.Match, error) {
-		return []networkingv1beta1firewall.Match{{
+	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string, position networkingv1beta1firewall.MatchPosition) ([][]networkingv1beta1firewall.Match, error) {
+		return [][]networkingv1beta1firewall.Match{{{
 			IP: &networkingv1beta1firewall.MatchIP{
-				Value:    "@offloaded",
+				Value:    utils.ForgeSetReference(forgeGroupSetName(connectivityv1.ResourceGroupOffloaded)),
 				Position: position,
 			},
 			Op: networkingv1beta1firewall.MatchOperationEq,
-		}}, nil
+		}}}, nil
 	},
-	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
+	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
 		return []networkingv1.NetworkPolicyPeer{{
 			NamespaceSelector: &metav1.LabelSelector{
 				MatchExpressions: []metav1.LabelSelectorRequirement{
@@ -0,0 +1,109 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroups
+
+import (
+	"context"
+
+	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// local-services: Matches the virtual IPs of the Services of the local cluster.
+// This doesn't need a set because it uses a simple CIDR match.
+var ResourceGroupLocalServices = groupFuncts{
+	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string, position networkingv1beta1firewall.MatchPosition) ([][]networkingv1beta1firewall.Match, error) {
+		// Get the local cluster's service CIDR and create a match rule for it.
+		cidr, err := utils.GetCurrentClusterServiceCIDR(ctx, cl, &opts.Liqo)
+		if err != nil {
+			return nil, err
+		}
+
+		return [][]networkingv1beta1firewall.Match{{{
+			IP: &networkingv1beta1firewall.MatchIP{
+				Value:    cidr,
+				Position: position,
+			},
+			Op: networkingv1beta1firewall.MatchOperationEq,
+		}}}, nil
+	},
+	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
+		// Most network plugins enforce NetworkPolicies after the service translation,
+		// hence this peer only matches the traffic that is not translated.
+		cidr, err := utils.GetCurrentClusterServiceCIDR(ctx, cl, &opts.Liqo)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return []networkingv1.NetworkPolicyPeer{{
+			IPBlock: &networkingv1.IPBlock{
+				CIDR: cidr,
+			},
+		}}, nil, nil
+	},
+}
+
+// remote-services: Matches the Services reflected on this provider cluster by the consumer cluster.
+// Uses a set containing both the virtual IPs and the endpoints, since traffic may be observed
+// before or after the service translation.
+var ResourceGroupRemoteServices = groupFuncts{
+	MakeFirewallConfigurationSets: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1beta1firewall.Set, error) {
+		virtualIPs, endpoints, err := utils.GetServiceAddressesFromConsumer(ctx, cl, clusterID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Endpoints in the pod CIDR of the consumer must be translated when Liqo remaps it.
+		translator, err := utils.GetRemoteClusterAddressTranslator(ctx, cl, &opts.Liqo, clusterID)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = translator.TranslateAll(endpoints)
+
+		set := utils.ForgeIPsSet(forgeGroupSetName(connectivityv1.ResourceGroupRemoteServices), append(virtualIPs, endpoints...))
+		return []networkingv1beta1firewall.Set{set}, nil
+	},
+	MakeFirewallConfigurationRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string, position networkingv1beta1firewall.MatchPosition) ([][]networkingv1beta1firewall.Match, error) {
+		return [][]networkingv1beta1firewall.Match{{{
+			IP: &networkingv1beta1firewall.MatchIP{
+				Value:    utils.ForgeSetReference(forgeGroupSetName(connectivityv1.ResourceGroupRemoteServices)),
+				Position: position,
+			},
+			Op: networkingv1beta1firewall.MatchOperationEq,
+		}}}, nil
+	},
+	MakeNetworkPolicyRule: func(ctx context.Context, cl client.Client, opts *options.Options, clusterID string) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, error) {
+		// NetworkPolicies are enforced after the service translation, hence only the endpoints are relevant.
+		_, endpoints, err := utils.GetServiceAddressesFromConsumer(ctx, cl, clusterID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		translator, err := utils.GetRemoteClusterAddressTranslator(ctx, cl, &opts.Liqo, clusterID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		peers := utils.ForgeAddressNetworkPolicyPeers(translator.TranslateAll(endpoints))
+		if len(peers) == 0 {
+			return nil, nil, ErrNoMatchingPeers
+		}
+		return peers, nil, nil
+	},
+}
@@ -0,0 +1,213 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchYears bounds the search of the next activation of a cron expression,
+// so that the expressions never matching (e.g., "0 0 30 2 *") do not loop forever.
+const maxSearchYears = 5
+
+// field describes the range and the symbolic names of a field of a cron expression.
+type field struct {
+	name     string
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// The day of week 7 is an alias of Sunday (0).
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// shorthands maps the supported shorthands to the equivalent cron expressions.
+var shorthands = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Cron is a parsed cron expression, with the allowed values of each field stored as bitmasks.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar record whether the day fields are unrestricted: as in cron, when both
+	// are restricted, a day matches if it matches either of them.
+	domStar, dowStar bool
+}
+
+// ParseCron parses a standard cron expression with five fields (minute, hour, day of month,
+// month and day of week), supporting lists, ranges, steps, the names of months and days,
+// and the @yearly, @monthly, @weekly, @daily and @hourly shorthands.
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(expr)
+	if expanded, found := shorthands[strings.ToLower(expr)]; found {
+		expr = expanded
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, found %d", expr, len(fields))
+	}
+
+	var (
+		cron Cron
+		err  error
+	)
+	if cron.minute, _, err = parseField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if cron.hour, _, err = parseField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if cron.dom, cron.domStar, err = parseField(fields[2], domField); err != nil {
+		return nil, err
+	}
+	if cron.month, _, err = parseField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if cron.dow, cron.dowStar, err = parseField(fields[4], dowField); err != nil {
+		return nil, err
+	}
+	if cron.dow&(1<<7) != 0 {
+		cron.dow = cron.dow&^(1<<7) | 1
+	}
+	return &cron, nil
+}
+
+// parseField parses a field of a cron expression, returning the bitmask of its allowed values
+// and whether it is unrestricted.
+func parseField(value string, f field) (uint64, bool, error) {
+	var bits uint64
+	for part := range strings.SplitSeq(value, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := uint(1)
+		if hasStep {
+			parsed, err := strconv.ParseUint(stepPart, 10, 8)
+			if err != nil || parsed == 0 {
+				return 0, false, fmt.Errorf("invalid step %q in the %s field", stepPart, f.name)
+			}
+			step = uint(parsed)
+		}
+
+		var low, high uint
+		switch lowPart, highPart, isRange := strings.Cut(rangePart, "-"); {
+		case rangePart == "*":
+			low, high = f.min, f.max
+		case isRange:
+			var err error
+			if low, err = f.parseValue(lowPart); err != nil {
+				return 0, false, err
+			}
+			if high, err = f.parseValue(highPart); err != nil {
+				return 0, false, err
+			}
+		default:
+			var err error
+			if low, err = f.parseValue(rangePart); err != nil {
+				return 0, false, err
+			}
+			// A single value with a step (e.g., 5/15) extends up to the maximum value.
+			high = low
+			if hasStep {
+				high = f.max
+			}
+		}
+
+		if low > high {
+			return 0, false, fmt.Errorf("invalid range %q in the %s field", rangePart, f.name)
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, value == "*", nil
+}
+
+// parseValue parses a single value of the field, either numeric or symbolic.
+func (f field) parseValue(value string) (uint, error) {
+	if v, found := f.names[strings.ToLower(value)]; found {
+		return v, nil
+	}
+
+	v, err := strconv.ParseUint(value, 10, 8)
+	if err != nil || uint(v) < f.min || uint(v) > f.max {
+		return 0, fmt.Errorf("invalid value %q in the %s field: must be between %d and %d", value, f.name, f.min, f.max)
+	}
+	return uint(v), nil
+}
+
+// Next returns the first activation of the cron expression strictly after the given time,
+// evaluated in its location. It returns the zero time if the expression does not activate
+// in the next years.
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	limit := t.AddDate(maxSearchYears, 0, 0)
+
+	// Activations are aligned to the minute.
+	t = t.Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+
+	for t.Before(limit) {
+		// The time is advanced with absolute increments within a day, so that it never moves
+		// backwards across the daylight saving time transitions.
+		var next time.Time
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			next = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.matchesDay(t):
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			next = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			next = t.Add(time.Minute)
+		default:
+			return t
+		}
+
+		if !next.After(t) {
+			next = t.Add(time.Minute)
+		}
+		t = next
+	}
+	return time.Time{}
+}
+
+// matchesDay checks whether the day of the given time matches the day of month and day of week fields.
+func (c *Cron) matchesDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,88 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cron", func() {
+	date := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	next := func(expr string, t time.Time) time.Time {
+		cron, err := ParseCron(expr)
+		Expect(err).ToNot(HaveOccurred())
+		return cron.Next(t)
+	}
+
+	DescribeTable("ParseCron should reject invalid expressions",
+		func(expr string) {
+			_, err := ParseCron(expr)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("too few fields", "* * * *"),
+		Entry("too many fields", "* * * * * *"),
+		Entry("out of range minute", "60 * * * *"),
+		Entry("out of range day of week", "* * * * 8"),
+		Entry("inverted range", "* 10-5 * * *"),
+		Entry("zero step", "*/0 * * * *"),
+		Entry("unknown name", "* * * foo *"),
+		Entry("unknown descriptor", "@often"),
+	)
+
+	DescribeTable("Next should return the first activation after the given time",
+		func(expr string, from, expected time.Time) {
+			Expect(next(expr, from)).To(Equal(expected))
+		},
+		Entry("every minute", "* * * * *", date(2026, 1, 1, 10, 0), date(2026, 1, 1, 10, 1)),
+		Entry("strictly after an activation", "0 9 * * *", date(2026, 1, 1, 9, 0), date(2026, 1, 2, 9, 0)),
+		Entry("list of hours", "0 9,18 * * *", date(2026, 1, 1, 10, 0), date(2026, 1, 1, 18, 0)),
+		Entry("step over a range", "0 8-18/4 * * *", date(2026, 1, 1, 13, 0), date(2026, 1, 1, 16, 0)),
+		Entry("month names", "0 0 1 jan,jul *", date(2026, 2, 1, 0, 0), date(2026, 7, 1, 0, 0)),
+		Entry("weekday names", "0 9 * * mon-fri", date(2026, 1, 2, 10, 0), date(2026, 1, 5, 9, 0)),
+		Entry("sunday as 7", "0 0 * * 7", date(2026, 1, 1, 0, 0), date(2026, 1, 4, 0, 0)),
+		Entry("either day field when both are restricted", "0 0 13 * fri", date(2026, 2, 1, 0, 0), date(2026, 2, 6, 0, 0)),
+		Entry("days missing from short months", "0 0 31 * *", date(2026, 4, 1, 0, 0), date(2026, 5, 31, 0, 0)),
+		Entry("leap days", "0 0 29 2 *", date(2026, 1, 1, 0, 0), date(2028, 2, 29, 0, 0)),
+		Entry("daily descriptor", "@daily", date(2026, 1, 1, 12, 0), date(2026, 1, 2, 0, 0)),
+		Entry("weekly descriptor", "@weekly", date(2026, 1, 1, 12, 0), date(2026, 1, 4, 0, 0)),
+	)
+
+	It("should return the zero time when the expression never activates", func() {
+		Expect(next("0 0 30 2 *", date(2026, 1, 1, 0, 0))).To(BeZero())
+	})
+
+	It("should evaluate the expression in the location of the given time", func() {
+		rome, err := time.LoadLocation("Europe/Rome")
+		Expect(err).ToNot(HaveOccurred())
+
+		activation := next("0 9 * * *", time.Date(2026, 1, 1, 10, 0, 0, 0, rome))
+		Expect(activation).To(BeTemporally("==", time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC)))
+	})
+
+	It("should skip the activations in the hour lost to daylight saving time", func() {
+		rome, err := time.LoadLocation("Europe/Rome")
+		Expect(err).ToNot(HaveOccurred())
+
+		// On 2026-03-29 clocks in Rome jump from 02:00 to 03:00.
+		activation := next("30 2 * * *", time.Date(2026, 3, 29, 0, 0, 0, 0, rome))
+		Expect(activation).To(BeTemporally("==", time.Date(2026, 3, 30, 2, 30, 0, 0, rome)))
+	})
+})
@@ -0,0 +1,19 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schedule evaluates the time windows in which the scheduled rules are active.
+// It parses the standard five-field cron expressions defining when each window opens,
+// and computes whether a schedule is active at a given time and when it changes next,
+// so that the reconciler can requeue at the window boundaries.
+package schedule
@@ -0,0 +1,101 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+)
+
+// maxMergedActivations bounds the number of overlapping activations of a window merged to compute
+// when it closes, since a window longer than the interval between its activations never closes.
+const maxMergedActivations = 1000
+
+// Window is a recurring time window, opening at each activation of a cron expression.
+type Window struct {
+	Start    *Cron
+	Duration time.Duration
+}
+
+// Schedule is a set of recurring time windows, evaluated in a time zone.
+type Schedule struct {
+	Windows  []Window
+	Location *time.Location
+}
+
+// Parse parses the schedule of a rule.
+func Parse(spec *connectivityv1.RuleSchedule) (*Schedule, error) {
+	location := time.UTC
+	if spec.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(spec.TimeZone); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", spec.TimeZone, err)
+		}
+	}
+
+	schedule := &Schedule{Location: location}
+	for i := range spec.Windows {
+		start, err := ParseCron(spec.Windows[i].Start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start of window %d: %w", i, err)
+		}
+		if spec.Windows[i].Duration.Duration <= 0 {
+			return nil, fmt.Errorf("invalid duration of window %d: must be positive", i)
+		}
+		schedule.Windows = append(schedule.Windows, Window{Start: start, Duration: spec.Windows[i].Duration.Duration})
+	}
+	return schedule, nil
+}
+
+// Evaluate returns whether any window of the schedule is open at the given time, and the next time
+// a window opens or closes, at which the schedule must be evaluated again. The returned time is zero
+// if no window opens or closes anymore.
+func (s *Schedule) Evaluate(now time.Time) (active bool, next time.Time) {
+	now = now.In(s.Location)
+	for _, window := range s.Windows {
+		open, transition := window.evaluate(now)
+		active = active || open
+		if !transition.IsZero() && (next.IsZero() || transition.Before(next)) {
+			next = transition
+		}
+	}
+	return active, next
+}
+
+// evaluate returns whether the window is open at the given time, and the time it closes, if open,
+// or opens, otherwise. A window opening at start is open in [start, start+duration).
+func (w Window) evaluate(now time.Time) (open bool, transition time.Time) {
+	// The window is open if it opened during the last duration.
+	start := w.Start.Next(now.Add(-w.Duration))
+	if start.IsZero() {
+		return false, time.Time{}
+	}
+	if start.After(now) {
+		return false, start
+	}
+
+	// Merge the activations opening the window again before it closes.
+	end := start.Add(w.Duration)
+	for range maxMergedActivations {
+		following := w.Start.Next(start)
+		if following.IsZero() || following.After(end) {
+			break
+		}
+		start, end = following, following.Add(w.Duration)
+	}
+	return true, end
+}
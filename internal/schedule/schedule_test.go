@@ -0,0 +1,106 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+)
+
+var _ = Describe("Schedule", func() {
+	date := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.January, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	parse := func(timeZone string, windows ...connectivityv1.ScheduleWindow) *Schedule {
+		schedule, err := Parse(&connectivityv1.RuleSchedule{Windows: windows, TimeZone: timeZone})
+		Expect(err).ToNot(HaveOccurred())
+		return schedule
+	}
+
+	window := func(start string, duration time.Duration) connectivityv1.ScheduleWindow {
+		return connectivityv1.ScheduleWindow{Start: start, Duration: metav1.Duration{Duration: duration}}
+	}
+
+	Describe("Parse", func() {
+		It("should default to UTC", func() {
+			Expect(parse("", window("@daily", time.Hour)).Location).To(Equal(time.UTC))
+		})
+
+		DescribeTable("should reject invalid schedules",
+			func(spec connectivityv1.RuleSchedule) {
+				_, err := Parse(&spec)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("unknown time zone", connectivityv1.RuleSchedule{
+				Windows: []connectivityv1.ScheduleWindow{window("@daily", time.Hour)}, TimeZone: "Mars/Olympus",
+			}),
+			Entry("invalid start", connectivityv1.RuleSchedule{
+				Windows: []connectivityv1.ScheduleWindow{window("0 25 * * *", time.Hour)},
+			}),
+			Entry("non-positive duration", connectivityv1.RuleSchedule{
+				Windows: []connectivityv1.ScheduleWindow{window("@daily", 0)},
+			}),
+		)
+	})
+
+	Describe("Evaluate", func() {
+		// 2026-01-05 is a Monday.
+		officeHours := window("0 9 * * mon-fri", 8*time.Hour)
+
+		DescribeTable("should report whether a window is open and when it next opens or closes",
+			func(now time.Time, active bool, next time.Time) {
+				gotActive, gotNext := parse("", officeHours).Evaluate(now)
+				Expect(gotActive).To(Equal(active))
+				Expect(gotNext).To(BeTemporally("==", next))
+			},
+			Entry("before the window opens", date(5, 8, 0), false, date(5, 9, 0)),
+			Entry("when the window opens", date(5, 9, 0), true, date(5, 17, 0)),
+			Entry("while the window is open", date(5, 12, 0), true, date(5, 17, 0)),
+			Entry("when the window closes", date(5, 17, 0), false, date(6, 9, 0)),
+			Entry("during the weekend", date(10, 12, 0), false, date(12, 9, 0)),
+		)
+
+		It("should merge activations opening the window again before it closes", func() {
+			active, next := parse("", window("0 * * * *", 90*time.Minute)).Evaluate(date(5, 12, 30))
+			Expect(active).To(BeTrue())
+			Expect(next).To(BeTemporally(">", date(5, 13, 30)))
+		})
+
+		It("should close merged windows after the last activation", func() {
+			active, next := parse("", window("0 9-11 * * *", 90*time.Minute)).Evaluate(date(5, 9, 30))
+			Expect(active).To(BeTrue())
+			Expect(next).To(BeTemporally("==", date(5, 12, 30)))
+		})
+
+		It("should report the earliest transition among the windows", func() {
+			active, next := parse("", officeHours, window("0 20 * * *", time.Hour)).Evaluate(date(5, 18, 0))
+			Expect(active).To(BeFalse())
+			Expect(next).To(BeTemporally("==", date(5, 20, 0)))
+		})
+
+		It("should evaluate the windows in the time zone of the schedule", func() {
+			// Europe/Rome is UTC+1 in January.
+			active, next := parse("Europe/Rome", officeHours).Evaluate(date(5, 8, 30))
+			Expect(active).To(BeTrue())
+			Expect(next).To(BeTemporally("==", date(5, 16, 0)))
+		})
+	})
+})
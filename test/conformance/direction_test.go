@@ -0,0 +1,320 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+	"strings"
+
+	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
+	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/gateway"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/networkpolicy"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
+)
+
+const clusterID = "cluster-a"
+
+// endpoint is a pod taking part in the connections checked by the conformance tests.
+type endpoint struct {
+	name      string
+	namespace string
+	ip        string
+	// offloaded is whether the pod is offloaded by the peered cluster, hence selected by the NetworkPolicies.
+	offloaded bool
+}
+
+var endpoints = []endpoint{
+	{name: "offloaded", namespace: "remote-app", ip: "10.0.0.1", offloaded: true},
+	{name: "alpha", namespace: "alpha", ip: "10.0.1.1"},
+	{name: "beta", namespace: "beta", ip: "10.0.2.1"},
+}
+
+// namespaceLabels returns the labels of the namespace of the given endpoint.
+func namespaceLabels(e *endpoint) map[string]string {
+	nsLabels := map[string]string{corev1.LabelMetadataName: e.namespace}
+	if e.offloaded {
+		nsLabels[consts.RemoteClusterID] = clusterID
+	}
+	return nsLabels
+}
+
+// gatewayAllows returns whether the connectivity rules of the gateway chain accept the packets from an endpoint to
+// another one. The preamble rules are not evaluated, as they do not depend on the connectivity rules.
+func gatewayAllows(spec *networkingv1beta1.FirewallConfigurationSpec, from, to *endpoint) bool {
+	inSet := func(reference, ip string) bool {
+		for i := range spec.Table.Sets {
+			if utils.ForgeSetReference(spec.Table.Sets[i].Name) == reference {
+				return slices.ContainsFunc(spec.Table.Sets[i].Elements, func(e networkingv1beta1firewall.SetElement) bool {
+					return e.Key == ip
+				})
+			}
+		}
+		Fail("missing set " + reference)
+		return false
+	}
+
+	for _, rule := range spec.Table.Chains[0].Rules.FilterRules {
+		if _, ok := gateway.ParseGatewayRuleName(ptr.Deref(rule.Name, "")); !ok {
+			continue
+		}
+
+		matched := true
+		for _, match := range rule.Match {
+			Expect(match.IP).NotTo(BeNil(), "only the IP matches are evaluated")
+			ip := from.ip
+			if match.IP.Position == networkingv1beta1firewall.MatchPositionDst {
+				ip = to.ip
+			}
+			if inSet(match.IP.Value, ip) != (match.Op == networkingv1beta1firewall.MatchOperationEq) {
+				matched = false
+			}
+		}
+		if matched {
+			return rule.Action == networkingv1beta1firewall.ActionAccept
+		}
+	}
+	return false
+}
+
+// networkPolicyAllows returns whether the NetworkPolicy selecting the offloaded pods allows the connections from
+// an endpoint to another one: the egress of the initiator and the ingress of the responder must be allowed, if
+// they are offloaded. The IP blocks match the addresses of the pods too, as the gateway firewall does.
+func networkPolicyAllows(spec *networkingv1.NetworkPolicySpec, from, to *endpoint) bool {
+	peersMatch := func(peers []networkingv1.NetworkPolicyPeer, e *endpoint) bool {
+		if len(peers) == 0 {
+			return true
+		}
+		return slices.ContainsFunc(peers, func(peer networkingv1.NetworkPolicyPeer) bool {
+			if peer.IPBlock != nil {
+				inPrefix := func(cidr string) bool {
+					return netip.MustParsePrefix(cidr).Contains(netip.MustParseAddr(e.ip))
+				}
+				return inPrefix(peer.IPBlock.CIDR) && !slices.ContainsFunc(peer.IPBlock.Except, inPrefix)
+			}
+
+			Expect(peer.NamespaceSelector).NotTo(BeNil(), "only the namespace selectors are evaluated")
+			Expect(peer.PodSelector).To(BeNil(), "only the namespace selectors are evaluated")
+			selector, err := metav1.LabelSelectorAsSelector(peer.NamespaceSelector)
+			Expect(err).NotTo(HaveOccurred())
+			return selector.Matches(labels.Set(namespaceLabels(e)))
+		})
+	}
+
+	egress := !from.offloaded || slices.ContainsFunc(spec.Egress, func(rule networkingv1.NetworkPolicyEgressRule) bool {
+		return peersMatch(rule.To, to)
+	})
+	ingress := !to.offloaded || slices.ContainsFunc(spec.Ingress, func(rule networkingv1.NetworkPolicyIngressRule) bool {
+		return peersMatch(rule.From, from)
+	})
+	return egress && ingress
+}
+
+var _ = Describe("Rule direction", func() {
+	var (
+		ctx  context.Context
+		cl   client.Client
+		opts *options.Options
+
+		offloaded = &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupOffloaded)}
+		alpha     = &connectivityv1.Party{Namespace: ptr.To("alpha")}
+		beta      = &connectivityv1.Party{Namespace: ptr.To("beta")}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		opts = options.NewDefaultOptions()
+
+		scheme := runtime.NewScheme()
+		utils.RegisterScheme(scheme)
+
+		builder := fake.NewClientBuilder().WithScheme(scheme)
+		for i := range endpoints {
+			e := &endpoints[i]
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: e.name, Namespace: e.namespace},
+				Status:     corev1.PodStatus{PodIP: e.ip},
+			}
+			if e.offloaded {
+				pod.Labels = map[string]string{forge.LiqoOriginClusterIDKey: clusterID}
+			}
+			builder = builder.WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: e.namespace, Labels: namespaceLabels(e)}},
+				pod,
+			)
+		}
+		cl = builder.Build()
+	})
+
+	// rule returns an allow rule with the given parties and direction.
+	rule := func(source, destination *connectivityv1.Party, direction connectivityv1.Direction) connectivityv1.Rule {
+		return connectivityv1.Rule{Action: connectivityv1.ActionAllow, Source: source, Destination: destination, Direction: direction}
+	}
+
+	// deny returns a deny rule with the given parties and direction.
+	deny := func(source, destination *connectivityv1.Party, direction connectivityv1.Direction) connectivityv1.Rule {
+		return connectivityv1.Rule{Action: connectivityv1.ActionDeny, Source: source, Destination: destination, Direction: direction}
+	}
+
+	// allowedConnections returns the connections of the offloaded pods allowed by the gateway firewall
+	// and by the NetworkPolicy forged from the given rules.
+	allowedConnections := func(rules []connectivityv1.Rule) (byGateway, byNetworkPolicy []string) {
+		cfg := &connectivityv1.PeeringConnectivity{Spec: connectivityv1.PeeringConnectivitySpec{Rules: rules}}
+
+		gatewaySpec, _, err := gateway.ForgeGatewaySpec(ctx, cl, opts, nil, cfg, nil, clusterID)
+		Expect(err).NotTo(HaveOccurred())
+		networkPolicySpec, err := networkpolicy.ForgeProviderNetworkPolicySpec(ctx, cl, opts, nil, cfg, clusterID, "remote-app")
+		Expect(err).NotTo(HaveOccurred())
+
+		for i := range endpoints {
+			for j := range endpoints {
+				from, to := &endpoints[i], &endpoints[j]
+				if !from.offloaded && !to.offloaded {
+					// The connections not involving the offloaded pods are not subject to the NetworkPolicies.
+					continue
+				}
+
+				connection := strings.Join([]string{from.name, to.name}, ">")
+				if gatewayAllows(gatewaySpec, from, to) {
+					byGateway = append(byGateway, connection)
+				}
+				if networkPolicyAllows(networkPolicySpec, from, to) {
+					byNetworkPolicy = append(byNetworkPolicy, connection)
+				}
+			}
+		}
+		return byGateway, byNetworkPolicy
+	}
+
+	DescribeTable("the gateway firewall and the NetworkPolicies should allow the same connections of the offloaded pods",
+		func(rules []connectivityv1.Rule, expected []string) {
+			byGateway, byNetworkPolicy := allowedConnections(rules)
+			Expect(byGateway).To(ConsistOf(expected), "connections allowed by the gateway firewall")
+			Expect(byNetworkPolicy).To(ConsistOf(expected), "connections allowed by the NetworkPolicy")
+		},
+		Entry("no rules", nil, []string{}),
+		Entry("an egress rule from the offloaded pods",
+			[]connectivityv1.Rule{rule(offloaded, alpha, connectivityv1.DirectionEgress)},
+			[]string{"offloaded>alpha"}),
+		Entry("a rule without direction, which defaults to egress",
+			[]connectivityv1.Rule{rule(offloaded, alpha, "")},
+			[]string{"offloaded>alpha"}),
+		Entry("an ingress rule of the offloaded pods",
+			[]connectivityv1.Rule{rule(offloaded, alpha, connectivityv1.DirectionIngress)},
+			[]string{"alpha>offloaded"}),
+		Entry("a bidirectional rule of the offloaded pods",
+			[]connectivityv1.Rule{rule(offloaded, alpha, connectivityv1.DirectionBidirectional)},
+			[]string{"offloaded>alpha", "alpha>offloaded"}),
+		Entry("an egress rule towards the offloaded pods",
+			[]connectivityv1.Rule{rule(alpha, offloaded, connectivityv1.DirectionEgress)},
+			[]string{"alpha>offloaded"}),
+		Entry("an ingress rule of a party towards the offloaded pods",
+			[]connectivityv1.Rule{rule(beta, offloaded, connectivityv1.DirectionIngress)},
+			[]string{"offloaded>beta"}),
+		Entry("a rule from any party to the offloaded pods",
+			[]connectivityv1.Rule{rule(nil, offloaded, connectivityv1.DirectionEgress)},
+			[]string{"offloaded>offloaded", "alpha>offloaded", "beta>offloaded"}),
+		Entry("a bidirectional rule between the offloaded pods and any party",
+			[]connectivityv1.Rule{rule(offloaded, nil, connectivityv1.DirectionBidirectional)},
+			[]string{"offloaded>offloaded", "offloaded>alpha", "offloaded>beta", "alpha>offloaded", "beta>offloaded"}),
+		Entry("a rule between the offloaded pods",
+			[]connectivityv1.Rule{rule(offloaded, offloaded, connectivityv1.DirectionBidirectional)},
+			[]string{"offloaded>offloaded"}),
+		Entry("a rule not involving the offloaded pods",
+			[]connectivityv1.Rule{rule(alpha, beta, connectivityv1.DirectionBidirectional)},
+			[]string{}),
+		Entry("multiple rules",
+			[]connectivityv1.Rule{
+				rule(offloaded, alpha, connectivityv1.DirectionEgress),
+				rule(offloaded, beta, connectivityv1.DirectionIngress),
+				rule(alpha, beta, connectivityv1.DirectionEgress),
+			},
+			[]string{"offloaded>alpha", "beta>offloaded"}),
+		Entry("a deny rule of the offloaded pods",
+			[]connectivityv1.Rule{deny(offloaded, alpha, connectivityv1.DirectionBidirectional)},
+			[]string{}),
+		Entry("a deny rule following an allow rule of the same connections",
+			[]connectivityv1.Rule{
+				rule(offloaded, alpha, connectivityv1.DirectionEgress),
+				deny(offloaded, alpha, connectivityv1.DirectionEgress),
+			},
+			[]string{"offloaded>alpha"}),
+		Entry("a deny rule and an allow rule of different connections",
+			[]connectivityv1.Rule{
+				deny(offloaded, beta, connectivityv1.DirectionBidirectional),
+				rule(offloaded, alpha, connectivityv1.DirectionBidirectional),
+			},
+			[]string{"offloaded>alpha", "alpha>offloaded"}),
+		Entry("a deny rule not involving the offloaded pods",
+			[]connectivityv1.Rule{
+				deny(alpha, beta, connectivityv1.DirectionBidirectional),
+				rule(offloaded, nil, connectivityv1.DirectionEgress),
+			},
+			[]string{"offloaded>offloaded", "offloaded>alpha", "offloaded>beta"}),
+		Entry("a deny rule preceding a broader allow rule",
+			[]connectivityv1.Rule{
+				deny(offloaded, alpha, connectivityv1.DirectionEgress),
+				rule(offloaded, nil, connectivityv1.DirectionEgress),
+			},
+			[]string{"offloaded>offloaded", "offloaded>beta"}),
+		Entry("a deny rule preceding a broader allow rule of the ingress",
+			[]connectivityv1.Rule{
+				deny(beta, offloaded, connectivityv1.DirectionBidirectional),
+				rule(nil, offloaded, connectivityv1.DirectionBidirectional),
+			},
+			[]string{"offloaded>offloaded", "offloaded>alpha", "alpha>offloaded"}),
+		Entry("a deny rule preceding an allow rule of the same connections",
+			[]connectivityv1.Rule{
+				deny(offloaded, alpha, connectivityv1.DirectionEgress),
+				rule(offloaded, alpha, connectivityv1.DirectionEgress),
+			},
+			[]string{}),
+		Entry("deny rules of the offloaded pods preceding a broader allow rule",
+			[]connectivityv1.Rule{
+				deny(offloaded, offloaded, connectivityv1.DirectionEgress),
+				deny(offloaded, beta, connectivityv1.DirectionEgress),
+				rule(offloaded, nil, connectivityv1.DirectionEgress),
+			},
+			[]string{"offloaded>alpha"}),
+	)
+
+	It("should reject the deny rules whose traffic cannot be excluded from the NetworkPolicy", func() {
+		cfg := &connectivityv1.PeeringConnectivity{Spec: connectivityv1.PeeringConnectivitySpec{Rules: []connectivityv1.Rule{
+			deny(offloaded, &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupAnyDNS)}, connectivityv1.DirectionEgress),
+			rule(offloaded, nil, connectivityv1.DirectionEgress),
+		}}}
+
+		// NetworkPolicies cannot allow any port but the denied ones.
+		_, err := networkpolicy.ForgeProviderNetworkPolicySpec(ctx, cl, opts, nil, cfg, clusterID, "remote-app")
+		Expect(err).To(MatchError(utils.ErrNotExpressible))
+	})
+})
@@ -0,0 +1,88 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/networkpolicy"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
+)
+
+var _ = Describe("Resource groups in NetworkPolicies", func() {
+	var (
+		ctx  context.Context
+		cl   client.Client
+		opts *options.Options
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		opts = options.NewDefaultOptions()
+
+		scheme := runtime.NewScheme()
+		utils.RegisterScheme(scheme)
+		cl = fake.NewClientBuilder().WithScheme(scheme).Build()
+	})
+
+	// forgeEgress returns the egress rules of the NetworkPolicy of the offloaded pods allowed towards the given group.
+	forgeEgress := func(group connectivityv1.ResourceGroup) []networkingv1.NetworkPolicyEgressRule {
+		cfg := &connectivityv1.PeeringConnectivity{Spec: connectivityv1.PeeringConnectivitySpec{
+			Rules: []connectivityv1.Rule{{
+				Action:      connectivityv1.ActionAllow,
+				Source:      &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupOffloaded)},
+				Destination: &connectivityv1.Party{Group: ptr.To(group)},
+			}},
+		}}
+
+		spec, err := networkpolicy.ForgeProviderNetworkPolicySpec(ctx, cl, opts, nil, cfg, clusterID, "remote-app")
+		Expect(err).NotTo(HaveOccurred())
+		return spec.Egress
+	}
+
+	It("should allow the DNS traffic towards any peer for the any-dns group", func() {
+		Expect(forgeEgress(connectivityv1.ResourceGroupAnyDNS)).To(Equal([]networkingv1.NetworkPolicyEgressRule{{
+			Ports: []networkingv1.NetworkPolicyPort{{
+				Port:     ptr.To(intstr.FromInt(53)),
+				Protocol: ptr.To(corev1.ProtocolTCP),
+			}, {
+				Port:     ptr.To(intstr.FromInt(53)),
+				Protocol: ptr.To(corev1.ProtocolUDP),
+			}},
+		}}))
+	})
+
+	It("should skip the rules towards the nameserver group if the DNS Service does not exist", func() {
+		// The DNS Service may have a different name, e.g. on clusters not using kube-dns.
+		Expect(forgeEgress(connectivityv1.ResourceGroupNameserver)).To(BeEmpty())
+	})
+
+	It("should skip the rules towards a group currently matching no peer", func() {
+		// Without nodes, an egress rule without peers would allow the traffic towards any peer instead.
+		Expect(forgeEgress(connectivityv1.ResourceGroupLocalNodes)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,228 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/fabric"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/gateway"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/counters"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
+)
+
+var _ = Describe("Chain placement", func() {
+	var (
+		ctx  context.Context
+		cl   client.Client
+		opts *options.Options
+
+		cfg = &connectivityv1.PeeringConnectivity{Spec: connectivityv1.PeeringConnectivitySpec{
+			Rules: []connectivityv1.Rule{{
+				Action:      connectivityv1.ActionAllow,
+				Source:      &connectivityv1.Party{Group: ptr.To(connectivityv1.ResourceGroupOffloaded)},
+				Destination: &connectivityv1.Party{Namespace: ptr.To("alpha")},
+			}},
+		}}
+		restrictions = []utils.NamespaceRestriction{{
+			Namespace:       "alpha",
+			RestrictIngress: true,
+			Ingress:         []connectivityv1.Party{{Group: ptr.To(connectivityv1.ResourceGroupOffloaded)}},
+		}}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		opts = options.NewDefaultOptions()
+
+		scheme := runtime.NewScheme()
+		utils.RegisterScheme(scheme)
+		cl = fake.NewClientBuilder().WithScheme(scheme).Build()
+	})
+
+	// forgeChains returns the chains of the forged gateway spec, keyed by name.
+	forgeChains := func() map[string]networkingv1beta1firewall.Chain {
+		spec, _, err := gateway.ForgeGatewaySpec(ctx, cl, opts, nil, cfg, restrictions, clusterID)
+		Expect(err).NotTo(HaveOccurred())
+
+		chains := make(map[string]networkingv1beta1firewall.Chain, len(spec.Table.Chains))
+		for _, chain := range spec.Table.Chains {
+			chains[*chain.Name] = chain
+		}
+		return chains
+	}
+
+	// preambleRules returns the names of the preamble rules of the given chain, and the positions of the
+	// interfaces they match.
+	preambleRules := func(chain *networkingv1beta1firewall.Chain) (names []string, positions []networkingv1beta1firewall.MatchDevPosition) {
+		for _, rule := range chain.Rules.FilterRules {
+			name := ptr.Deref(rule.Name, "")
+			if _, ok := gateway.ParseGatewayRuleName(name); ok || strings.HasPrefix(name, "allowed-") || strings.HasPrefix(name, "restricted-") {
+				continue
+			}
+			names = append(names, name)
+			for _, match := range rule.Match {
+				if match.Dev != nil {
+					positions = append(positions, match.Dev.Position)
+				}
+			}
+		}
+		return names, positions
+	}
+
+	DescribeTable("should attach the gateway and restriction chains to the configured hook",
+		func(hook string, priority int, preamble []string, positions []networkingv1beta1firewall.MatchDevPosition) {
+			opts.Gateway.ChainHooks = []string{hook}
+			opts.Gateway.ChainPriority = priority
+			Expect(opts.Validate()).To(Succeed())
+
+			chains := forgeChains()
+			Expect(chains).To(HaveLen(2))
+
+			filter := chains[gateway.ForgeGatewayChainName(clusterID, hook)]
+			Expect(filter.Hook).To(HaveValue(BeEquivalentTo(hook)))
+			Expect(filter.Priority).To(HaveValue(BeEquivalentTo(priority)))
+			Expect(filter.Policy).To(HaveValue(Equal(networkingv1beta1firewall.ChainPolicyDrop)))
+
+			// The restriction chains are evaluated right after the gateway chain at the same hook.
+			restriction := chains[gateway.ForgeGatewayRestrictionChainName(clusterID, "alpha", connectivityv1.NamespacePolicyTypeIngress, hook)]
+			Expect(restriction.Hook).To(HaveValue(BeEquivalentTo(hook)))
+			Expect(restriction.Priority).To(HaveValue(BeEquivalentTo(priority + 1)))
+			Expect(restriction.Policy).To(HaveValue(Equal(networkingv1beta1firewall.ChainPolicyAccept)))
+
+			// The bypass rules match only the interfaces the packets have at the hook.
+			for _, chain := range []*networkingv1beta1firewall.Chain{&filter, &restriction} {
+				names, devPositions := preambleRules(chain)
+				Expect(names).To(Equal(preamble))
+				Expect(devPositions).To(Equal(positions))
+			}
+		},
+		// The default placement, seeing the traffic leaving the gateway after the source NAT decision.
+		Entry("postrouting", "postrouting", options.DefaultChainPriority,
+			[]string{"allow-established-related", "match-tunnel-interface", "allow-eth0"},
+			[]networkingv1beta1firewall.MatchDevPosition{networkingv1beta1firewall.MatchDevPositionIn, networkingv1beta1firewall.MatchDevPositionOut}),
+		// The traffic routed through the gateway, with the addresses before the source NAT.
+		Entry("forward", "forward", 0,
+			[]string{"allow-established-related", "match-tunnel-interface", "allow-eth0"},
+			[]networkingv1beta1firewall.MatchDevPosition{networkingv1beta1firewall.MatchDevPositionIn, networkingv1beta1firewall.MatchDevPositionOut}),
+		// The traffic delivered to the gateway itself, before any output interface is known.
+		Entry("input", "input", 0,
+			[]string{"allow-established-related", "match-tunnel-interface"},
+			[]networkingv1beta1firewall.MatchDevPosition{networkingv1beta1firewall.MatchDevPositionIn}),
+		// The traffic generated by the gateway itself, without an input interface: only the traffic sent to the
+		// tunnel is filtered, hence the loopback and uplink traffic of the gateway is never dropped.
+		Entry("output", "output", 0,
+			[]string{"allow-established-related", "match-tunnel-interface", "allow-eth0"},
+			[]networkingv1beta1firewall.MatchDevPosition{networkingv1beta1firewall.MatchDevPositionOut, networkingv1beta1firewall.MatchDevPositionOut}),
+		// The traffic entering the gateway, after the destination NAT at priority -100, before the routing decision.
+		Entry("prerouting", "prerouting", -50,
+			[]string{"allow-established-related", "match-tunnel-interface"},
+			[]networkingv1beta1firewall.MatchDevPosition{networkingv1beta1firewall.MatchDevPositionIn}),
+	)
+
+	It("should accept the traffic of the gateway not sent to the tunnel at the output hook", func() {
+		opts.Gateway.ChainHooks = []string{"output"}
+		Expect(opts.Validate()).To(Succeed())
+
+		output := forgeChains()[gateway.ForgeGatewayChainName(clusterID, "output")]
+		Expect(output.Policy).To(HaveValue(Equal(networkingv1beta1firewall.ChainPolicyDrop)))
+
+		var bypass *networkingv1beta1firewall.FilterRule
+		for i := range output.Rules.FilterRules {
+			if ptr.Deref(output.Rules.FilterRules[i].Name, "") == "match-tunnel-interface" {
+				bypass = &output.Rules.FilterRules[i]
+			}
+		}
+		Expect(bypass).NotTo(BeNil())
+		Expect(bypass.Action).To(Equal(networkingv1beta1firewall.ActionAccept))
+		Expect(bypass.Match).To(ConsistOf(networkingv1beta1firewall.Match{
+			Dev: &networkingv1beta1firewall.MatchDev{
+				Position: networkingv1beta1firewall.MatchDevPositionOut,
+				Value:    opts.Gateway.TunnelInterface,
+			},
+			Op: networkingv1beta1firewall.MatchOperationNeq,
+		}))
+	})
+
+	It("should render a chain with the same connectivity rules for each configured hook", func() {
+		opts.Gateway.ChainHooks = []string{"forward", "input"}
+		Expect(opts.Validate()).To(Succeed())
+
+		chains := forgeChains()
+		Expect(chains).To(HaveLen(4))
+
+		forward := chains[gateway.ForgeGatewayChainName(clusterID, "forward")]
+		input := chains[gateway.ForgeGatewayChainName(clusterID, "input")]
+		Expect(forward.Hook).To(HaveValue(Equal(networkingv1beta1firewall.ChainHookForward)))
+		Expect(input.Hook).To(HaveValue(Equal(networkingv1beta1firewall.ChainHookInput)))
+
+		// The input chain misses the bypass of the uplink interfaces, as the packets have no output interface yet.
+		forwardPreamble, _ := preambleRules(&forward)
+		inputPreamble, _ := preambleRules(&input)
+		Expect(forwardPreamble).To(Equal(append(slices.Clone(inputPreamble), "allow-eth0")))
+		Expect(forward.Rules.FilterRules[len(forwardPreamble):]).To(Equal(input.Rules.FilterRules[len(inputPreamble):]))
+
+		for _, hook := range opts.Gateway.ChainHooks {
+			Expect(chains).To(HaveKey(gateway.ForgeGatewayRestrictionChainName(clusterID, "alpha", connectivityv1.NamespacePolicyTypeIngress, hook)))
+		}
+	})
+
+	It("should keep the fabric chain at postrouting whatever the gateway hooks", func() {
+		opts.Gateway.ChainHooks = []string{"forward", "input"}
+		opts.Gateway.ChainPriority = 0
+		Expect(opts.Validate()).To(Succeed())
+
+		spec, _, err := fabric.ForgeFabricSpec(ctx, cl, opts, nil, cfg, clusterID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec.Table.Chains).To(HaveLen(1))
+
+		chain := spec.Table.Chains[0]
+		Expect(chain.Name).To(HaveValue(Equal(fabric.ForgeFabricChainName(clusterID))))
+		Expect(chain.Hook).To(HaveValue(BeEquivalentTo(options.DefaultChainHook)))
+		Expect(chain.Priority).To(HaveValue(BeEquivalentTo(options.DefaultChainPriority)))
+	})
+
+	It("should give different names to the chains of different hooks", func() {
+		names := make(map[string]struct{})
+		for _, hook := range options.SupportedChainHooks {
+			names[gateway.ForgeGatewayChainName(clusterID, hook)] = struct{}{}
+		}
+		Expect(names).To(HaveLen(len(options.SupportedChainHooks)))
+	})
+
+	It("should sum the counters of the chains of all the hooks", func() {
+		rule := gateway.ForgeGatewayRuleName(0, 0, 1)
+		snapshot := counters.Snapshot{
+			{Chain: gateway.ForgeGatewayChainName(clusterID, "forward"), Rule: rule}: {Packets: 3, Bytes: 300},
+			{Chain: gateway.ForgeGatewayChainName(clusterID, "input"), Rule: rule}:   {Packets: 1, Bytes: 100},
+			{Chain: gateway.ForgeGatewayChainName("cluster-b", "input"), Rule: rule}: {Packets: 7, Bytes: 700},
+		}
+		Expect(gateway.AggregateRuleCounters(snapshot, clusterID)).To(Equal(map[int]counters.Counter{
+			0: {Packets: 4, Bytes: 400},
+		}))
+	})
+})
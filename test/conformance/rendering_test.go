@@ -0,0 +1,146 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
+	networkingv1beta1firewall "github.com/liqotech/liqo/apis/networking/v1beta1/firewall"
+	"github.com/liqotech/liqo/pkg/consts"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/fabric"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/gateway"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
+)
+
+var _ = Describe("Rendering", func() {
+	namespaces := []string{"alpha", "beta", "gamma", "delta", "epsilon"}
+
+	var (
+		ctx   context.Context
+		cl    client.Client
+		opts  *options.Options
+		chain *utils.PeeringConnectivityChain
+	)
+
+	newPod := func(namespace string, i int) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i), Namespace: namespace},
+			Status:     corev1.PodStatus{PodIP: fmt.Sprintf("10.0.%d.%d", len(namespace), 20-i)},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		opts = options.NewDefaultOptions()
+
+		scheme := runtime.NewScheme()
+		utils.RegisterScheme(scheme)
+		builder := fake.NewClientBuilder().WithScheme(scheme)
+
+		// A rule for each namespace, so that the namespace sets are tracked in a map while forging the spec.
+		cfg := &connectivityv1.PeeringConnectivity{
+			ObjectMeta: metav1.ObjectMeta{Name: "peering", Namespace: "liqo-tenant-" + clusterID, UID: types.UID("uid")},
+		}
+		for _, namespace := range namespaces {
+			cfg.Spec.Rules = append(cfg.Spec.Rules, connectivityv1.Rule{
+				Action:      connectivityv1.ActionAllow,
+				Destination: &connectivityv1.Party{Namespace: ptr.To(namespace)},
+			})
+			for i := range 3 {
+				builder = builder.WithObjects(newPod(namespace, i))
+			}
+		}
+		cl = builder.Build()
+
+		chain = &utils.PeeringConnectivityChain{Effective: cfg, Members: []*connectivityv1.PeeringConnectivity{cfg}}
+	})
+
+	It("should render the same spec with sorted sets and elements", func() {
+		spec, _, err := gateway.ForgeGatewaySpec(ctx, cl, opts, nil, chain.Effective, nil, clusterID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec.Table.Sets).To(HaveLen(len(namespaces)))
+
+		Expect(slices.IsSortedFunc(spec.Table.Sets, func(a, b networkingv1beta1firewall.Set) int {
+			return strings.Compare(a.Name, b.Name)
+		})).To(BeTrue())
+		for i := range spec.Table.Sets {
+			Expect(spec.Table.Sets[i].Elements).To(HaveLen(3))
+			Expect(spec.Table.Sets[i].Elements[0].Key).To(HaveSuffix(".18"))
+		}
+
+		// The maps are iterated in a different order on each render.
+		for range 20 {
+			again, _, err := gateway.ForgeGatewaySpec(ctx, cl, opts, nil, chain.Effective, nil, clusterID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(again).To(Equal(spec))
+		}
+	})
+
+	It("should skip the write of an unchanged render", func() {
+		op, hash, err := gateway.ReconcileGatewayFirewallConfiguration(ctx, cl, cl.Scheme(), opts, nil, chain, clusterID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(op).To(Equal(controllerutil.OperationResultCreated))
+
+		for range 5 {
+			op, rendered, err := gateway.ReconcileGatewayFirewallConfiguration(ctx, cl, cl.Scheme(), opts, nil, chain, clusterID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(op).To(Equal(controllerutil.OperationResultNone))
+			Expect(rendered).To(Equal(hash))
+		}
+
+		Expect(cl.Create(ctx, newPod("alpha", 3))).To(Succeed())
+		op, rendered, err := gateway.ReconcileGatewayFirewallConfiguration(ctx, cl, cl.Scheme(), opts, nil, chain, clusterID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(op).To(Equal(controllerutil.OperationResultUpdated))
+		Expect(rendered).NotTo(Equal(hash))
+	})
+
+	It("should scope the fabric FirewallConfiguration to the peering", func() {
+		other := &connectivityv1.PeeringConnectivity{
+			ObjectMeta: metav1.ObjectMeta{Name: "peering", Namespace: "liqo-tenant-other", UID: types.UID("other")},
+			Spec:       chain.Effective.Spec,
+		}
+		otherChain := &utils.PeeringConnectivityChain{Effective: other, Members: []*connectivityv1.PeeringConnectivity{other}}
+
+		_, err := fabric.ReconcileFabricFirewallConfiguration(ctx, cl, cl.Scheme(), opts, nil, chain, clusterID)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = fabric.ReconcileFabricFirewallConfiguration(ctx, cl, cl.Scheme(), opts, nil, otherChain, "other")
+		Expect(err).NotTo(HaveOccurred())
+
+		var fwcfgs networkingv1beta1.FirewallConfigurationList
+		Expect(cl.List(ctx, &fwcfgs, client.MatchingLabels{consts.RemoteClusterID: clusterID})).To(Succeed())
+		Expect(fwcfgs.Items).To(HaveLen(1))
+		Expect(fwcfgs.Items[0].Name).To(Equal(fabric.ForgeFabricResourceName(clusterID)))
+		Expect(fwcfgs.Items[0].Spec.Table.Name).To(HaveValue(Equal(fabric.ForgeFabricTableName(clusterID))))
+		Expect(fabric.ForgeFabricTableName(clusterID)).NotTo(Equal(fabric.ForgeFabricTableName("other")))
+	})
+})
@@ -0,0 +1,125 @@
+// Copyright 2019-2026 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	connectivityv1 "github.com/riccardotornesello/liqo-connectivity-engine/api/v1"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/gateway"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/controller/utils"
+	"github.com/riccardotornesello/liqo-connectivity-engine/internal/options"
+)
+
+var _ = Describe("Gateway settings", func() {
+	var (
+		ctx  context.Context
+		cl   client.Client
+		opts *options.Options
+		cfg  *connectivityv1.PeeringConnectivity
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		opts = options.NewDefaultOptions()
+
+		scheme := runtime.NewScheme()
+		utils.RegisterScheme(scheme)
+		cl = fake.NewClientBuilder().WithScheme(scheme).Build()
+
+		cfg = &connectivityv1.PeeringConnectivity{Spec: connectivityv1.PeeringConnectivitySpec{
+			Rules: []connectivityv1.Rule{{
+				Action:      connectivityv1.ActionAllow,
+				Destination: &connectivityv1.Party{Namespace: ptr.To("alpha")},
+			}},
+		}}
+	})
+
+	It("should reject an uplink interface matching the tunnel interface", func() {
+		cfg.Spec.Gateway = &connectivityv1.GatewaySettings{
+			UplinkInterfaces: []connectivityv1.InterfaceName{"eth1", connectivityv1.InterfaceName(opts.Gateway.TunnelInterface)},
+		}
+
+		_, _, err := gateway.ForgeGatewaySpec(ctx, cl, opts, nil, cfg, nil, clusterID)
+		Expect(err).To(MatchError(ContainSubstring("cannot be the tunnel interface")))
+	})
+
+	It("should accept uplink interfaces different from the tunnel interface", func() {
+		cfg.Spec.Gateway = &connectivityv1.GatewaySettings{UplinkInterfaces: []connectivityv1.InterfaceName{"eth1"}}
+
+		_, _, err := gateway.ForgeGatewaySpec(ctx, cl, opts, nil, cfg, nil, clusterID)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("merged conntrack settings", func() {
+		// preambleRuleNames returns the names of the preamble rules of the chain of the given PeeringConnectivity resources.
+		preambleRuleNames := func(members ...*connectivityv1.PeeringConnectivity) []string {
+			chain := utils.NewPeeringConnectivityChain(members)
+			rules := gateway.ForgePreambleRules(gateway.ResolveGatewayOptions(opts, chain.Effective.Spec.Gateway), options.DefaultChainHook)
+
+			names := make([]string, len(rules))
+			for i := range rules {
+				names[i] = *rules[i].Name
+			}
+			return names
+		}
+
+		withConntrack := func(name string, priority int32, conntrack connectivityv1.ConntrackSettings) *connectivityv1.PeeringConnectivity {
+			return &connectivityv1.PeeringConnectivity{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec: connectivityv1.PeeringConnectivitySpec{
+					Priority: priority,
+					Gateway:  &connectivityv1.GatewaySettings{Conntrack: &conntrack},
+				},
+			}
+		}
+
+		It("should take each setting from the PeeringConnectivity with the highest priority setting it", func() {
+			names := preambleRuleNames(
+				withConntrack("high", 10, connectivityv1.ConntrackSettings{DropInvalid: ptr.To(true)}),
+				withConntrack("low", 0, connectivityv1.ConntrackSettings{DropInvalid: ptr.To(false), Related: ptr.To(false)}),
+			)
+			Expect(names).To(HaveLen(len(opts.Gateway.UplinkInterfaces) + 3))
+			Expect(names[:3]).To(Equal([]string{"drop-invalid", "allow-established", "match-tunnel-interface"}))
+		})
+
+		It("should omit the established rule when the merged mode is stateless", func() {
+			names := preambleRuleNames(
+				withConntrack("high", 10, connectivityv1.ConntrackSettings{Mode: connectivityv1.ConntrackModeStateless}),
+				withConntrack("low", 0, connectivityv1.ConntrackSettings{Mode: connectivityv1.ConntrackModeStateful}),
+			)
+			Expect(names).NotTo(ContainElement(HavePrefix("allow-established")))
+		})
+
+		It("should omit the established rule when the existing connections are re-evaluated", func() {
+			names := preambleRuleNames(
+				withConntrack("high", 10, connectivityv1.ConntrackSettings{DropInvalid: ptr.To(true)}),
+				withConntrack("low", 0, connectivityv1.ConntrackSettings{
+					ExistingConnections: connectivityv1.ExistingConnectionsReevaluate,
+				}),
+			)
+			Expect(names).To(ContainElement("drop-invalid"))
+			Expect(names).NotTo(ContainElement(HavePrefix("allow-established")))
+		})
+	})
+})